@@ -0,0 +1,119 @@
+// Command gradcheck checks the gradients of the built in layers, activations
+// and losses against finite differences and prints the largest relative error
+// of every checked tensor.
+//
+//	go run ./cmd/gradcheck
+package main
+
+import (
+	"fmt"
+
+	a "github.com/cangeroe7/giraffe/pgk/activations"
+	"github.com/cangeroe7/giraffe/pgk/gradcheck"
+	l "github.com/cangeroe7/giraffe/pgk/layers"
+	lo "github.com/cangeroe7/giraffe/pgk/losses"
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+func main() {
+	t.SetSeed(0)
+
+	fmt.Println("Layers")
+	layers := []struct {
+		name    string
+		layer   l.Layer
+		inShape t.Shape
+		batch   t.Shape
+	}{
+		{"Dense, relu", &l.Dense{Units: 4, Activation: &a.Relu{}}, t.Shape{1, 5}, t.Shape{3, 5}},
+		{"Dense, sigmoid", &l.Dense{Units: 4, Activation: &a.Sigmoid{}}, t.Shape{1, 5}, t.Shape{3, 5}},
+		{"Dense, softmax", &l.Dense{Units: 4, Activation: &a.Softmax{}}, t.Shape{1, 5}, t.Shape{3, 5}},
+		{"Conv2D, valid", &l.Conv2D{Filters: 2, KernelSize: [2]int{3, 3}, Strides: [2]int{1, 1}, Mode: l.Valid, Activation: &a.Sigmoid{}}, t.Shape{2, 6, 6}, t.Shape{2, 2, 6, 6}},
+		{"Conv2D, valid, strides 2", &l.Conv2D{Filters: 2, KernelSize: [2]int{3, 3}, Strides: [2]int{2, 2}, Mode: l.Valid, Activation: &a.Sigmoid{}}, t.Shape{2, 7, 7}, t.Shape{2, 2, 7, 7}},
+		{"Conv2D, full", &l.Conv2D{Filters: 2, KernelSize: [2]int{3, 3}, Strides: [2]int{1, 1}, Mode: l.Full, Activation: &a.Sigmoid{}}, t.Shape{2, 5, 5}, t.Shape{2, 2, 5, 5}},
+		{"Pooling, max", &l.Pooling{PoolType: l.MaxPooling, KernelSize: [2]int{2, 2}, Strides: [2]int{2, 2}, Mode: l.Valid}, t.Shape{2, 4, 4}, t.Shape{2, 2, 4, 4}},
+		{"Pooling, avg", &l.Pooling{PoolType: l.AvgPooling, KernelSize: [2]int{2, 2}, Strides: [2]int{2, 2}, Mode: l.Valid}, t.Shape{2, 4, 4}, t.Shape{2, 2, 4, 4}},
+		{"Flatten", &l.Flatten{}, t.Shape{2, 3, 3}, t.Shape{2, 2, 3, 3}},
+	}
+
+	for _, tc := range layers {
+		if _, err := tc.layer.CompileLayer(tc.inShape); err != nil {
+			report(tc.name, nil, err)
+			continue
+		}
+
+		input, _ := t.RandTensor(tc.batch, -1, 1)
+		result, err := check(func() (gradcheck.Result, error) {
+			return gradcheck.Layer(tc.layer, input)
+		})
+		report(tc.name, result, err)
+	}
+
+	fmt.Println("Activations")
+	activations := []struct {
+		name       string
+		activation a.Activation
+	}{
+		{"relu", &a.Relu{}},
+		{"sigmoid", &a.Sigmoid{}},
+		{"softmax", &a.Softmax{}},
+	}
+
+	for _, tc := range activations {
+		input, _ := t.RandTensor(t.Shape{3, 4}, -2, 2)
+		result, err := check(func() (gradcheck.Result, error) {
+			return gradcheck.Activation(tc.activation, input)
+		})
+		report(tc.name, result, err)
+	}
+
+	fmt.Println("Losses")
+	probabilities, _ := (&a.Softmax{}).Forward(must(t.RandTensor(t.Shape{3, 4}, -1, 1)))
+	oneHot, _ := t.TensorFrom([]int{3, 4}, []float64{0, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0, 1})
+	binary, _ := t.TensorFrom([]int{3, 4}, []float64{0, 1, 1, 0, 1, 0, 0, 1, 0, 1, 0, 1})
+	losses := []struct {
+		name  string
+		loss  lo.Loss
+		yTrue t.Tensor
+	}{
+		{"mean square error", &lo.MeanSquareError{}, binary},
+		{"binary cross entropy", &lo.BinaryCrossEntropy{}, binary},
+		{"categorical crossentropy", &lo.CategoricalCrossentropy{}, oneHot},
+	}
+
+	for _, tc := range losses {
+		result, err := check(func() (gradcheck.Result, error) {
+			return gradcheck.Loss(tc.loss, tc.yTrue, probabilities)
+		})
+		report(tc.name, result, err)
+	}
+}
+
+// check turns panics in the checked code into errors, so one broken layer
+// doesn't stop the others from being checked.
+func check(fn func() (gradcheck.Result, error)) (result gradcheck.Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn()
+}
+
+func report(name string, result gradcheck.Result, err error) {
+	switch {
+	case err != nil:
+		fmt.Printf("  %-26s error: %v\n", name, err)
+	case result.Max() > 1e-4:
+		fmt.Printf("  %-26s %v  MISMATCH\n", name, result)
+	default:
+		fmt.Printf("  %-26s %v\n", name, result)
+	}
+}
+
+func must(ten t.Tensor, err error) t.Tensor {
+	if err != nil {
+		panic(err)
+	}
+	return ten
+}
@@ -2,6 +2,8 @@ package activations
 
 import (
 	"fmt"
+	"math"
+
 	t "github.com/cangeroe7/giraffe/pgk/tensor"
 )
 
@@ -19,15 +21,8 @@ func (a *Relu) Type() string {
 
 func (a *Relu) Forward(input t.Tensor) (t.Tensor, error) {
 	a.input = input
-	relu := func(x float64) (float64, error) {
-		if x > 0 {
-			return x, nil
-		} else {
-			return 0.0, nil
-		}
-	}
 
-	output, err := input.Map(relu, false)
+	output, err := input.Clip(0, math.Inf(1), false)
 
 	if err != nil {
 		return nil, err
@@ -37,20 +32,15 @@ func (a *Relu) Forward(input t.Tensor) (t.Tensor, error) {
 }
 
 func (a *Relu) Backward(gradient t.Tensor) (t.Tensor, error) {
-	reluPrime := func(x float64) (float64, error) {
-		if x > 0 {
-			return 1.0, nil
-		} else {
-			return 0.0, nil
-		}
-	}
-	deactivated, err := a.input.Map(reluPrime, false)
+	// The gradient passes where the input was positive
+	deactivated, err := a.input.Greater(t.Zeros(t.Shape{1}, a.input.DType()))
 	if err != nil {
-		fmt.Printf("err after map in relu: %v\n", err)
+		fmt.Printf("err after mask in relu: %v\n", err)
 		return nil, err
 	}
 
 	outGradient, err := gradient.Multiply(deactivated, false)
+	t.Release(deactivated)
 	if err != nil {
 		fmt.Printf("err in creating output gradient for relu: %v\n", err)
 		return nil, err
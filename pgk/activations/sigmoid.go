@@ -2,7 +2,6 @@ package activations
 
 import (
 	"fmt"
-	"math"
 
 	t "github.com/cangeroe7/giraffe/pgk/tensor"
 )
@@ -23,34 +22,25 @@ func (a *Sigmoid) Forward(input t.Tensor) (t.Tensor, error) {
 
 	a.input = input
 
-	sigmoid := func(x float64) (float64, error) {
-		output := 1 / (1 + math.Exp(-x))
-		return output, nil
-	}
-
-	output, err := input.Map(sigmoid, false)
-	if err != nil {
-		return nil, err
-	}
-
-	return output, nil
+	return sigmoidOf(input), nil
 }
 
 func (a *Sigmoid) Backward(gradient t.Tensor) (t.Tensor, error) {
 
-	sigmoidPrime := func(x float64) (float64, error) {
-		sigmoid := 1 / (1 + math.Exp(-x))
-		output := sigmoid * (1 - sigmoid)
-		return output, nil
-	}
+	// sigmoid'(x) = sigmoid(x) * (1 - sigmoid(x))
+	sigmoid := sigmoidOf(a.input)
+	oneMinus := sigmoid.ScalarMultiply(-1, false)
+	oneMinus.ScalarAdd(1, true)
 
-	primeInput, err := a.input.Map(sigmoidPrime, false)
+	primeInput, err := sigmoid.Multiply(oneMinus, true)
+	t.Release(oneMinus)
 	if err != nil {
 		fmt.Printf("err: %v\n", err)
 		return nil, err
 	}
 
 	outGradient, err := gradient.Multiply(primeInput, false)
+	t.Release(sigmoid)
 	if err != nil {
 		fmt.Printf("err: %v\n", err)
 		return nil, err
@@ -58,3 +48,10 @@ func (a *Sigmoid) Backward(gradient t.Tensor) (t.Tensor, error) {
 
 	return outGradient, nil
 }
+
+// sigmoidOf computes 1 / (1 + e^-x) for every value of input.
+func sigmoidOf(input t.Tensor) t.Tensor {
+	output := input.ScalarMultiply(-1, false).Exp(true)
+	output.ScalarAdd(1, true)
+	return output.Pow(-1, true)
+}
@@ -3,7 +3,6 @@ package activations
 import (
 	"errors"
 	"fmt"
-	"math"
 
 	t "github.com/cangeroe7/giraffe/pgk/tensor"
 )
@@ -22,21 +21,15 @@ func (a *Softmax) Type() string {
 
 func (a *Softmax) Forward(input t.Tensor) (t.Tensor, error) {
 
-	batchIter, err := t.IterFromTensor(input, "rows")
+	batchIter, err := t.IterRows(input)
 	if err != nil {
 		return nil, err
 	}
 	for batch, ok := batchIter.Next(); ok; batch, ok = batchIter.Next() {
 
-		maxVal := batch.Max()
-		softmax := func(x float64) (float64, error) {
-			return math.Exp(x - maxVal), nil
-		}
-
-		expValues, err := batch.Map(softmax, true)
-		if err != nil {
-			return nil, err
-		}
+		// Shift by the max so exp can't overflow
+		batch.ScalarSubtract(batch.Max(), true)
+		expValues := batch.Exp(true)
 
 		batchSum := expValues.Sum()
 		_, err = expValues.ScalarDivide(batchSum, true)
@@ -54,19 +47,19 @@ func (a *Softmax) Backward(gradient t.Tensor) (t.Tensor, error) {
 
 	softmax := a.output
 
-	outputGradient := t.ZerosTensor(gradient.Shape().Clone())
+	outputGradient := t.Zeros(gradient.Shape().Clone(), gradient.DType())
 
-	softmaxIter, err := t.IterFromTensor(softmax, "rows")
+	softmaxIter, err := t.IterRows(softmax)
 	if err != nil {
 		return nil, err
 	}
 
-	outGradientIter, err := t.IterFromTensor(outputGradient, "rows")
+	outGradientIter, err := t.IterRows(outputGradient)
 	if err != nil {
 		return nil, err
 	}
 
-	gradientIter, err := t.IterFromTensor(gradient, "rows")
+	gradientIter, err := t.IterRows(gradient)
 	if err != nil {
 		return nil, err
 	}
@@ -87,10 +80,10 @@ func (a *Softmax) Backward(gradient t.Tensor) (t.Tensor, error) {
 
 		identity, _ := t.Identity(size)
 
-		// No broadcast/repadd function for subtraction
-		row.ScalarMultiply(-1.0, true)
+		// Negated copy, rowT is a view of the same row
+		negRow := row.ScalarMultiply(-1.0, false)
 
-		identity.RepAdd(row, true)
+		identity.RepAdd(negRow, true)
 
 		identity.RepMultiply(rowT, true)
 
@@ -0,0 +1,279 @@
+// Package gradcheck compares the gradients computed by hand written Backward
+// and Gradient methods with central finite differences, to catch mistakes
+// that would otherwise only show as a model that trains badly.
+//
+// Layers and activations are checked through the scalar sum(output * p), with
+// p a fixed random tensor in the shape of the output, so Backward gets p as
+// its gradient. Losses are checked through CalcLoss directly. Use float64
+// layers and inputs, float32 is too coarse for finite differences.
+package gradcheck
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	a "github.com/cangeroe7/giraffe/pgk/activations"
+	l "github.com/cangeroe7/giraffe/pgk/layers"
+	lo "github.com/cangeroe7/giraffe/pgk/losses"
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+// Checker holds the settings of a gradient check, the zero value uses the
+// defaults.
+type Checker struct {
+	Epsilon float64 // Step of the finite differences, defaults to 1e-6
+	Floor   float64 // Smallest denominator of the relative errors, defaults to 1e-8
+	RNG     *t.RNG  // Draws the projection of the output, defaults to the global RNG
+}
+
+// Result holds the largest relative error |analytical - numerical| /
+// max(|analytical|, |numerical|) of every checked tensor, under "input",
+// "weights" and "biases" for layers and activations and "yPred" for losses.
+// Errors below about 1e-6 mean the gradient is right.
+type Result map[string]float64
+
+// Max returns the largest error of all checked tensors.
+func (r Result) Max() float64 {
+	worst := 0.0
+	for _, err := range r {
+		worst = math.Max(worst, err)
+	}
+	return worst
+}
+
+func (r Result) String() string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %.3e", name, r[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Layer checks the input, weights and biases gradients of a compiled layer
+// for the given input. Layers without weights or biases only get the input
+// checked.
+func Layer(layer l.Layer, input t.Tensor) (Result, error) {
+	return (&Checker{}).Layer(layer, input)
+}
+
+// Activation checks the input gradient of an activation for the given input.
+func Activation(activation a.Activation, input t.Tensor) (Result, error) {
+	return (&Checker{}).Activation(activation, input)
+}
+
+// Loss checks the gradient of a loss with respect to yPred.
+func Loss(loss lo.Loss, yTrue, yPred t.Tensor) (Result, error) {
+	return (&Checker{}).Loss(loss, yTrue, yPred)
+}
+
+// Layer checks the input, weights and biases gradients of a compiled layer
+// for the given input.
+func (c *Checker) Layer(layer l.Layer, input t.Tensor) (Result, error) {
+	if layer == nil || input == nil {
+		return nil, errors.New("layer and input cannot be nil")
+	}
+
+	// Every forward pass gets a copy, as Forward may change its input
+	x := input.AsType(t.Float64)
+	forward := func() (t.Tensor, error) {
+		return layer.Forward(x.AsType(t.Float64))
+	}
+
+	projection, err := c.projection(forward)
+	if err != nil {
+		return nil, err
+	}
+
+	inputGradient, err := layer.Backward(projection)
+	if err != nil {
+		return nil, fmt.Errorf("backward: %w", err)
+	}
+
+	objective := func() (float64, error) {
+		return project(forward, projection)
+	}
+
+	result := Result{}
+	checks := []struct {
+		name     string
+		param    t.Tensor
+		gradient t.Tensor
+	}{
+		{"input", x, inputGradient},
+		{"weights", layer.Weights(), layer.WeightsGradient()},
+		{"biases", layer.Biases(), layer.BiasesGradient()},
+	}
+
+	// Read the gradients before the finite differences run the layer again
+	analytical := make([][]float64, len(checks))
+	for i, check := range checks {
+		if check.param == nil {
+			continue
+		}
+		if check.gradient == nil {
+			return nil, fmt.Errorf("%s gradient is nil", check.name)
+		}
+		analytical[i] = t.Values[float64](check.gradient)
+	}
+
+	for i, check := range checks {
+		if check.param == nil {
+			continue
+		}
+
+		result[check.name], err = c.compare(check.name, check.param, analytical[i], objective)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// Activation checks the input gradient of an activation for the given input.
+func (c *Checker) Activation(activation a.Activation, input t.Tensor) (Result, error) {
+	if activation == nil || input == nil {
+		return nil, errors.New("activation and input cannot be nil")
+	}
+
+	x := input.AsType(t.Float64)
+	forward := func() (t.Tensor, error) {
+		return activation.Forward(x.AsType(t.Float64))
+	}
+
+	projection, err := c.projection(forward)
+	if err != nil {
+		return nil, err
+	}
+
+	inputGradient, err := activation.Backward(projection)
+	if err != nil {
+		return nil, fmt.Errorf("backward: %w", err)
+	}
+	if inputGradient == nil {
+		return nil, errors.New("input gradient is nil")
+	}
+
+	inputError, err := c.compare("input", x, t.Values[float64](inputGradient), func() (float64, error) {
+		return project(forward, projection)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return Result{"input": inputError}, nil
+}
+
+// Loss checks the gradient of a loss with respect to yPred.
+func (c *Checker) Loss(loss lo.Loss, yTrue, yPred t.Tensor) (Result, error) {
+	if loss == nil || yTrue == nil || yPred == nil {
+		return nil, errors.New("loss, yTrue and yPred cannot be nil")
+	}
+
+	pred := yPred.AsType(t.Float64)
+	gradient, err := loss.Gradient(yTrue, pred)
+	if err != nil {
+		return nil, fmt.Errorf("gradient: %w", err)
+	}
+	if gradient == nil {
+		return nil, errors.New("yPred gradient is nil")
+	}
+
+	predError, err := c.compare("yPred", pred, t.Values[float64](gradient), func() (float64, error) {
+		return loss.CalcLoss(yTrue, pred)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return Result{"yPred": predError}, nil
+}
+
+// projection runs the forward pass once and draws the random tensor its
+// output is projected on.
+func (c *Checker) projection(forward func() (t.Tensor, error)) (t.Tensor, error) {
+	output, err := forward()
+	if err != nil {
+		return nil, fmt.Errorf("forward: %w", err)
+	}
+
+	return c.RNG.OrGlobal().RandTensor(output.Shape(), -1, 1)
+}
+
+// project runs the forward pass and returns sum(output * projection).
+func project(forward func() (t.Tensor, error), projection t.Tensor) (float64, error) {
+	output, err := forward()
+	if err != nil {
+		return 0, fmt.Errorf("forward: %w", err)
+	}
+
+	outValues, projValues := t.Values[float64](output), t.Values[float64](projection)
+	if len(outValues) != len(projValues) {
+		return 0, fmt.Errorf("output changed size from %d to %d", len(projValues), len(outValues))
+	}
+
+	sum := 0.0
+	for i, val := range outValues {
+		sum += val * projValues[i]
+	}
+	return sum, nil
+}
+
+// compare nudges every value of param in place by plus and minus epsilon and
+// returns the largest relative error between the change of objective and the
+// analytical gradient.
+func (c *Checker) compare(name string, param t.Tensor, analytical []float64, objective func() (float64, error)) (float64, error) {
+	if len(analytical) != param.Size() {
+		return 0, fmt.Errorf("%s gradient has %d values for %d parameters", name, len(analytical), param.Size())
+	}
+
+	epsilon := c.Epsilon
+	if epsilon <= 0 {
+		epsilon = 1e-6
+	}
+	floor := c.Floor
+	if floor <= 0 {
+		floor = 1e-8
+	}
+
+	worst := 0.0
+	for i := 0; i < param.Size(); i++ {
+		orig := param.ValueAt(i)
+
+		param.SetValueAt(i, orig+epsilon)
+		plus, err := objective()
+		if err != nil {
+			return 0, err
+		}
+
+		param.SetValueAt(i, orig-epsilon)
+		minus, err := objective()
+		if err != nil {
+			return 0, err
+		}
+
+		param.SetValueAt(i, orig)
+
+		numerical := (plus - minus) / (2 * epsilon)
+		diff := math.Abs(analytical[i] - numerical)
+		scale := math.Max(math.Max(math.Abs(analytical[i]), math.Abs(numerical)), floor)
+
+		// NaN counts as the worst error
+		relErr := diff / scale
+		if math.IsNaN(relErr) {
+			relErr = math.Inf(1)
+		}
+		worst = math.Max(worst, relErr)
+	}
+
+	return worst, nil
+}
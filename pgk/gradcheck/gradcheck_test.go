@@ -0,0 +1,195 @@
+package gradcheck
+
+import (
+	"testing"
+
+	a "github.com/cangeroe7/giraffe/pgk/activations"
+	l "github.com/cangeroe7/giraffe/pgk/layers"
+	lo "github.com/cangeroe7/giraffe/pgk/losses"
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+// tolerance is the largest error a correct gradient may have.
+const tolerance = 1e-5
+
+// wrongDense is a Dense layer whose Backward returns twice the input
+// gradient, the kind of mistake the checker has to catch.
+type wrongDense struct {
+	*l.Dense
+}
+
+func (d *wrongDense) Backward(gradient t.Tensor) (t.Tensor, error) {
+	inputGradient, err := d.Dense.Backward(gradient)
+	if err != nil {
+		return nil, err
+	}
+	return inputGradient.ScalarMultiply(2, false), nil
+}
+
+func compiledDense(test *testing.T) (*l.Dense, t.Tensor) {
+	dense := &l.Dense{Units: 4, Activation: &a.Sigmoid{}}
+	if _, err := dense.CompileLayer(t.Shape{1, 5}); err != nil {
+		test.Fatal(err)
+	}
+
+	input, err := t.NewRNG(1).RandTensor(t.Shape{3, 5}, -1, 1)
+	if err != nil {
+		test.Fatal(err)
+	}
+	return dense, input
+}
+
+func TestLayerCorrect(test *testing.T) {
+	dense, input := compiledDense(test)
+
+	result, err := (&Checker{RNG: t.NewRNG(2)}).Layer(dense, input)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	for _, name := range []string{"input", "weights", "biases"} {
+		if _, ok := result[name]; !ok {
+			test.Errorf("%s was not checked", name)
+		}
+	}
+	if result.Max() > tolerance {
+		test.Errorf("errors %v, want all below %v", result, tolerance)
+	}
+}
+
+func TestLayerWrongBackward(test *testing.T) {
+	dense, input := compiledDense(test)
+
+	result, err := (&Checker{RNG: t.NewRNG(2)}).Layer(&wrongDense{dense}, input)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	// Twice the gradient is off by half of the larger of the two
+	if result["input"] < 0.3 {
+		test.Errorf("input error %v, want a large error for a doubled gradient", result["input"])
+	}
+	if result["weights"] > tolerance || result["biases"] > tolerance {
+		test.Errorf("errors %v, only the input gradient is wrong", result)
+	}
+}
+
+// affine is tanh(x*W + b) written as a Module, its gradients come from the
+// tape of an AutogradLayer.
+type affine struct {
+	units int
+}
+
+func (m *affine) Build(inShape t.Shape) (weights, biases t.Tensor, outShape t.Shape, err error) {
+	rng := t.NewRNG(4)
+	if weights, err = rng.RandTensor(t.Shape{inShape.Cols(), m.units}, -1, 1); err != nil {
+		return nil, nil, nil, err
+	}
+	if biases, err = rng.RandTensor(t.Shape{1, m.units}, -1, 1); err != nil {
+		return nil, nil, nil, err
+	}
+	return weights, biases, t.Shape{1, m.units}, nil
+}
+
+func (m *affine) Forward(tape *t.Tape, input, weights, biases *t.Variable) (*t.Variable, error) {
+	product, err := tape.MatMul(input, weights)
+	if err != nil {
+		return nil, err
+	}
+
+	shifted, err := tape.Add(product, biases)
+	if err != nil {
+		return nil, err
+	}
+	return tape.Tanh(shifted)
+}
+
+func (m *affine) Type() string {
+	return "Affine"
+}
+
+func (m *affine) Params() map[string]interface{} {
+	return map[string]interface{}{"units": m.units}
+}
+
+func TestAutogradLayer(test *testing.T) {
+	layer := &l.AutogradLayer{Module: &affine{units: 3}}
+	if _, err := layer.CompileLayer(t.Shape{1, 5}); err != nil {
+		test.Fatal(err)
+	}
+
+	input, _ := t.NewRNG(5).RandTensor(t.Shape{4, 5}, -1, 1)
+	result, err := (&Checker{RNG: t.NewRNG(6)}).Layer(layer, input)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	for _, name := range []string{"input", "weights", "biases"} {
+		if _, ok := result[name]; !ok {
+			test.Errorf("%s was not checked", name)
+		}
+	}
+	if result.Max() > tolerance {
+		test.Errorf("errors %v, want all below %v", result, tolerance)
+	}
+}
+
+// A loss gives the gradient in the layout of the labels, like (1, 1, n, k) for
+// an output of (n, k), which the tape has to get as (n, k).
+func TestAutogradLayerGradientLayout(test *testing.T) {
+	layer := &l.AutogradLayer{Module: &affine{units: 3}}
+	if _, err := layer.CompileLayer(t.Shape{1, 5}); err != nil {
+		test.Fatal(err)
+	}
+
+	input, _ := t.NewRNG(5).RandTensor(t.Shape{4, 5}, -1, 1)
+	gradient, _ := t.NewRNG(6).RandTensor(t.Shape{4, 3}, -1, 1)
+
+	backward := func(shape t.Shape) []float64 {
+		if _, err := layer.Forward(input); err != nil {
+			test.Fatal(err)
+		}
+
+		laidOut, _ := t.TensorFrom(shape, gradient.DataCopy())
+		inputGradient, err := layer.Backward(laidOut)
+		if err != nil {
+			test.Fatalf("gradient of shape %v: %v", shape, err)
+		}
+		return inputGradient.DataCopy()
+	}
+
+	want := backward(t.Shape{4, 3})
+	for i, val := range backward(t.Shape{1, 1, 4, 3}) {
+		if val != want[i] {
+			test.Fatalf("input gradient %d is %v, want %v", i, val, want[i])
+		}
+	}
+
+	if _, err := layer.Backward(t.Zeros(t.Shape{4, 4}, t.Float64)); err == nil {
+		test.Error("gradient of the wrong size: expected an error")
+	}
+}
+
+func TestActivationAndLoss(test *testing.T) {
+	rng := t.NewRNG(3)
+	checker := &Checker{RNG: rng}
+
+	input, _ := rng.RandTensor(t.Shape{3, 4}, -2, 2)
+	result, err := checker.Activation(&a.Softmax{}, input)
+	if err != nil {
+		test.Fatal(err)
+	}
+	if result.Max() > tolerance {
+		test.Errorf("softmax errors %v, want all below %v", result, tolerance)
+	}
+
+	yTrue, _ := t.TensorFrom([]int{3, 4}, []float64{0, 1, 1, 0, 1, 0, 0, 1, 0, 1, 0, 1})
+	yPred, _ := rng.RandTensor(t.Shape{3, 4}, 0.1, 0.9)
+	result, err = checker.Loss(&lo.MeanSquareError{}, yTrue, yPred)
+	if err != nil {
+		test.Fatal(err)
+	}
+	if result.Max() > tolerance {
+		test.Errorf("mean square error errors %v, want all below %v", result, tolerance)
+	}
+}
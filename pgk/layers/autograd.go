@@ -0,0 +1,149 @@
+package layers
+
+import (
+	"errors"
+
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+// Module describes a layer by its forward pass only. Wrapped in an
+// AutogradLayer the forward pass is recorded on a tape and the gradients for
+// the input, weights and biases are derived from it.
+type Module interface {
+	// Build creates the parameters for the given input shape, weights and
+	// biases can be nil when the module has none.
+	Build(inShape t.Shape) (weights, biases t.Tensor, outShape t.Shape, err error)
+	Forward(tape *t.Tape, input, weights, biases *t.Variable) (*t.Variable, error)
+	Type() string
+	Params() map[string]interface{}
+}
+
+type AutogradLayer struct {
+	Module Module
+
+	tape   *t.Tape
+	input  *t.Variable
+	output *t.Variable
+
+	weightsVar *t.Variable
+	biasesVar  *t.Variable
+
+	weights         t.Tensor
+	biases          t.Tensor
+	weightsGradient t.Tensor
+	biasesGradient  t.Tensor
+}
+
+func (l *AutogradLayer) Type() string {
+	return l.Module.Type()
+}
+
+func (l *AutogradLayer) Params() map[string]interface{} {
+	return l.Module.Params()
+}
+
+func (l *AutogradLayer) CompileLayer(inShape t.Shape) (t.Shape, error) {
+	if l.Module == nil {
+		return nil, errors.New("autograd layer needs a module")
+	}
+
+	weights, biases, outShape, err := l.Module.Build(inShape)
+	if err != nil {
+		return nil, err
+	}
+
+	l.weights = weights
+	l.biases = biases
+
+	return outShape, nil
+}
+
+func (l *AutogradLayer) Forward(input t.Tensor) (t.Tensor, error) {
+	if input == nil {
+		return nil, errors.New("input cannot be nil")
+	}
+
+	l.tape = t.NewTape()
+	l.input = l.tape.Variable(input)
+	l.weightsVar, l.biasesVar = nil, nil
+
+	if l.weights != nil {
+		l.weightsVar = l.tape.Variable(l.weights)
+	}
+
+	if l.biases != nil {
+		l.biasesVar = l.tape.Variable(l.biases)
+	}
+
+	output, err := l.Module.Forward(l.tape, l.input, l.weightsVar, l.biasesVar)
+	if err != nil {
+		return nil, err
+	}
+
+	l.output = output
+
+	return output.Value, nil
+}
+
+func (l *AutogradLayer) Backward(gradient t.Tensor) (t.Tensor, error) {
+	if gradient == nil {
+		return nil, errors.New("gradient cannot be nil")
+	}
+
+	if l.tape == nil {
+		return nil, errors.New("backward called before forward")
+	}
+
+	// Gradients from other layers and losses can hold the same values in
+	// another layout, like (1, 1, n, k) for an output of (n, k)
+	output := l.output.Value
+	if !gradient.Shape().Eq(output.Shape()) && gradient.Size() == output.Size() {
+		reshaped, err := t.FromSlice(output.Shape().Clone(), gradient.DataCopy())
+		if err != nil {
+			return nil, err
+		}
+
+		if reshaped.DType() != gradient.DType() {
+			reshaped = reshaped.AsType(gradient.DType())
+		}
+		gradient = reshaped
+	}
+
+	if err := l.tape.Backward(l.output, gradient); err != nil {
+		return nil, err
+	}
+
+	l.weightsGradient, l.biasesGradient = nil, nil
+
+	if l.weightsVar != nil {
+		l.weightsGradient = l.weightsVar.Grad
+	}
+
+	if l.biasesVar != nil {
+		l.biasesGradient = l.biasesVar.Grad
+	}
+
+	inputGradient := l.input.Grad
+	if inputGradient == nil {
+		// The output did not depend on the input
+		inputGradient = t.Zeros(l.input.Value.Shape(), l.input.Value.DType())
+	}
+
+	return inputGradient, nil
+}
+
+func (l *AutogradLayer) Weights() t.Tensor {
+	return l.weights
+}
+
+func (l *AutogradLayer) Biases() t.Tensor {
+	return l.biases
+}
+
+func (l *AutogradLayer) WeightsGradient() t.Tensor {
+	return l.weightsGradient
+}
+
+func (l *AutogradLayer) BiasesGradient() t.Tensor {
+	return l.biasesGradient
+}
@@ -2,7 +2,6 @@ package layers
 
 import (
 	"errors"
-	"fmt"
 	"math"
 
 	a "github.com/cangeroe7/giraffe/pgk/activations"
@@ -14,10 +13,14 @@ type Conv2D struct {
 	KernelSize [2]int
 	Strides    [2]int
 	Mode       PaddingMode
+	DType      t.DType // Defaults to tensor.DefaultDType()
+	RNG        *t.RNG  // Initializes the weights, defaults to the global RNG
 
 	padding []int
 
-	input t.Tensor
+	// Patches of the padded input from Im2Col
+	cols     t.Tensor
+	padShape t.Shape
 
 	inShape         t.Shape
 	outShape        t.Shape
@@ -40,6 +43,7 @@ func (c *Conv2D) Params() map[string]interface{} {
 		"strides":     c.Strides,
 		"mode":        c.Mode,
 		"padding":     c.padding,
+		"dtype":       c.DType,
 	}
 }
 
@@ -48,6 +52,10 @@ func (c *Conv2D) CompileLayer(inShape t.Shape) (t.Shape, error) {
 		return nil, errors.New("Must be 1 or more filters")
 	}
 
+	if c.DType == "" {
+		c.DType = t.DefaultDType()
+	}
+
 	// Check kernel sizes and sets default if needed
 	switch {
 	case c.KernelSize[0] < 0 || c.KernelSize[1] < 0:
@@ -82,7 +90,7 @@ func (c *Conv2D) CompileLayer(inShape t.Shape) (t.Shape, error) {
 	c.padding = padding
 
 	// Initialize biases to zero: 1 per filter
-	c.biases = t.ZerosTensor([]int{1, c.Filters})
+	c.biases = t.Zeros([]int{1, c.Filters}, c.DType)
 
 	// Compute output shape
 	outHeight := (padding[0]+padding[2]+inShape.Rows()-c.KernelSize[0])/c.Strides[0] + 1
@@ -95,11 +103,15 @@ func (c *Conv2D) CompileLayer(inShape t.Shape) (t.Shape, error) {
 	limit := math.Sqrt(6 / float64(inShape.Channels()+c.Filters))
 
 	// Initialize weights/kernels to random value between -limit and limit
-	c.weights, err = t.RandTensor([]int{c.Filters, inShape.Channels(), c.KernelSize[0], c.KernelSize[1]}, -limit, limit)
+	c.weights, err = c.RNG.OrGlobal().RandTensor([]int{c.Filters, inShape.Channels(), c.KernelSize[0], c.KernelSize[1]}, -limit, limit)
 	if err != nil {
 		return nil, err
 	}
 
+	if c.weights.DType() != c.DType {
+		c.weights = c.weights.AsType(c.DType)
+	}
+
 	// Default activation function
 	if c.Activation == nil {
 		c.Activation = &a.Relu{}
@@ -109,6 +121,11 @@ func (c *Conv2D) CompileLayer(inShape t.Shape) (t.Shape, error) {
 }
 
 func (c *Conv2D) Forward(input t.Tensor) (t.Tensor, error) {
+	// Compute in the precision of the weights
+	if input.DType() != c.DType {
+		input = input.AsType(c.DType)
+	}
+
 	c.inShape = input.Shape().Clone()
 
 	padInput, err := input.Pad(c.padding...)
@@ -116,47 +133,77 @@ func (c *Conv2D) Forward(input t.Tensor) (t.Tensor, error) {
 		return nil, err
 	}
 
-	c.input = padInput
+	c.padShape = padInput.Shape().Clone()
+	outHeight := (c.padShape.Rows()-c.KernelSize[0])/c.Strides[0] + 1
+	outWidth := (c.padShape.Cols()-c.KernelSize[1])/c.Strides[1] + 1
 
-	outHeight := (padInput.Shape().Rows()-c.KernelSize[0])/c.Strides[0] + 1
-	outWidth := (padInput.Shape().Cols()-c.KernelSize[1])/c.Strides[1] + 1
+	// The patches of the last forward pass are not needed anymore
+	t.Release(c.cols)
 
-	resTen := t.ZerosTensor([]int{input.Shape().Batches(), c.Filters, outHeight, outWidth})
+	// Unfold the patches of the input, so applying every filter to a batch is
+	// a single matrix multiplication
+	c.cols, err = padInput.Im2Col(c.KernelSize, c.Strides)
+	if err != nil {
+		return nil, err
+	}
+
+	if padInput != input {
+		t.Release(padInput)
+	}
+
+	resTen := t.Zeros([]int{input.Shape().Batches(), c.Filters, outHeight, outWidth}, c.DType)
+
+	kernels, err := c.kernelMatrix()
+	if err != nil {
+		return nil, err
+	}
+	defer t.Release(kernels)
 
-	inIter, err := t.IterFromTensor(padInput, "batches")
+	colsIter, err := t.IterAxes(c.cols, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	resIter, err := t.IterFromTensor(resTen, "mat")
+	resIter, err := t.IterAxes(resTen, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	for inBatch, ok := inIter.Next(); ok; inBatch, ok = inIter.Next() {
+	for colsBatch, ok := colsIter.Next(); ok; colsBatch, ok = colsIter.Next() {
+		resBatch, ok := resIter.Next()
+		if !ok {
+			return nil, errors.New("result Tensor doesn't have enough batches for convolved input")
+		}
 
-		filterIter, err := t.IterFromTensor(c.weights, "batches")
+		// (filters, patches)
+		filtered, err := kernels.MatMul(colsBatch.Transpose(false))
 		if err != nil {
 			return nil, err
 		}
 
-		currentBias := 0
-		for filter, ok := filterIter.Next(); ok; filter, ok = filterIter.Next() {
-			resMat, ok := resIter.Next()
+		err = filtered.Reshape([]int{c.Filters, outHeight, outWidth})
+		if err != nil {
+			return nil, err
+		}
 
-			if !ok {
-				return nil, errors.New("result Tensor doesn't have enough matrices for convolved input")
-			}
+		_, err = resBatch.Add(filtered, true)
+		t.Release(filtered)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-			_, err := inBatch.CrossCorrelate(filter, c.Strides, resMat)
-			if err != nil {
-				return nil, err
-			}
+	// Add the bias of every filter
+	biases := c.biases.AsType(c.DType)
+	err = biases.Reshape([]int{c.Filters, 1, 1})
+	if err != nil {
+		return nil, err
+	}
 
-			// Add the bias
-			resMat.ScalarAdd(c.biases.ValueAt(currentBias), true)
-			currentBias++
-		}
+	_, err = resTen.Add(biases, true)
+	t.Release(biases)
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply the activation function
@@ -170,13 +217,24 @@ func (c *Conv2D) Forward(input t.Tensor) (t.Tensor, error) {
 
 func (c *Conv2D) Backward(gradient t.Tensor) (t.Tensor, error) {
 
-	gradient, err := c.Activation.Backward(gradient)
+	activationGradient, err := c.Activation.Backward(gradient)
 	if err != nil {
 		return nil, err
 	}
 
+	// The activation gradient is only needed here
+	if activationGradient != gradient {
+		defer t.Release(activationGradient)
+	}
+	gradient = activationGradient
+
 	if gradient.Shape().Channels() != c.Filters {
-		return nil, errors.New("gradient shape does not match the output shape")
+		// Every filter gives a channel of the output
+		expected := gradient.Shape().Clone()
+		if expected.Dims() >= 3 {
+			expected[expected.Dims()-3] = c.Filters
+		}
+		return nil, &t.ShapeMismatchError{Op: "Conv2D Backward", Expected: expected, Actual: gradient.Shape().Clone()}
 	}
 
 	// Compute the biases gradient
@@ -185,142 +243,132 @@ func (c *Conv2D) Backward(gradient t.Tensor) (t.Tensor, error) {
 		return nil, err
 	}
 
-	// Compute the weights gradient
-	err = c.computeWeightsGradient(gradient)
+	// Compute the weights and input gradient
+	inputGradient, err := c.computeGradients(gradient)
 	if err != nil {
 		return nil, err
 	}
 
-	// Compute the input gradient
-	inputGradient, err := c.computeInputGradient(gradient)
+	return inputGradient, nil
+}
+
+// kernelMatrix returns a copy of the weights as a (filters,
+// channels*kernelRows*kernelCols) matrix, matching the rows made by Im2Col.
+func (c *Conv2D) kernelMatrix() (t.Tensor, error) {
+	kernels := c.weights.AsType(c.DType)
+	err := kernels.Reshape([]int{c.Filters, c.weights.Size() / c.Filters})
 	if err != nil {
 		return nil, err
 	}
-
-	return inputGradient, nil
+	return kernels, nil
 }
 
 func (c *Conv2D) computeBiasGradient(gradient t.Tensor) error {
-	c.biasesGradient = t.ZerosTensor([]int{1, c.Filters})
+	// Sum the gradient of every filter over the batches, rows and cols
+	axes := []int{-2, -1}
+	if gradient.Dims() == 4 {
+		axes = append(axes, 0)
+	}
 
-	// Compute the bias gradient: sum each output filter gradient
-	gradientBatchIter, err := t.IterFromTensor(gradient, "batches")
+	filterSums, err := gradient.SumAxes(false, axes...)
 	if err != nil {
 		return err
 	}
+	defer t.Release(filterSums)
 
-	for gradientBatch, ok := gradientBatchIter.Next(); ok; gradientBatch, ok = gradientBatchIter.Next() {
-
-		gradientFilterIter, err := t.IterFromTensor(gradientBatch, "matrix")
-		if err != nil {
-			return err
-		}
-
-		i := 0
-		for gradientFilter, ok := gradientFilterIter.Next(); ok; gradientFilter, ok = gradientFilterIter.Next() {
-			c.biasesGradient.SetValueAt(i, c.biasesGradient.ValueAt(i)+gradientFilter.Sum())
-			i++
-		}
-	}
-	return nil
+	c.biasesGradient = t.Zeros([]int{1, c.Filters}, c.DType)
+	_, err = c.biasesGradient.Add(filterSums, true)
+	return err
 }
 
-func (c *Conv2D) computeWeightsGradient(gradient t.Tensor) error {
-	weightsGradient := t.ZerosTensor(c.weights.Shape().Clone())
-
-	gradient, err := gradient.Dilate(c.Strides[0]-1, c.Strides[1]-1)
+// computeGradients computes the weights gradient and returns the input
+// gradient, using the patches saved by Forward. With the gradient of a batch
+// as a (filters, patches) matrix, both are a matrix multiplication away.
+func (c *Conv2D) computeGradients(gradient t.Tensor) (t.Tensor, error) {
+	kernels, err := c.kernelMatrix()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer t.Release(kernels)
 
-	gradientBatchIter, err := t.IterFromTensor(gradient, "batches")
-	inputBatchIter, err := t.IterFromTensor(c.input, "batches")
-
-	for gradientBatch, ok := gradientBatchIter.Next(); ok; gradientBatch, ok = gradientBatchIter.Next() {
-		inputBatch, _ := inputBatchIter.Next()
-
-		filterGradientIter, err := t.IterFromTensor(weightsGradient, "channels")
-		if err != nil {
-			return err
-		}
-
-		gradientChannelIter, err := t.IterFromTensor(gradientBatch, "channels")
-
-		for gradientChannel, ok := gradientChannelIter.Next(); ok; gradientChannel, ok = gradientChannelIter.Next() {
+	weightsGradient := t.Zeros(kernels.Shape(), c.DType)
+	colsGradient := t.Zeros(c.cols.Shape(), c.DType)
 
-			inputChannelIter, err := t.IterFromTensor(inputBatch, "channels")
-			if err != nil {
-				return err
-			}
+	patches := c.cols.Shape().Rows()
 
-			for inputChannel, ok := inputChannelIter.Next(); ok; inputChannel, ok = inputChannelIter.Next() {
-				filterGradient, _ := filterGradientIter.Next()
-
-				_, err := inputChannel.CrossCorrelate(gradientChannel, [2]int{1, 1}, filterGradient)
-				if err != nil {
-					fmt.Println("Error computing weights gradient crosss correlate")
-					return err
-				}
-			}
-		}
+	gradientBatchIter, err := t.IterAxes(gradient, 0)
+	if err != nil {
+		return nil, err
 	}
 
-	c.weightsGradient = weightsGradient
-	return nil
-}
-
-func (c *Conv2D) computeInputGradient(gradient t.Tensor) (t.Tensor, error) {
-
-	inputGradient := t.ZerosTensor(c.inShape)
-
-	gradient, err := gradient.Dilate(c.Strides[0]-1, c.Strides[1]-1)
-
-	gradient, err = gradient.Pad(c.KernelSize[0]-1, c.KernelSize[1]-1)
+	colsIter, err := t.IterAxes(c.cols, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	gradientBatchIter, err := t.IterFromTensor(gradient, "batches")
+	colsGradientIter, err := t.IterAxes(colsGradient, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	inputGradientBatchIter, err := t.IterFromTensor(inputGradient, "batches")
-
 	for gradientBatch, ok := gradientBatchIter.Next(); ok; gradientBatch, ok = gradientBatchIter.Next() {
-		inputGradientBatch, _ := inputGradientBatchIter.Next()
+		colsBatch, ok := colsIter.Next()
+		if !ok {
+			return nil, errors.New("gradient has more batches than the input")
+		}
+		colsGradientBatch, _ := colsGradientIter.Next()
+
+		err := gradientBatch.Reshape([]int{c.Filters, patches})
+		if err != nil {
+			return nil, err
+		}
+
+		filterGradient, err := gradientBatch.MatMul(colsBatch)
+		if err != nil {
+			return nil, err
+		}
 
-		filterIter, err := t.IterFromTensor(c.weights, "channels")
+		_, err = weightsGradient.Add(filterGradient, true)
+		t.Release(filterGradient)
 		if err != nil {
 			return nil, err
 		}
 
-		gradientChannelIter, err := t.IterFromTensor(gradientBatch, "channel")
+		patchGradient, err := gradientBatch.Transpose(false).MatMul(kernels)
 		if err != nil {
 			return nil, err
 		}
 
-		for gradientChannel, ok := gradientChannelIter.Next(); ok; gradientChannel, ok = gradientChannelIter.Next() {
-			inputGradientChannelIter, err := t.IterFromTensor(inputGradientBatch, "channels")
-			if err != nil {
-				return nil, err
-			}
-
-			for inputGradientChannel, ok := inputGradientChannelIter.Next(); ok; inputGradientChannel, ok = inputGradientChannelIter.Next() {
-				filter, _ := filterIter.Next()
-				_, err := gradientChannel.Convolve(filter, [2]int{1, 1}, inputGradientChannel)
-				if err != nil {
-					return nil, err
-				}
-			}
+		_, err = colsGradientBatch.Add(patchGradient, true)
+		t.Release(patchGradient)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	inputGradient, err = inputGradient.Trim(c.padding...)
+	err = weightsGradient.Reshape(c.weights.Shape().Clone())
 	if err != nil {
 		return nil, err
 	}
 
+	c.weightsGradient = weightsGradient
+
+	// Fold the patches back, summing where they overlap
+	paddedGradient, err := colsGradient.Col2Im(c.padShape, c.KernelSize, c.Strides)
+	t.Release(colsGradient)
+	if err != nil {
+		return nil, err
+	}
+
+	inputGradient, err := paddedGradient.Trim(c.padding...)
+	if err != nil {
+		return nil, err
+	}
+
+	if inputGradient != paddedGradient {
+		t.Release(paddedGradient)
+	}
+
 	return inputGradient, nil
 }
 
@@ -341,6 +389,38 @@ func (c *Conv2D) BiasesGradient() t.Tensor {
 }
 
 func Conv2DFromParams(params map[string]interface{}, weights []float64, biases []float64) (Layer, error) {
+	conv, err := conv2DFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	biasesShape := []int{1, conv.Filters}
+
+	inChannels := len(weights) / (conv.Filters * conv.KernelSize[0] * conv.KernelSize[1])
+	weightsShape := []int{conv.Filters, inChannels, conv.KernelSize[0], conv.KernelSize[1]}
+
+	weightsTensor, err := t.TensorFrom(weightsShape, weights)
+	if err != nil {
+		return nil, err
+	}
+
+	biasesTensor, err := t.TensorFrom(biasesShape, biases)
+	if err != nil {
+		return nil, err
+	}
+
+	if weightsTensor.DType() != conv.DType {
+		weightsTensor = weightsTensor.AsType(conv.DType)
+		biasesTensor = biasesTensor.AsType(conv.DType)
+	}
+
+	conv.weights, conv.biases = weightsTensor, biasesTensor
+	return conv, nil
+}
+
+// conv2DFromParams reads the configuration of a Conv2D layer, without its
+// weights and biases.
+func conv2DFromParams(params map[string]interface{}) (*Conv2D, error) {
 
 	filtersFloat64, ok := params["filters"].(float64)
 	if !ok {
@@ -395,17 +475,7 @@ func Conv2DFromParams(params map[string]interface{}, weights []float64, biases [
 
 	activationStruct := a.Activations[activation]()
 
-	biasesShape := []int{1, filters}
-
-	inChannels := len(weights) / (filters * kernelSize[0] * kernelSize[1])
-	weightsShape := []int{filters, inChannels, kernelSize[0], kernelSize[1]}
-
-	weightsTensor, err := t.TensorFrom(weightsShape, weights)
-	if err != nil {
-		return nil, err
-	}
-
-	biasesTensor, err := t.TensorFrom(biasesShape, biases)
+	dtype, err := dtypeFromParams(params)
 	if err != nil {
 		return nil, err
 	}
@@ -416,8 +486,7 @@ func Conv2DFromParams(params map[string]interface{}, weights []float64, biases [
 		Strides:    strides,
 		Activation: activationStruct,
 		Mode:       PaddingMode(mode),
+		DType:      dtype,
 		padding:    padding,
-		weights:    weightsTensor,
-		biases:     biasesTensor,
 	}, nil
 }
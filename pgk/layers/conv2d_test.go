@@ -0,0 +1,109 @@
+package layers
+
+import (
+	"math"
+	"testing"
+
+	a "github.com/cangeroe7/giraffe/pgk/activations"
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+// slidingConv2D is the forward pass Conv2D used before im2col, sliding every
+// filter over every batch with CrossCorrelate.
+func slidingConv2D(conv *Conv2D, input t.Tensor, outShape t.Shape) (t.Tensor, error) {
+	resTen := t.ZerosTensor(outShape)
+	inIter, err := t.IterAxes(input, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	resIter, err := t.IterMatrices(resTen)
+	if err != nil {
+		return nil, err
+	}
+
+	for inBatch, ok := inIter.Next(); ok; inBatch, ok = inIter.Next() {
+		filterIter, err := t.IterAxes(conv.Weights(), 0)
+		if err != nil {
+			return nil, err
+		}
+
+		currentBias := 0
+		for filter, ok := filterIter.Next(); ok; filter, ok = filterIter.Next() {
+			resMat, _ := resIter.Next()
+			if _, err := inBatch.CrossCorrelate(filter, conv.Strides, resMat); err != nil {
+				return nil, err
+			}
+
+			resMat.ScalarAdd(conv.Biases().ValueAt(currentBias), true)
+			currentBias++
+		}
+	}
+
+	return conv.Activation.Forward(resTen)
+}
+
+// mnistConv2D returns the first convolution of an MNIST model, compiled, with
+// a batch of 32 images for it.
+func mnistConv2D(tb testing.TB, strides [2]int) (*Conv2D, t.Tensor) {
+	conv := &Conv2D{Filters: 32, KernelSize: [2]int{3, 3}, Strides: strides, Mode: Valid, Activation: &a.Relu{}}
+	if _, err := conv.CompileLayer([]int{1, 28, 28}); err != nil {
+		tb.Fatal(err)
+	}
+
+	input, _ := t.RandTensor([]int{32, 1, 28, 28}, -1, 1)
+	return conv, input
+}
+
+func TestConv2DMatchesSliding(test *testing.T) {
+	for _, strides := range [][2]int{{1, 1}, {2, 2}} {
+		conv, input := mnistConv2D(test, strides)
+
+		output, err := conv.Forward(input)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		expected, err := slidingConv2D(conv, input, output.Shape())
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		expectedValues := expected.DataCopy()
+		for i, val := range output.DataCopy() {
+			if math.Abs(val-expectedValues[i]) > 1e-9 {
+				test.Fatalf("strides %v: value %d is %v, want %v", strides, i, val, expectedValues[i])
+			}
+		}
+	}
+}
+
+// BenchmarkConv2D times the first convolution of an MNIST model on a batch of
+// 32, sliding the kernels like before im2col and through im2col.
+func BenchmarkConv2D(b *testing.B) {
+	conv, input := mnistConv2D(b, [2]int{1, 1})
+	output, err := conv.Forward(input)
+	if err != nil {
+		b.Fatal(err)
+	}
+	gradient, _ := t.RandTensor(output.Shape(), -1, 1)
+
+	b.Run("forward/sliding", func(b *testing.B) {
+		for range b.N {
+			slidingConv2D(conv, input, output.Shape())
+		}
+	})
+
+	b.Run("forward/im2col", func(b *testing.B) {
+		for range b.N {
+			conv.Forward(input)
+		}
+	})
+
+	b.Run("forward_backward/im2col", func(b *testing.B) {
+		for range b.N {
+			conv.Forward(input)
+			conv.Backward(gradient)
+		}
+	})
+}
@@ -12,8 +12,11 @@ import (
 type Dense struct {
 	Units      int
 	Activation a.Activation
+	DType      t.DType // Defaults to tensor.DefaultDType()
+	RNG        *t.RNG  // Initializes the weights, defaults to the global RNG
 
 	input           t.Tensor
+	sparseInput     *t.CSR
 	weights         t.Tensor
 	biases          t.Tensor
 	weightsGradient t.Tensor
@@ -28,24 +31,33 @@ func (d *Dense) Params() map[string]interface{} {
 	return map[string]interface{}{
 		"units":      d.Units,
 		"activation": d.Activation.Type(),
+		"dtype":      d.DType,
 	}
 }
 
 func (d *Dense) CompileLayer(inShape t.Shape) (t.Shape, error) {
+	if d.DType == "" {
+		d.DType = t.DefaultDType()
+	}
+
 	var limit float64
 	if d.Activation.Type() == "relu" {
 		// He initialization
 		limit = math.Sqrt(2.0 / float64(inShape.Cols()))
-		d.weights, _ = t.RandTensor([]int{inShape.Cols(), d.Units}, -limit, limit)
+		d.weights, _ = d.RNG.OrGlobal().RandTensor([]int{inShape.Cols(), d.Units}, -limit, limit)
 	} else {
 		// Xavier initialization
 		limit = math.Sqrt(6.0 / float64(inShape.Cols()+d.Units))
-		d.weights, _ = t.RandTensor([]int{inShape.Cols(), d.Units}, -limit, limit)
+		d.weights, _ = d.RNG.OrGlobal().RandTensor([]int{inShape.Cols(), d.Units}, -limit, limit)
 
 	}
 
+	if d.weights.DType() != d.DType {
+		d.weights = d.weights.AsType(d.DType)
+	}
+
 	// Biases set to zero
-	d.biases = t.ZerosTensor([]int{1, d.Units})
+	d.biases = t.Zeros([]int{1, d.Units}, d.DType)
 
 	return d.biases.Shape(), nil
 }
@@ -55,19 +67,52 @@ func (d *Dense) Forward(input t.Tensor) (t.Tensor, error) {
 		return nil, errors.New("input cannot be nil")
 	}
 
+	// Compute in the precision of the weights
+	if input.DType() != d.DType {
+		input = input.AsType(d.DType)
+	}
+
 	// For when Dense comes in as (batches, 1, 1, cols)
 	totalSize := input.Shape().TotalSize()
 	rows := totalSize / input.Shape().Cols()
 	input.Reshape([]int{1, 1, rows, input.Shape().Cols()})
 
 	d.input = input
+	d.sparseInput = nil
 	Y, err := input.MatMul(d.weights)
 
 	if err != nil {
 		return nil, err
 	}
 
-	Y.RepAdd(d.biases, true)
+	return d.activate(Y)
+}
+
+// ForwardSparse is Forward for a sparse (batches, cols) input, like bag of
+// words or one hot vectors. The product with the weights only goes over the
+// stored values, and Backward uses the sparse input for the weights gradient.
+func (d *Dense) ForwardSparse(input *t.CSR) (t.Tensor, error) {
+	if input == nil {
+		return nil, errors.New("input cannot be nil")
+	}
+
+	d.input = nil
+	d.sparseInput = input
+	Y, err := input.MatMul(d.weights)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return d.activate(Y)
+}
+
+func (d *Dense) activate(Y t.Tensor) (t.Tensor, error) {
+	// Biases broadcast over every row of the batch
+	_, err := Y.Add(d.biases, true)
+	if err != nil {
+		return nil, err
+	}
 
 	YActivated, err := d.Activation.Forward(Y)
 
@@ -83,12 +128,26 @@ func (d *Dense) Backward(gradient t.Tensor) (t.Tensor, error) {
 		return nil, errors.New("gradient cannot be nil")
 	}
 
-	gradient, err := d.Activation.Backward(gradient)
+	if gradient.DType() != d.DType {
+		gradient = gradient.AsType(d.DType)
+	}
+
+	activationGradient, err := d.Activation.Backward(gradient)
 	if err != nil {
 		return nil, err
 	}
 
-	d.weightsGradient, err = d.input.Transpose(false).MatMul(gradient)
+	// The activation gradient is only needed here
+	if activationGradient != gradient {
+		defer t.Release(activationGradient)
+	}
+	gradient = activationGradient
+
+	if d.sparseInput != nil {
+		d.weightsGradient, err = d.sparseInput.TransposeMatMul(gradient)
+	} else {
+		d.weightsGradient, err = d.input.Transpose(false).MatMul(gradient)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -138,6 +197,11 @@ func DenseFromParams(params map[string]interface{}, weights []float64, biases []
 
   activationStruct := a.Activations[activation]()
 
+  dtype, err := dtypeFromParams(params)
+  if err != nil {
+    return nil, err
+  }
+
   weightsShape := []int{len(weights)/units, units}
   biasesShape := []int{1, units}
 
@@ -152,9 +216,15 @@ func DenseFromParams(params map[string]interface{}, weights []float64, biases []
     return nil, err
   }
 
+  if weightsTensor.DType() != dtype {
+    weightsTensor = weightsTensor.AsType(dtype)
+    biasesTensor = biasesTensor.AsType(dtype)
+  }
+
   return &Dense{
     Units: units,
     Activation: activationStruct,
+    DType: dtype,
     weights: weightsTensor,
     biases: biasesTensor,
   }, nil
@@ -0,0 +1,180 @@
+package layers
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	a "github.com/cangeroe7/giraffe/pgk/activations"
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+func assertClose(test *testing.T, name string, got, want t.Tensor) {
+	test.Helper()
+
+	if !got.Shape().Eq(want.Shape()) {
+		test.Fatalf("%s: shape %v, want %v", name, got.Shape(), want.Shape())
+	}
+
+	expected := t.Values[float64](want)
+	for i, val := range t.Values[float64](got) {
+		if math.Abs(val-expected[i]) > 1e-9 {
+			test.Fatalf("%s: value %d is %v, want %v", name, i, val, expected[i])
+		}
+	}
+}
+
+// TestDenseForwardSparse runs the same batch through two Dense layers with
+// the same weights, once dense and once sparse, and compares the outputs and
+// all gradients.
+func TestDenseForwardSparse(test *testing.T) {
+	// An empty row and two entries at the same position
+	coo, err := t.NewCOO(4, 6, []int{0, 0, 2, 2, 0}, []int{1, 4, 0, 5, 1}, []float64{2, -1, 3, 0.5, 1})
+	if err != nil {
+		test.Fatal(err)
+	}
+	sparse := coo.ToCSR()
+	input := sparse.ToDense(t.Float64)
+
+	layers := [2]*Dense{}
+	for i := range layers {
+		layers[i] = &Dense{Units: 3, Activation: &a.Sigmoid{}, DType: t.Float64, RNG: t.NewRNG(1)}
+		if _, err := layers[i].CompileLayer(t.Shape{1, 6}); err != nil {
+			test.Fatal(err)
+		}
+	}
+	dense, sparseDense := layers[0], layers[1]
+
+	want, err := dense.Forward(input.AsType(t.Float64))
+	if err != nil {
+		test.Fatal(err)
+	}
+	got, err := sparseDense.ForwardSparse(sparse)
+	if err != nil {
+		test.Fatal(err)
+	}
+	assertClose(test, "output", got, want)
+
+	gradient, _ := t.NewRNG(2).RandTensor(t.Shape{4, 3}, -1, 1)
+	wantInput, err := dense.Backward(gradient.AsType(t.Float64))
+	if err != nil {
+		test.Fatal(err)
+	}
+	gotInput, err := sparseDense.Backward(gradient.AsType(t.Float64))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	assertClose(test, "input gradient", gotInput, wantInput)
+	assertClose(test, "weights gradient", sparseDense.WeightsGradient(), dense.WeightsGradient())
+	assertClose(test, "biases gradient", sparseDense.BiasesGradient(), dense.BiasesGradient())
+
+	if _, err := sparseDense.ForwardSparse(nil); err == nil {
+		test.Error("nil input: expected an error")
+	}
+}
+
+// TestDenseFloat32 runs a batch through a float32 Dense layer and a float64
+// one with the same weights, the float32 layer has to stay float32 and agree
+// up to float32 precision.
+func TestDenseFloat32(test *testing.T) {
+	layers := map[t.DType]*Dense{}
+	for _, dtype := range []t.DType{t.Float32, t.Float64} {
+		layers[dtype] = &Dense{Units: 3, Activation: &a.Sigmoid{}, DType: dtype, RNG: t.NewRNG(1)}
+		if _, err := layers[dtype].CompileLayer(t.Shape{1, 5}); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	rng := t.NewRNG(2)
+	input, _ := rng.RandTensor(t.Shape{4, 5}, -1, 1)
+	gradient, _ := rng.RandTensor(t.Shape{4, 3}, -1, 1)
+
+	results := map[t.DType][]t.Tensor{}
+	for dtype, layer := range layers {
+		output, err := layer.Forward(input.AsType(t.Float64))
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		inputGradient, err := layer.Backward(gradient.AsType(t.Float64))
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		results[dtype] = []t.Tensor{output, inputGradient, layer.WeightsGradient(), layer.BiasesGradient()}
+	}
+
+	for i, name := range []string{"output", "input gradient", "weights gradient", "biases gradient"} {
+		got, want := results[t.Float32][i], results[t.Float64][i]
+		if got.DType() != t.Float32 {
+			test.Errorf("%s has dtype %s, want float32", name, got.DType())
+		}
+
+		expected := t.Values[float64](want)
+		for j, val := range t.Values[float64](got) {
+			if math.Abs(val-expected[j]) > 1e-5 {
+				test.Fatalf("%s: value %d is %v, want %v", name, j, val, expected[j])
+			}
+		}
+	}
+}
+
+func TestDenseFromParamsDType(test *testing.T) {
+	params := map[string]interface{}{"units": 2.0, "activation": "sigmoid"}
+	weights, biases := []float64{1, 2, 3, 4, 5, 6}, []float64{0.5, -0.5}
+
+	for _, tc := range []struct {
+		dtype any
+		want  t.DType
+	}{{nil, t.DefaultDType()}, {"float32", t.Float32}, {"float64", t.Float64}} {
+		if tc.dtype != nil {
+			params["dtype"] = tc.dtype
+		}
+
+		layer, err := DenseFromParams(params, weights, biases)
+		if err != nil {
+			test.Fatal(err)
+		}
+		if got := layer.Weights().DType(); got != tc.want {
+			test.Errorf("dtype %v: weights loaded as %s, want %s", tc.dtype, got, tc.want)
+		}
+	}
+
+	params["dtype"] = "float23"
+	if _, err := DenseFromParams(params, weights, biases); err == nil {
+		test.Error("dtype float23: expected an error")
+	}
+}
+
+// BenchmarkDense times a forward and backward pass through a Dense layer on a
+// batch of 64, once with a single MatMul worker and once with the default
+// pool.
+func BenchmarkDense(b *testing.B) {
+	defer t.SetMatMulWorkers(0)
+
+	for _, units := range []int{512, 1024, 2048} {
+		dense := &Dense{Units: units, Activation: &a.Relu{}}
+		if _, err := dense.CompileLayer([]int{1, units}); err != nil {
+			b.Fatal(err)
+		}
+
+		input, _ := t.RandTensor([]int{64, units}, -1, 1)
+		gradient, _ := t.RandTensor([]int{64, units}, -1, 1)
+
+		for _, workers := range []int{1, 0} {
+			name := fmt.Sprintf("%d/1_worker", units)
+			if workers == 0 {
+				name = fmt.Sprintf("%d/default_pool", units)
+			}
+
+			b.Run(name, func(b *testing.B) {
+				t.SetMatMulWorkers(workers)
+				for range b.N {
+					dense.Forward(input)
+					dense.Backward(gradient)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package layers
+
+import "fmt"
+
+// LayerError wraps an error from a layer of a model with the position and
+// type of that layer, so a failure in Fit points at the layer that caused it.
+// The wrapped error is reachable through errors.As and errors.Is.
+type LayerError struct {
+	Index int    // Position of the layer in the model, starting at 0
+	Type  string // Type of the layer, like "Dense"
+	Op    string // What the layer was doing, like "Forward"
+	Err   error
+}
+
+func (e *LayerError) Error() string {
+	return fmt.Sprintf("layer %d (%s) %s: %v", e.Index, e.Type, e.Op, e.Err)
+}
+
+func (e *LayerError) Unwrap() error {
+	return e.Err
+}
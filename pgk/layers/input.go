@@ -24,16 +24,8 @@ func (i *Input) Forward(input t.Tensor) (t.Tensor, error) {
 
 	inShape := input.Shape()
 
-	if inShape.Channels() != i.Shape.Channels() {
-		return nil, errors.New("Channels dimsize does not match")
-	}
-
-	if inShape.Rows() != i.Shape.Rows() {
-		return nil, errors.New("Row dimsize does not match")
-	}
-
-	if inShape.Cols() != i.Shape.Cols() {
-		return nil, errors.New("Column dimsize does not match")
+	if inShape.Channels() != i.Shape.Channels() || inShape.Rows() != i.Shape.Rows() || inShape.Cols() != i.Shape.Cols() {
+		return nil, &t.ShapeMismatchError{Op: "Input", Expected: i.Shape.Clone(), Actual: inShape.Clone()}
 	}
 
 	return input, nil
@@ -2,6 +2,7 @@ package layers
 
 import (
 	"errors"
+	"fmt"
 	"math"
 
 	t "github.com/cangeroe7/giraffe/pgk/tensor"
@@ -82,6 +83,21 @@ func interfaceToIntArray(input []interface{}) ([]int, error) {
 	}
   return result, nil
 }
+// dtypeFromParams reads the optional 'dtype' parameter, models saved before it
+// existed load with the default dtype.
+func dtypeFromParams(params map[string]interface{}) (t.DType, error) {
+	dtype, ok := params["dtype"].(string)
+	if !ok || dtype == "" {
+		return t.DefaultDType(), nil
+	}
+
+	switch t.DType(dtype) {
+	case t.Float32, t.Float64:
+		return t.DType(dtype), nil
+	}
+	return "", fmt.Errorf("invalid 'dtype' parameter %q", dtype)
+}
+
 func InterfaceToFloat64Array(input []interface{}) ([]float64, error) {
 
 	result := make([]float64, len(input))
@@ -67,21 +67,31 @@ func (p *Pooling) Forward(input t.Tensor) (t.Tensor, error) {
 	outHeight := (inputShape.Rows()-p.KernelSize[0])/p.Strides[0] + 1
 	outWidth := (inputShape.Cols()-p.KernelSize[1])/p.Strides[1] + 1
 	outShape := []int{inputShape.Batches(), inputShape.Channels(), outHeight, outWidth}
-	output := t.ZerosTensor(outShape)
+	output := t.Zeros(outShape, input.DType())
 
-	// Iterate through channels
-	channelIter, _ := t.IterFromTensor(input, "channel")
-	outIter, _ := t.IterFromTensor(output, "channel")
+	// Pool every channel of every batch, the channels are independent
+	channelIter, err := t.IterMatrices(input)
+	if err != nil {
+		return nil, err
+	}
+
+	outIter, err := t.IterMatrices(output)
+	if err != nil {
+		return nil, err
+	}
 
-	for inChannel, ok := channelIter.Next(); ok; inChannel, ok = channelIter.Next() {
-		outChannel, _ := outIter.Next()
+	err = channelIter.ParallelEach(func(pos []int, inChannel t.Tensor) error {
+		outChannel, err := outIter.At(pos...)
+		if err != nil {
+			return err
+		}
 
 		// Apply pooling operation
 		for i := 0; i < outHeight; i++ {
 			for j := 0; j < outWidth; j++ {
 				region, _, err := inChannel.RegionSlice(p.Strides[0]*i, p.Strides[1]*j, p.Strides[0], p.Strides[0])
 				if err != nil {
-					return nil, err
+					return err
 				}
 
 				switch p.PoolType {
@@ -94,6 +104,10 @@ func (p *Pooling) Forward(input t.Tensor) (t.Tensor, error) {
 				}
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return output, nil
@@ -104,20 +118,44 @@ func (p *Pooling) Backward(gradient t.Tensor) (t.Tensor, error) {
 		return nil, errors.New("gradient tensor cannot be nil")
 	}
 
-	if gradient.Shape().Rows() != (p.input.Shape().Rows()-p.KernelSize[0])/p.Strides[0]+1 ||
-		gradient.Shape().Cols() != (p.input.Shape().Cols()-p.KernelSize[1])/p.Strides[1]+1 {
-		return nil, errors.New("gradient shape does not match output shape of forward pass")
+	outRows := (p.input.Shape().Rows()-p.KernelSize[0])/p.Strides[0] + 1
+	outCols := (p.input.Shape().Cols()-p.KernelSize[1])/p.Strides[1] + 1
+	if gradient.Shape().Rows() != outRows || gradient.Shape().Cols() != outCols {
+		expected := gradient.Shape().Clone()
+		if expected.Dims() >= 2 {
+			expected[expected.Dims()-2], expected[expected.Dims()-1] = outRows, outCols
+		}
+		return nil, &t.ShapeMismatchError{Op: "Pooling Backward", Expected: expected, Actual: gradient.Shape().Clone()}
 	}
 
-	outputGradient := t.ZerosTensor(p.input.Shape().Clone())
+	outputGradient := t.Zeros(p.input.Shape().Clone(), p.input.DType())
 
-	channelIter, _ := t.IterFromTensor(p.input, "channel")
-	gradientIter, _ := t.IterFromTensor(gradient, "channel")
-	outGradientIter, _ := t.IterFromTensor(outputGradient, "channel")
+	channelIter, err := t.IterMatrices(p.input)
+	if err != nil {
+		return nil, err
+	}
 
-	for inChannel, ok := channelIter.Next(); ok; inChannel, ok = channelIter.Next() {
-		gradientChannel, _ := gradientIter.Next()
-		outChannel, _ := outGradientIter.Next()
+	gradientIter, err := t.IterMatrices(gradient)
+	if err != nil {
+		return nil, err
+	}
+
+	outGradientIter, err := t.IterMatrices(outputGradient)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every channel only adds to its own part of the output gradient
+	err = channelIter.ParallelEach(func(pos []int, inChannel t.Tensor) error {
+		gradientChannel, err := gradientIter.At(pos...)
+		if err != nil {
+			return err
+		}
+
+		outChannel, err := outGradientIter.At(pos...)
+		if err != nil {
+			return err
+		}
 
 		for i := 0; i < gradient.Shape().Rows(); i++ {
 			for j := 0; j < gradient.Shape().Cols(); j++ {
@@ -128,7 +166,7 @@ func (p *Pooling) Backward(gradient t.Tensor) (t.Tensor, error) {
 				// Slice the region from the input
 				region, indices, err := inChannel.RegionSlice(startRow, startCol, p.KernelSize[0], p.KernelSize[1])
 				if err != nil {
-					return nil, err
+					return err
 				}
 
 				gradientIdx := i*gradient.Shape().Cols() + j
@@ -157,6 +195,10 @@ func (p *Pooling) Backward(gradient t.Tensor) (t.Tensor, error) {
 				}
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return outputGradient, nil
@@ -0,0 +1,376 @@
+package layers
+
+import (
+	"errors"
+	"math"
+
+	a "github.com/cangeroe7/giraffe/pgk/activations"
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+// errInferenceOnly is returned by Backward of the quantized layers.
+var errInferenceOnly = errors.New("quantized layers are for inference only, train the float model instead")
+
+// QuantizedLayer is a layer with int8 weights. They are quantized per output,
+// with a scale and zero point for every unit or filter, while the input is
+// quantized with a single scale and zero point calibrated on sample data.
+// Biases stay in float and are added after the int8 product.
+type QuantizedLayer interface {
+	Layer
+	QuantizedWeights() *t.QTensor
+}
+
+// QuantizedDense is the int8 inference version of a Dense layer, made by
+// QuantizeDense.
+type QuantizedDense struct {
+	Units          int
+	Activation     a.Activation
+	DType          t.DType // Dtype of the output
+	InputScale     float64
+	InputZeroPoint int32
+
+	// (units, inputs), quantized per unit
+	weights *t.QTensor
+	biases  t.Tensor
+}
+
+// QuantizeDense quantizes the weights of a compiled Dense layer, where
+// inputMin and inputMax are the range of its input seen during calibration.
+func QuantizeDense(d *Dense, inputMin, inputMax float64) (*QuantizedDense, error) {
+	if d == nil || d.weights == nil {
+		return nil, errors.New("dense layer has to be compiled before quantizing")
+	}
+
+	// One row per unit, so every unit gets its own scale
+	weights, err := t.QuantizePerChannel(d.weights.Transpose(false), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	scale, zeroPoint := t.QuantParams(inputMin, inputMax)
+	return &QuantizedDense{
+		Units:          d.Units,
+		Activation:     d.Activation,
+		DType:          d.DType,
+		InputScale:     scale,
+		InputZeroPoint: zeroPoint,
+		weights:        weights,
+		biases:         d.biases.AsType(d.DType),
+	}, nil
+}
+
+func (d *QuantizedDense) Type() string {
+	return "QuantizedDense"
+}
+
+func (d *QuantizedDense) Params() map[string]interface{} {
+	return map[string]interface{}{
+		"units":            d.Units,
+		"activation":       d.Activation.Type(),
+		"dtype":            d.DType,
+		"input_scale":      d.InputScale,
+		"input_zero_point": d.InputZeroPoint,
+	}
+}
+
+// CompileLayer only gives the output shape, the weights come from the
+// quantized Dense layer.
+func (d *QuantizedDense) CompileLayer(inShape t.Shape) (t.Shape, error) {
+	return []int{1, d.Units}, nil
+}
+
+func (d *QuantizedDense) Forward(input t.Tensor) (t.Tensor, error) {
+	if input == nil {
+		return nil, errors.New("input cannot be nil")
+	}
+
+	x, err := t.Quantize(input, d.InputScale, d.InputZeroPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every row of the input, however it is shaped, against every unit
+	Y, err := t.QuantizedMatMul(x, d.weights, d.DType)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = Y.Add(d.biases, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.Activation.Forward(Y)
+}
+
+func (d *QuantizedDense) Backward(gradient t.Tensor) (t.Tensor, error) {
+	return nil, errInferenceOnly
+}
+
+func (d *QuantizedDense) QuantizedWeights() *t.QTensor { return d.weights }
+func (d *QuantizedDense) Weights() t.Tensor            { return nil }
+func (d *QuantizedDense) Biases() t.Tensor             { return d.biases }
+func (d *QuantizedDense) WeightsGradient() t.Tensor    { return nil }
+func (d *QuantizedDense) BiasesGradient() t.Tensor     { return nil }
+
+func QuantizedDenseFromParams(params map[string]interface{}, weights *t.QTensor, biases []float64) (Layer, error) {
+	unitsFloat64, ok := params["units"].(float64)
+	if !ok {
+		return nil, errors.New("missing or invalid 'units' parameter")
+	}
+
+	units := int(unitsFloat64)
+
+	activation, ok := params["activation"].(string)
+	if !ok {
+		return nil, errors.New("missing or invalid 'activation' parameter")
+	}
+
+	scale, zeroPoint, err := inputQuantFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if weights == nil || weights.Shape().Dims() != 2 || weights.Shape()[0] != units {
+		return nil, errors.New("missing or invalid quantized weights")
+	}
+
+	dtype, err := dtypeFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	biasesTensor, err := t.TensorFrom([]int{1, units}, biases)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QuantizedDense{
+		Units:          units,
+		Activation:     a.Activations[activation](),
+		DType:          dtype,
+		InputScale:     scale,
+		InputZeroPoint: zeroPoint,
+		weights:        weights,
+		biases:         biasesTensor.AsType(dtype),
+	}, nil
+}
+
+// QuantizedConv2D is the int8 inference version of a Conv2D layer, made by
+// QuantizeConv2D. The patches of the input are quantized after Im2Col, so
+// the padding stays an exact zero.
+type QuantizedConv2D struct {
+	Filters        int
+	KernelSize     [2]int
+	Strides        [2]int
+	Mode           PaddingMode
+	Activation     a.Activation
+	DType          t.DType // Dtype of the output
+	InputScale     float64
+	InputZeroPoint int32
+
+	padding []int
+
+	// (filters, channels*kernelRows*kernelCols), quantized per filter
+	weights *t.QTensor
+	biases  t.Tensor
+}
+
+// QuantizeConv2D quantizes the kernels of a compiled Conv2D layer, where
+// inputMin and inputMax are the range of its input seen during calibration.
+func QuantizeConv2D(c *Conv2D, inputMin, inputMax float64) (*QuantizedConv2D, error) {
+	if c == nil || c.weights == nil {
+		return nil, errors.New("conv2d layer has to be compiled before quantizing")
+	}
+
+	weights, err := t.QuantizePerChannel(c.weights, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Flattened like the kernel matrix, the scales along axis 0 don't change
+	weights.TShape = t.Shape{c.Filters, weights.Size() / c.Filters}
+
+	scale, zeroPoint := t.QuantParams(inputMin, inputMax)
+	return &QuantizedConv2D{
+		Filters:        c.Filters,
+		KernelSize:     c.KernelSize,
+		Strides:        c.Strides,
+		Mode:           c.Mode,
+		Activation:     c.Activation,
+		DType:          c.DType,
+		InputScale:     scale,
+		InputZeroPoint: zeroPoint,
+		padding:        append([]int(nil), c.padding...),
+		weights:        weights,
+		biases:         c.biases.AsType(c.DType),
+	}, nil
+}
+
+func (c *QuantizedConv2D) Type() string {
+	return "QuantizedConv2D"
+}
+
+func (c *QuantizedConv2D) Params() map[string]interface{} {
+	return map[string]interface{}{
+		"filters":          c.Filters,
+		"activation":       c.Activation.Type(),
+		"kernel_size":      c.KernelSize,
+		"strides":          c.Strides,
+		"mode":             c.Mode,
+		"padding":          c.padding,
+		"dtype":            c.DType,
+		"input_scale":      c.InputScale,
+		"input_zero_point": c.InputZeroPoint,
+	}
+}
+
+// CompileLayer only gives the output shape, the kernels come from the
+// quantized Conv2D layer.
+func (c *QuantizedConv2D) CompileLayer(inShape t.Shape) (t.Shape, error) {
+	if len(c.padding) != 4 {
+		return nil, errors.New("padding has to be set by quantizing a Conv2D layer")
+	}
+
+	outHeight := (c.padding[0]+c.padding[2]+inShape.Rows()-c.KernelSize[0])/c.Strides[0] + 1
+	outWidth := (c.padding[1]+c.padding[3]+inShape.Cols()-c.KernelSize[1])/c.Strides[1] + 1
+
+	return []int{c.Filters, outHeight, outWidth}, nil
+}
+
+func (c *QuantizedConv2D) Forward(input t.Tensor) (t.Tensor, error) {
+	if input == nil {
+		return nil, errors.New("input cannot be nil")
+	}
+
+	padInput, err := input.Pad(c.padding...)
+	if err != nil {
+		return nil, err
+	}
+
+	outHeight := (padInput.Shape().Rows()-c.KernelSize[0])/c.Strides[0] + 1
+	outWidth := (padInput.Shape().Cols()-c.KernelSize[1])/c.Strides[1] + 1
+
+	// (batches, 1, patches, channels*kernelRows*kernelCols)
+	cols, err := padInput.Im2Col(c.KernelSize, c.Strides)
+	if padInput != input {
+		t.Release(padInput)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := t.Quantize(cols, c.InputScale, c.InputZeroPoint)
+	t.Release(cols)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every filter against the patches of a batch gives the (filters,
+	// patches) output of the batch
+	batches := input.Shape().Batches()
+	patches := outHeight * outWidth
+	patchSize := x.Size() / (batches * patches)
+
+	outputs := make([]t.Tensor, batches)
+	for b := range batches {
+		batch := &t.QTensor{
+			TShape:     t.Shape{patches, patchSize},
+			Data:       x.Data[b*patches*patchSize : (b+1)*patches*patchSize],
+			Axis:       -1,
+			Scales:     x.Scales,
+			ZeroPoints: x.ZeroPoints,
+		}
+
+		outputs[b], err = t.QuantizedMatMul(c.weights, batch, c.DType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resTen, err := t.Concatenate(0, outputs...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = resTen.Reshape([]int{c.Filters, outHeight, outWidth})
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the bias of every filter
+	biases := c.biases.AsType(c.DType)
+	err = biases.Reshape([]int{c.Filters, 1, 1})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = resTen.Add(biases, true)
+	t.Release(biases)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Activation.Forward(resTen)
+}
+
+func (c *QuantizedConv2D) Backward(gradient t.Tensor) (t.Tensor, error) {
+	return nil, errInferenceOnly
+}
+
+func (c *QuantizedConv2D) QuantizedWeights() *t.QTensor { return c.weights }
+func (c *QuantizedConv2D) Weights() t.Tensor            { return nil }
+func (c *QuantizedConv2D) Biases() t.Tensor             { return c.biases }
+func (c *QuantizedConv2D) WeightsGradient() t.Tensor    { return nil }
+func (c *QuantizedConv2D) BiasesGradient() t.Tensor     { return nil }
+
+func QuantizedConv2DFromParams(params map[string]interface{}, weights *t.QTensor, biases []float64) (Layer, error) {
+	conv, err := conv2DFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	scale, zeroPoint, err := inputQuantFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if weights == nil || weights.Shape().Dims() != 2 || weights.Shape()[0] != conv.Filters {
+		return nil, errors.New("missing or invalid quantized weights")
+	}
+
+	biasesTensor, err := t.TensorFrom([]int{1, conv.Filters}, biases)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QuantizedConv2D{
+		Filters:        conv.Filters,
+		KernelSize:     conv.KernelSize,
+		Strides:        conv.Strides,
+		Mode:           conv.Mode,
+		Activation:     conv.Activation,
+		DType:          conv.DType,
+		InputScale:     scale,
+		InputZeroPoint: zeroPoint,
+		padding:        conv.padding,
+		weights:        weights,
+		biases:         biasesTensor.AsType(conv.DType),
+	}, nil
+}
+
+// inputQuantFromParams reads the scale and zero point of the input of a
+// quantized layer.
+func inputQuantFromParams(params map[string]interface{}) (float64, int32, error) {
+	scale, ok := params["input_scale"].(float64)
+	if !ok || scale <= 0 {
+		return 0, 0, errors.New("missing or invalid 'input_scale' parameter")
+	}
+
+	zeroPoint, ok := params["input_zero_point"].(float64)
+	if !ok || zeroPoint < math.MinInt8 || zeroPoint > math.MaxInt8 {
+		return 0, 0, errors.New("missing or invalid 'input_zero_point' parameter")
+	}
+
+	return scale, int32(zeroPoint), nil
+}
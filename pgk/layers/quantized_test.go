@@ -0,0 +1,128 @@
+package layers
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	a "github.com/cangeroe7/giraffe/pgk/activations"
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+// quantizedPair returns a compiled Dense and Conv2D layer with their
+// quantized versions for inputs in [-1, 1].
+func quantizedPair(tb testing.TB) (*Dense, *QuantizedDense, *Conv2D, *QuantizedConv2D) {
+	dense := &Dense{Units: 64, Activation: &a.Relu{}}
+	if _, err := dense.CompileLayer([]int{1, 128}); err != nil {
+		tb.Fatal(err)
+	}
+
+	conv := &Conv2D{Filters: 8, KernelSize: [2]int{3, 3}, Strides: [2]int{1, 1}, Mode: Full, Activation: &a.Relu{}}
+	if _, err := conv.CompileLayer([]int{2, 12, 12}); err != nil {
+		tb.Fatal(err)
+	}
+
+	quantDense, err := QuantizeDense(dense, -1, 1)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	quantConv, err := QuantizeConv2D(conv, -1, 1)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return dense, quantDense, conv, quantConv
+}
+
+func TestQuantizedLayersMatchFloat(test *testing.T) {
+	dense, quantDense, conv, quantConv := quantizedPair(test)
+
+	denseInput, _ := t.RandTensor([]int{16, 128}, -1, 1)
+	convInput, _ := t.RandTensor([]int{4, 2, 12, 12}, -1, 1)
+
+	tests := []struct {
+		name        string
+		float, int8 Layer
+		input       t.Tensor
+	}{
+		{"dense", dense, quantDense, denseInput},
+		{"conv2d", conv, quantConv, convInput},
+	}
+
+	for _, tc := range tests {
+		expected, err := tc.float.Forward(tc.input)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		output, err := tc.int8.Forward(tc.input)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		if !output.Shape().Eq(expected.Shape()) {
+			test.Fatalf("%s: shape %v, want %v", tc.name, output.Shape(), expected.Shape())
+		}
+
+		// Within a percent of the range of the outputs
+		tolerance := 0.01 * (expected.Max() - expected.Min())
+		expectedValues := expected.DataCopy()
+		for i, val := range output.DataCopy() {
+			if math.Abs(val-expectedValues[i]) > tolerance {
+				test.Fatalf("%s: value %d is %v, want %v within %v", tc.name, i, val, expectedValues[i], tolerance)
+			}
+		}
+
+		if _, err := tc.int8.Backward(expected); !errors.Is(err, errInferenceOnly) {
+			test.Errorf("%s: Backward gave %v, want the inference only error", tc.name, err)
+		}
+	}
+}
+
+// BenchmarkQuantized times the forward pass of a Dense and a Conv2D layer in
+// float64 and with int8 weights and inputs.
+func BenchmarkQuantized(b *testing.B) {
+	dense := &Dense{Units: 512, Activation: &a.Relu{}}
+	conv := &Conv2D{Filters: 32, KernelSize: [2]int{3, 3}, Strides: [2]int{1, 1}, Mode: Valid, Activation: &a.Relu{}}
+	if _, err := dense.CompileLayer([]int{1, 512}); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := conv.CompileLayer([]int{1, 28, 28}); err != nil {
+		b.Fatal(err)
+	}
+
+	quantDense, err := QuantizeDense(dense, -1, 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	quantConv, err := QuantizeConv2D(conv, -1, 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	singleInput, _ := t.RandTensor([]int{1, 512}, -1, 1)
+	denseInput, _ := t.RandTensor([]int{64, 512}, -1, 1)
+	convInput, _ := t.RandTensor([]int{32, 1, 28, 28}, -1, 1)
+
+	tests := []struct {
+		name  string
+		layer Layer
+		input t.Tensor
+	}{
+		{"dense_single/float64", dense, singleInput},
+		{"dense_single/int8", quantDense, singleInput},
+		{"dense_batch/float64", dense, denseInput},
+		{"dense_batch/int8", quantDense, denseInput},
+		{"conv2d_batch/float64", conv, convInput},
+		{"conv2d_batch/int8", quantConv, convInput},
+	}
+
+	for _, test := range tests {
+		b.Run(test.name, func(b *testing.B) {
+			for range b.N {
+				test.layer.Forward(test.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package layers
+
+import (
+	"testing"
+
+	a "github.com/cangeroe7/giraffe/pgk/activations"
+	o "github.com/cangeroe7/giraffe/pgk/optimizers"
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+// benchmarkTrainingStep times a forward and backward pass through layer
+// followed by an Adam step on its weights and biases, once allocating every
+// tensor and once taking the buffers from the pool.
+func benchmarkTrainingStep(b *testing.B, layer Layer, inShape t.Shape, input t.Tensor) {
+	defer t.SetBufferPooling(true)
+
+	if _, err := layer.CompileLayer(inShape); err != nil {
+		b.Fatal(err)
+	}
+
+	output, err := layer.Forward(input)
+	if err != nil {
+		b.Fatal(err)
+	}
+	gradient, _ := t.RandTensor(output.Shape(), -1, 1)
+
+	for _, pooling := range []bool{false, true} {
+		name := "allocating"
+		if pooling {
+			name = "pooled"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			t.SetBufferPooling(pooling)
+			adam := &o.Adam{}
+			adam.Initialize()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				layer.Forward(input)
+				layer.Backward(gradient)
+				adam.Apply("weights", layer.Weights(), layer.WeightsGradient())
+				adam.Apply("biases", layer.Biases(), layer.BiasesGradient())
+			}
+		})
+	}
+}
+
+func BenchmarkDenseTrainingStep(b *testing.B) {
+	input, _ := t.RandTensor([]int{64, 512}, -1, 1)
+	dense := &Dense{Units: 512, Activation: &a.Relu{}}
+	benchmarkTrainingStep(b, dense, []int{1, 512}, input)
+}
+
+func BenchmarkConv2DTrainingStep(b *testing.B) {
+	input, _ := t.RandTensor([]int{32, 1, 28, 28}, -1, 1)
+	conv := &Conv2D{Filters: 32, KernelSize: [2]int{3, 3}, Strides: [2]int{1, 1}, Mode: Valid, Activation: &a.Relu{}}
+	benchmarkTrainingStep(b, conv, []int{1, 28, 28}, input)
+}
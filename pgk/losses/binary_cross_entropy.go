@@ -12,8 +12,8 @@ import (
 type BinaryCrossEntropy struct{}
 
 func (l *BinaryCrossEntropy) CalcLoss(yTrue, yPred t.Tensor) (float64, error) {
-  if !yTrue.Shape().Eq(yPred.Shape()) {
-    return 0.0, errors.New("Dimensions not equal")
+  if !sameShape(yTrue.Shape(), yPred.Shape()) {
+    return 0.0, &t.ShapeMismatchError{Op: "BinaryCrossEntropy", Expected: yTrue.Shape().Clone(), Actual: yPred.Shape().Clone()}
   }
   BCE := func(vals ...float64) (float64, error) {
     if len(vals) != 2 {
@@ -23,7 +23,7 @@ func (l *BinaryCrossEntropy) CalcLoss(yTrue, yPred t.Tensor) (float64, error) {
 		return x*math.Log(y) + (1-x)*math.Log(1-y), nil
 	}
 
-	yPredClipped, _ := yPred.Map(noZerosOnes, false)
+	yPredClipped, _ := clipProbabilities(yPred)
 
 	losses, _ := yTrue.MapBatch(BCE, false, yPredClipped)
 
@@ -53,9 +53,11 @@ func (l *BinaryCrossEntropy) Accuracy(yTrue, yPred t.Tensor) (float64, error) {
 	return sum / float64(predicted.Size()), nil
 }
 
+// Gradient returns the gradient of the mean loss CalcLoss gives with respect
+// to every value of yPred.
 func (l *BinaryCrossEntropy) Gradient(yTrue, yPred t.Tensor) (t.Tensor, error) {
-  if !yTrue.Shape().Eq(yPred.Shape()) {
-    return nil, errors.New("Dimensions do not match")
+  if !sameShape(yTrue.Shape(), yPred.Shape()) {
+    return nil, &t.ShapeMismatchError{Op: "BinaryCrossEntropy", Expected: yTrue.Shape().Clone(), Actual: yPred.Shape().Clone()}
   }
 
 	primeBCE := func(vals ...float64) (float64, error) {
@@ -70,11 +72,16 @@ func (l *BinaryCrossEntropy) Gradient(yTrue, yPred t.Tensor) (t.Tensor, error) {
 		return -x/y + (1-x)/(1-y), nil
 	}
 
-	yPredClipped, _ := yPred.Map(noZerosOnes, false)
+	yPredClipped, _ := clipProbabilities(yPred)
 	lossGradient, err := yTrue.MapBatch(primeBCE, false, yPredClipped)
 	if err != nil {
 		return nil, err
 	}
 
+	_, err = lossGradient.ScalarDivide(float64(lossGradient.Size()), true)
+	if err != nil {
+		return nil, err
+	}
+
 	return lossGradient, nil
 }
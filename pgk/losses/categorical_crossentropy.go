@@ -1,8 +1,6 @@
 package losses
 
 import (
-	"math"
-
 	t "github.com/cangeroe7/giraffe/pgk/tensor"
 )
 
@@ -10,17 +8,12 @@ type CategoricalCrossentropy struct{}
 
 func (l *CategoricalCrossentropy) CalcLoss(yTrue, yPred t.Tensor) (float64, error) {
 
-  yPredClipped, err := yPred.Map(noZerosOnes, false)
+  yPredClipped, err := clipProbabilities(yPred)
   if err != nil {
     return 0.0, err
   }
 
-  yPredLog, err := yPredClipped.Map(func (x float64) (float64, error) {
-    return math.Log(x), nil
-  }, true)
-  if err != nil {
-    return 0.0, err
-  }
+  yPredLog := yPredClipped.Log(true)
 
   lossTen, err := yTrue.Multiply(yPredLog, false)
   if err != nil {
@@ -56,13 +49,19 @@ func (l *CategoricalCrossentropy) Accuracy(yTrue, yPred t.Tensor) (float64, erro
 	return accuracy, nil
 }
 
+// Gradient returns the gradient of the mean loss over the rows CalcLoss gives.
 func (l *CategoricalCrossentropy) Gradient(yTrue, yPred t.Tensor) (t.Tensor, error) {
 
-	yPredClipped, _ := yPred.Map(noZerosOnes, false)
+	yPredClipped, _ := clipProbabilities(yPred)
 
   gradient := yTrue.ScalarMultiply(-1.0, false)
 
   gradient, _ = gradient.Divide(yPredClipped, true)
 
+	_, err := gradient.ScalarDivide(float64(yTrue.Shape().Rows()), true)
+	if err != nil {
+		return nil, err
+	}
+
 	return gradient, nil
 }
@@ -10,13 +10,22 @@ type Loss interface {
   Gradient(yTrue, yPred t.Tensor) (t.Tensor, error)
 }
 
-func noZerosOnes(x float64) (float64, error) {
+// clipProbabilities keeps predicted probabilities away from 0 and 1, so their
+// logs and the divisions by them stay finite.
+func clipProbabilities(yPred t.Tensor) (t.Tensor, error) {
 	epsilon := 1e-15
-	if x < epsilon {
-		return epsilon, nil
-	}
-	if x > 1-epsilon {
-		return 1 - epsilon, nil
+	return yPred.Clip(epsilon, 1-epsilon, false)
+}
+
+// sameShape reports if yTrue and yPred hold the same values in the same
+// layout, leading dimensions of size 1 aside, so labels batched as
+// (1, 1, n, k) match predictions of (n, k).
+func sameShape(yTrue, yPred t.Shape) bool {
+	trim := func(s t.Shape) t.Shape {
+		for len(s) > 1 && s[0] == 1 {
+			s = s[1:]
+		}
+		return s
 	}
-	return x, nil
+	return trim(yTrue).Eq(trim(yPred))
 }
@@ -0,0 +1,74 @@
+package losses_test
+
+import (
+	"testing"
+
+	a "github.com/cangeroe7/giraffe/pgk/activations"
+	"github.com/cangeroe7/giraffe/pgk/gradcheck"
+	lo "github.com/cangeroe7/giraffe/pgk/losses"
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+// The gradients have to be of the loss CalcLoss reports, including its mean
+// over the batch.
+func TestGradientsMatchLoss(test *testing.T) {
+	logits, _ := t.RandTensor(t.Shape{3, 4}, -1, 1)
+	probabilities, _ := (&a.Softmax{}).Forward(logits)
+	oneHot, _ := t.TensorFrom([]int{3, 4}, []float64{0, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0, 1})
+	binary, _ := t.TensorFrom([]int{3, 4}, []float64{0, 1, 1, 0, 1, 0, 0, 1, 0, 1, 0, 1})
+
+	tests := []struct {
+		name  string
+		loss  lo.Loss
+		yTrue t.Tensor
+	}{
+		{"mean square error", &lo.MeanSquareError{}, binary},
+		{"binary cross entropy", &lo.BinaryCrossEntropy{}, binary},
+		{"categorical crossentropy", &lo.CategoricalCrossentropy{}, oneHot},
+	}
+
+	for _, tc := range tests {
+		result, err := gradcheck.Loss(tc.loss, tc.yTrue, probabilities)
+		if err != nil {
+			test.Fatalf("%s: %v", tc.name, err)
+		}
+		if result.Max() > 1e-4 {
+			test.Errorf("%s: gradient doesn't match the loss, %v", tc.name, result)
+		}
+	}
+}
+
+// Labels batched by Fit come as (1, 1, n, k) while a Dense layer gives (n, k),
+// so leading dimensions of size 1 don't count, every other one does.
+func TestLossShapes(test *testing.T) {
+	yPred, _ := t.TensorFrom([]int{3, 1}, []float64{0.2, 0.7, 0.9})
+	batched, _ := t.TensorFrom([]int{1, 1, 3, 1}, []float64{0, 1, 1})
+	row, _ := t.TensorFrom([]int{1, 3}, []float64{0, 1, 1})
+	column, _ := t.TensorFrom([]int{3, 1}, []float64{0, 1, 1})
+
+	for _, loss := range []lo.Loss{&lo.MeanSquareError{}, &lo.BinaryCrossEntropy{}} {
+		want, err := loss.CalcLoss(column, yPred)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		got, err := loss.CalcLoss(batched, yPred)
+		if err != nil {
+			test.Fatalf("%T of (1, 1, 3, 1) labels: %v", loss, err)
+		}
+		if got != want {
+			test.Errorf("%T of (1, 1, 3, 1) labels is %v, want %v", loss, got, want)
+		}
+
+		if _, err := loss.Gradient(batched, yPred); err != nil {
+			test.Errorf("%T gradient of (1, 1, 3, 1) labels: %v", loss, err)
+		}
+
+		if _, err := loss.CalcLoss(row, yPred); err == nil {
+			test.Errorf("%T of (1, 3) labels for (3, 1) predictions: expected an error", loss)
+		}
+		if _, err := loss.Gradient(row, yPred); err == nil {
+			test.Errorf("%T gradient of (1, 3) labels for (3, 1) predictions: expected an error", loss)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package losses
 
 import (
-	"errors"
 	"math"
 
 	t "github.com/cangeroe7/giraffe/pgk/tensor"
@@ -10,13 +9,8 @@ import (
 type MeanSquareError struct{}
 
 func (l *MeanSquareError) CalcLoss(yTrue, yPred t.Tensor) (float64, error) {
-	trueShape, predShape := yTrue.Shape(), yPred.Shape()
-	if len(trueShape) != len(predShape) {
-		for i := range trueShape {
-			if trueShape[i] != trueShape[i] {
-				return 0.0, errors.New("Dimensions do not match")
-			}
-		}
+	if !sameShape(yTrue.Shape(), yPred.Shape()) {
+		return 0.0, &t.ShapeMismatchError{Op: "MeanSquareError", Expected: yTrue.Shape().Clone(), Actual: yPred.Shape().Clone()}
 	}
 	diffs, _ := yTrue.Subtract(yPred, false)
 	squaredDiffs, _ := diffs.Multiply(diffs, true)
@@ -47,13 +41,8 @@ func (l *MeanSquareError) Accuracy(yTrue, yPred t.Tensor) (float64, error) {
 }
 
 func (l *MeanSquareError) Gradient(yTrue, yPred t.Tensor) (t.Tensor, error) {
-	trueShape, predShape := yTrue.Shape(), yPred.Shape()
-	if len(trueShape) != len(predShape) {
-		for i := range trueShape {
-			if trueShape[i] != trueShape[i] {
-				return nil, errors.New("Dimensions do not match")
-			}
-		}
+	if !sameShape(yTrue.Shape(), yPred.Shape()) {
+		return nil, &t.ShapeMismatchError{Op: "MeanSquareError", Expected: yTrue.Shape().Clone(), Actual: yPred.Shape().Clone()}
 	}
 
 	size := yTrue.Size()
@@ -61,7 +50,7 @@ func (l *MeanSquareError) Gradient(yTrue, yPred t.Tensor) (t.Tensor, error) {
 	if err != nil {
 		return nil, err
 	}
-	gradient := diffs.ScalarMultiply(2.0/float64(size), true)
+	diffs.ScalarMultiply(2.0/float64(size), true)
 
-	return gradient, nil
+	return diffs, nil
 }
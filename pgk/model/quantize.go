@@ -0,0 +1,200 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	la "github.com/cangeroe7/giraffe/pgk/layers"
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+// Quantize returns an int8 copy of the model for inference. Every Dense and
+// Conv2D layer is replaced by its quantized version, with the range of its
+// input taken from running calibration through the model, so calibration
+// should be a few hundred samples like the ones the model will see. The
+// calibration runs through a clone of s and the layers without weights come
+// from the same clone, so s can keep training. The quantized model keeps the
+// loss of s for CompareQuantized.
+func (s *sequential) Quantize(calibration t.Tensor) (*sequential, error) {
+	if calibration == nil {
+		return nil, errors.New("calibration data cannot be nil")
+	}
+
+	calibrated, err := s.clone()
+	if err != nil {
+		return nil, err
+	}
+
+	quantized := Sequential()
+	quantized.loss = s.loss
+
+	// Forward may change its input, so the calibration data is copied
+	output := calibration.AsType(calibration.DType())
+	for i, layer := range calibrated.layers {
+		switch layer := layer.(type) {
+		case *la.Dense:
+			var q *la.QuantizedDense
+			q, err = la.QuantizeDense(layer, output.Min(), output.Max())
+			quantized.Add(q)
+		case *la.Conv2D:
+			var q *la.QuantizedConv2D
+			q, err = la.QuantizeConv2D(layer, output.Min(), output.Max())
+			quantized.Add(q)
+		default:
+			quantized.Add(layer)
+		}
+
+		if err == nil {
+			output, err = layer.Forward(output)
+		}
+		if err != nil {
+			return nil, &la.LayerError{Index: i, Type: layer.Type(), Op: "Quantize", Err: err}
+		}
+	}
+
+	return quantized, nil
+}
+
+// QuantizationReport compares a quantized model with the float model it was
+// made from on the same data.
+type QuantizationReport struct {
+	FloatLoss         float64
+	QuantizedLoss     float64
+	FloatAccuracy     float64
+	QuantizedAccuracy float64
+	Agreement         float64 // Share of the samples both models predict the same class for
+	MaxAbsError       float64 // Largest difference between the outputs of the models
+	FloatBytes        int     // Size of the weights and biases of the float model
+	QuantizedBytes    int     // Size of the weights and biases of the quantized model
+}
+
+func (r *QuantizationReport) String() string {
+	return fmt.Sprintf("loss: %.4f -> %.4f, accuracy: %.4f -> %.4f, agreement: %.4f, max abs error: %.3e, size: %d -> %d bytes",
+		r.FloatLoss, r.QuantizedLoss, r.FloatAccuracy, r.QuantizedAccuracy, r.Agreement, r.MaxAbsError, r.FloatBytes, r.QuantizedBytes)
+}
+
+// CompareQuantized runs x through a clone of s and the quantized model made
+// from it and compares their outputs, losses and accuracies against y. The
+// loss of s is used for both, so s has to be compiled or have a loss set by
+// Compile after LoadModel. A single output counts as the class output >= 0.5.
+func (s *sequential) CompareQuantized(quantized *sequential, x, y t.Tensor) (*QuantizationReport, error) {
+	if quantized == nil || x == nil || y == nil {
+		return nil, errors.New("quantized model and data cannot be nil")
+	}
+
+	if s.loss == nil {
+		return nil, errors.New("loss has to be assigned")
+	}
+
+	float, err := s.clone()
+	if err != nil {
+		return nil, err
+	}
+
+	floatOutput, err := float.forward(x.AsType(x.DType()))
+	if err != nil {
+		return nil, err
+	}
+
+	quantizedOutput, err := quantized.forward(x.AsType(x.DType()))
+	if err != nil {
+		return nil, err
+	}
+
+	// Same layout of the labels as in Fit
+	yTrue := y.AsType(y.DType())
+	yTrue.Reshape([]int{yTrue.Shape().Batches(), yTrue.Shape().Cols()})
+
+	report := &QuantizationReport{
+		FloatBytes:     paramBytes(s.layers),
+		QuantizedBytes: paramBytes(quantized.layers),
+	}
+
+	report.FloatLoss, err = s.loss.CalcLoss(yTrue, floatOutput)
+	if err != nil {
+		return nil, fmt.Errorf("loss: %w", err)
+	}
+
+	report.QuantizedLoss, err = s.loss.CalcLoss(yTrue, quantizedOutput)
+	if err != nil {
+		return nil, fmt.Errorf("loss: %w", err)
+	}
+
+	report.FloatAccuracy, err = s.loss.Accuracy(yTrue, floatOutput)
+	if err != nil {
+		return nil, fmt.Errorf("accuracy: %w", err)
+	}
+
+	report.QuantizedAccuracy, err = s.loss.Accuracy(yTrue, quantizedOutput)
+	if err != nil {
+		return nil, fmt.Errorf("accuracy: %w", err)
+	}
+
+	floatValues := t.Values[float64](floatOutput)
+	quantizedValues := t.Values[float64](quantizedOutput)
+	if len(floatValues) != len(quantizedValues) {
+		return nil, &t.ShapeMismatchError{Op: "CompareQuantized", Expected: floatOutput.Shape().Clone(), Actual: quantizedOutput.Shape().Clone()}
+	}
+
+	for i, val := range floatValues {
+		report.MaxAbsError = math.Max(report.MaxAbsError, math.Abs(val-quantizedValues[i]))
+	}
+
+	classes := floatOutput.Shape().Cols()
+	samples := len(floatValues) / classes
+	agreed := 0
+	for i := range samples {
+		row := i * classes
+		if predictedClass(floatValues[row:row+classes]) == predictedClass(quantizedValues[row:row+classes]) {
+			agreed++
+		}
+	}
+	report.Agreement = float64(agreed) / float64(samples)
+
+	return report, nil
+}
+
+// predictedClass returns the index of the largest output, or for a single
+// output 1 when it is at least 0.5.
+func predictedClass(outputs []float64) int {
+	if len(outputs) == 1 {
+		if outputs[0] >= 0.5 {
+			return 1
+		}
+		return 0
+	}
+
+	class := 0
+	for i, val := range outputs {
+		if val > outputs[class] {
+			class = i
+		}
+	}
+	return class
+}
+
+// paramBytes returns the size of the weights and biases of layers, one byte
+// per int8 weight plus the scales and zero points for quantized layers.
+func paramBytes(layers []la.Layer) int {
+	size := 0
+	for _, layer := range layers {
+		if quantized, ok := layer.(la.QuantizedLayer); ok {
+			weights := quantized.QuantizedWeights()
+			size += len(weights.Data) + 8*len(weights.Scales) + 4*len(weights.ZeroPoints)
+		}
+
+		for _, param := range []t.Tensor{layer.Weights(), layer.Biases()} {
+			if param == nil {
+				continue
+			}
+
+			if param.DType() == t.Float32 {
+				size += 4 * param.Size()
+			} else {
+				size += 8 * param.Size()
+			}
+		}
+	}
+	return size
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"path/filepath"
+	"testing"
+
+	a "github.com/cangeroe7/giraffe/pgk/activations"
+	la "github.com/cangeroe7/giraffe/pgk/layers"
+	lo "github.com/cangeroe7/giraffe/pgk/losses"
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+// quantizableModel returns a compiled model with Conv2D, Pooling, Flatten and
+// Dense layers for (2, 8, 8) inputs.
+func quantizableModel(test *testing.T) *sequential {
+	model := Sequential(
+		&la.Conv2D{Filters: 4, KernelSize: [2]int{3, 3}, Strides: [2]int{1, 1}, Mode: la.Valid, Activation: &a.Relu{}},
+		&la.Pooling{PoolType: la.MaxPooling, KernelSize: [2]int{2, 2}, Strides: [2]int{2, 2}, Mode: la.Valid},
+		&la.Flatten{},
+		&la.Dense{Units: 8, Activation: &a.Relu{}},
+		&la.Dense{Units: 3, Activation: &a.Softmax{}},
+	)
+
+	if err := model.Compile([]int{2, 8, 8}, &lo.CategoricalCrossentropy{}, nil, nil, true); err != nil {
+		test.Fatal(err)
+	}
+	return model
+}
+
+func TestQuantizedModelSaveLoad(test *testing.T) {
+	model := quantizableModel(test)
+	calibration, _ := t.RandTensor([]int{16, 2, 8, 8}, -1, 1)
+
+	quantized, err := model.Quantize(calibration)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	path := filepath.Join(test.TempDir(), "quantized.json")
+	if err := quantized.SaveModel(path); err != nil {
+		test.Fatal(err)
+	}
+
+	loaded, err := LoadModel(path)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if len(loaded.layers) != len(quantized.layers) {
+		test.Fatalf("loaded %d layers, want %d", len(loaded.layers), len(quantized.layers))
+	}
+	for i, layer := range loaded.layers {
+		if layer.Type() != quantized.layers[i].Type() {
+			test.Errorf("layer %d loaded as %s, want %s", i, layer.Type(), quantized.layers[i].Type())
+		}
+	}
+
+	input, _ := t.RandTensor([]int{4, 2, 8, 8}, -1, 1)
+	expected, err := quantized.forward(input.AsType(input.DType()))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	output, err := loaded.forward(input.AsType(input.DType()))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	// The int8 weights and the scales are saved exactly
+	expectedValues := expected.DataCopy()
+	for i, val := range output.DataCopy() {
+		if val != expectedValues[i] {
+			test.Fatalf("value %d of the loaded model is %v, want %v", i, val, expectedValues[i])
+		}
+	}
+}
+
+// Quantize must not touch what the float layers keep for Backward.
+func TestQuantizeKeepsFloatState(test *testing.T) {
+	model := quantizableModel(test)
+	dense := model.layers[3].(*la.Dense)
+
+	input, _ := t.RandTensor([]int{4, 36}, -1, 1)
+	gradient, _ := t.RandTensor([]int{4, 8}, -1, 1)
+	calibration, _ := t.RandTensor([]int{16, 2, 8, 8}, -1, 1)
+
+	backward := func(quantize bool) []float64 {
+		if _, err := dense.Forward(input); err != nil {
+			test.Fatal(err)
+		}
+
+		if quantize {
+			if _, err := model.Quantize(calibration); err != nil {
+				test.Fatal(err)
+			}
+		}
+
+		if _, err := dense.Backward(gradient); err != nil {
+			test.Fatal(err)
+		}
+		return dense.WeightsGradient().DataCopy()
+	}
+
+	expected := backward(false)
+	for i, val := range backward(true) {
+		if val != expected[i] {
+			test.Fatalf("weights gradient %d is %v after quantizing, want %v", i, val, expected[i])
+		}
+	}
+}
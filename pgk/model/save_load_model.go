@@ -2,17 +2,28 @@ package model
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 	"os"
 
 	l "github.com/cangeroe7/giraffe/pgk/layers"
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
 )
 
 type serializableLayer struct {
-	Type    string                 `json:"type"`
-	Params  map[string]interface{} `json:"params"`
-	Weights []float64              `json:"weights,omitempty"`
-	Biases  []float64              `json:"biases,omitempty"`
+	Type      string                 `json:"type"`
+	Params    map[string]interface{} `json:"params"`
+	Weights   []float64              `json:"weights,omitempty"`
+	Biases    []float64              `json:"biases,omitempty"`
+	Quantized *serializableQTensor   `json:"quantized,omitempty"`
+}
+
+// serializableQTensor holds the int8 weights of a quantized layer.
+type serializableQTensor struct {
+	Shape      []int     `json:"shape"`
+	Data       []int8    `json:"data"`
+	Axis       int       `json:"axis"`
+	Scales     []float64 `json:"scales"`
+	ZeroPoints []int32   `json:"zero_points"`
 }
 
 type serializableModel struct {
@@ -20,6 +31,36 @@ type serializableModel struct {
 	History map[string][]float64 `json:"history"`
 }
 
+// serializeLayer gives the type, parameters and weights of a layer as they
+// are saved.
+func serializeLayer(layer l.Layer) serializableLayer {
+	layerInfo := serializableLayer{
+		Type:   layer.Type(),
+		Params: layer.Params(),
+	}
+
+	if weights := layer.Weights(); weights != nil {
+		layerInfo.Weights = weights.DataCopy()
+	}
+
+	if biases := layer.Biases(); biases != nil {
+		layerInfo.Biases = biases.DataCopy()
+	}
+
+	if quantized, ok := layer.(l.QuantizedLayer); ok {
+		weights := quantized.QuantizedWeights()
+		layerInfo.Quantized = &serializableQTensor{
+			Shape:      weights.Shape(),
+			Data:       weights.Data,
+			Axis:       weights.Axis,
+			Scales:     weights.Scales,
+			ZeroPoints: weights.ZeroPoints,
+		}
+	}
+
+	return layerInfo
+}
+
 func (s *sequential) SaveModel(path string) error {
 
 	serializedModel := serializableModel{
@@ -27,19 +68,7 @@ func (s *sequential) SaveModel(path string) error {
 	}
 
 	for _, layer := range s.layers {
-		layerInfo := serializableLayer{
-			Type:   layer.Type(),
-			Params: layer.Params(),
-		}
-
-		if weights := layer.Weights(); weights != nil {
-			layerInfo.Weights = weights.DataCopy()
-		}
-
-		if biases := layer.Biases(); biases != nil {
-			layerInfo.Biases = biases.DataCopy()
-		}
-		serializedModel.Layers = append(serializedModel.Layers, layerInfo)
+		serializedModel.Layers = append(serializedModel.Layers, serializeLayer(layer))
 	}
 
 	file, err := os.Create(path)
@@ -77,12 +106,12 @@ func LoadModel(path string) (*sequential, error) {
 	model.history = serializedModel.History
 
 	// Recreate each layer using their Load function
-	for _, layerInfo := range serializedModel.Layers {
+	for i, layerInfo := range serializedModel.Layers {
 
 		// Get the layer's Load function based on its type
-		layer, err := loadLayer(layerInfo.Type, layerInfo.Params, layerInfo.Weights, layerInfo.Biases)
+		layer, err := loadLayer(layerInfo)
 		if err != nil {
-			return nil, err
+			return nil, &l.LayerError{Index: i, Type: layerInfo.Type, Op: "Load", Err: err}
 		}
 
 		// Add the reconstructed layer to the model
@@ -92,12 +121,48 @@ func LoadModel(path string) (*sequential, error) {
 	return model, nil
 }
 
-func loadLayer(layerType string, params map[string]interface{}, weights []float64, biases []float64) (l.Layer, error) {
-	switch layerType {
+// clone returns a copy of the model with layers of its own, made by saving
+// and loading every layer, so running the copy leaves the inputs and other
+// state the layers of s keep for Backward alone. The loss is shared. Layers
+// that can't be saved, like AutogradLayer, can't be cloned either.
+func (s *sequential) clone() (*sequential, error) {
+	model := Sequential()
+	model.loss = s.loss
+
+	for i, layer := range s.layers {
+		// Through JSON, so the parameters have the types loading expects
+		data, err := json.Marshal(serializeLayer(layer))
+		if err != nil {
+			return nil, &l.LayerError{Index: i, Type: layer.Type(), Op: "Clone", Err: err}
+		}
+
+		var layerInfo serializableLayer
+		if err := json.Unmarshal(data, &layerInfo); err != nil {
+			return nil, &l.LayerError{Index: i, Type: layer.Type(), Op: "Clone", Err: err}
+		}
+
+		cloned, err := loadLayer(layerInfo)
+		if err != nil {
+			return nil, &l.LayerError{Index: i, Type: layer.Type(), Op: "Clone", Err: err}
+		}
+		model.Add(cloned)
+	}
+
+	return model, nil
+}
+
+func loadLayer(layerInfo serializableLayer) (l.Layer, error) {
+	params, weights, biases := layerInfo.Params, layerInfo.Weights, layerInfo.Biases
+
+	switch layerInfo.Type {
 	case "Dense":
 		return l.DenseFromParams(params, weights, biases)
 	case "Conv2D":
 		return l.Conv2DFromParams(params, weights, biases)
+	case "QuantizedDense":
+		return l.QuantizedDenseFromParams(params, layerInfo.Quantized.qTensor(), biases)
+	case "QuantizedConv2D":
+		return l.QuantizedConv2DFromParams(params, layerInfo.Quantized.qTensor(), biases)
 	case "Pooling":
 		return l.PoolingFromParams(params)
 	case "Flatten":
@@ -105,6 +170,22 @@ func loadLayer(layerType string, params map[string]interface{}, weights []float6
 	case "Input":
 		return l.InputFromParams(params)
 	default:
-		return nil, errors.New("LoadLayer() Error: Invalid layer type")
+		return nil, fmt.Errorf("invalid layer type %q", layerInfo.Type)
+	}
+}
+
+// qTensor turns the saved int8 weights back into a QTensor, nil when they
+// are missing.
+func (q *serializableQTensor) qTensor() *t.QTensor {
+	if q == nil || len(q.Data) != t.Shape(q.Shape).TotalSize() || len(q.Scales) == 0 || len(q.Scales) != len(q.ZeroPoints) {
+		return nil
+	}
+
+	return &t.QTensor{
+		TShape:     q.Shape,
+		Data:       q.Data,
+		Axis:       q.Axis,
+		Scales:     q.Scales,
+		ZeroPoints: q.ZeroPoints,
 	}
 }
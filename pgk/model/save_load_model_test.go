@@ -0,0 +1,79 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	a "github.com/cangeroe7/giraffe/pgk/activations"
+	la "github.com/cangeroe7/giraffe/pgk/layers"
+	lo "github.com/cangeroe7/giraffe/pgk/losses"
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+func TestSaveLoadKeepsFloat32(test *testing.T) {
+	model := Sequential(
+		&la.Conv2D{Filters: 2, KernelSize: [2]int{3, 3}, Strides: [2]int{1, 1}, Mode: la.Valid, Activation: &a.Relu{}, DType: t.Float32},
+		&la.Flatten{},
+		&la.Dense{Units: 3, Activation: &a.Softmax{}, DType: t.Float32},
+	)
+	if err := model.Compile([]int{1, 5, 5}, &lo.CategoricalCrossentropy{}, nil, nil, true); err != nil {
+		test.Fatal(err)
+	}
+
+	path := filepath.Join(test.TempDir(), "float32.json")
+	if err := model.SaveModel(path); err != nil {
+		test.Fatal(err)
+	}
+
+	loaded, err := LoadModel(path)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	for i, layer := range loaded.layers {
+		if weights := layer.Weights(); weights != nil && weights.DType() != t.Float32 {
+			test.Errorf("weights of layer %d loaded as %s, want float32", i, weights.DType())
+		}
+		if biases := layer.Biases(); biases != nil && biases.DType() != t.Float32 {
+			test.Errorf("biases of layer %d loaded as %s, want float32", i, biases.DType())
+		}
+	}
+
+	input, _ := t.RandTensor([]int{4, 1, 5, 5}, -1, 1)
+	expected, err := model.forward(input.AsType(input.DType()))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	output, err := loaded.forward(input.AsType(input.DType()))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if output.DType() != t.Float32 {
+		test.Errorf("output of the loaded model has dtype %s, want float32", output.DType())
+	}
+
+	// float32 values go through JSON as float64 and back exactly
+	expectedValues := expected.DataCopy()
+	for i, val := range output.DataCopy() {
+		if val != expectedValues[i] {
+			test.Fatalf("value %d of the loaded model is %v, want %v", i, val, expectedValues[i])
+		}
+	}
+}
+
+func TestLoadModelInvalidDType(test *testing.T) {
+	path := filepath.Join(test.TempDir(), "float23.json")
+	saved := `{"layers": [{"type": "Dense", "params": {"units": 1, "activation": "sigmoid", "dtype": "float23"}, "weights": [1, 2], "biases": [0]}]}`
+	if err := os.WriteFile(path, []byte(saved), 0o644); err != nil {
+		test.Fatal(err)
+	}
+
+	_, err := LoadModel(path)
+	if err == nil || !strings.Contains(err.Error(), "float23") {
+		test.Errorf("error %v, want one naming the dtype float23", err)
+	}
+}
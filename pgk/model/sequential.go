@@ -28,6 +28,7 @@ type sequential struct {
 	batchSize int
 	epochs    int
 	history   map[string]([]float64)
+	rng       *t.RNG
 }
 
 func Sequential(layers ...la.Layer) *sequential {
@@ -39,6 +40,12 @@ func Sequential(layers ...la.Layer) *sequential {
 	return &model
 }
 
+// SetRNG sets the RNG that shuffles the training data in Fit, by default the
+// global RNG is used.
+func (s *sequential) SetRNG(rng *t.RNG) {
+	s.rng = rng
+}
+
 func (s *sequential) Add(layer la.Layer) {
 	s.layers = append(s.layers, layer)
 }
@@ -47,11 +54,11 @@ func (s *sequential) Compile(inputShape []int, loss lo.Loss, optimizer o.Optimiz
 	// TODO: Check if all layers can be or are properly connected to each other
 	if compileLayers {
 		outputShape := inputShape
-		for _, layer := range s.layers {
+		for i, layer := range s.layers {
 			var err error
 			outputShape, err = layer.CompileLayer(outputShape)
 			if err != nil {
-				return err
+				return &la.LayerError{Index: i, Type: layer.Type(), Op: "CompileLayer", Err: err}
 			}
 		}
 	}
@@ -83,7 +90,9 @@ func (s *sequential) Fit(xTrain, yTrain t.Tensor, batchSize, epochs int, normali
 		totalAccuracy := 0.0
 
 		// Shuffle data for each epoch to prevent overfitting to fixed batches
-		t.Shuffle(xTrain, yTrain)
+		if err := s.rng.OrGlobal().Shuffle(xTrain, yTrain); err != nil {
+			return err
+		}
 
 		numBatches := (xTrain.Shape().Batches() + batchSize - 1) / batchSize
 
@@ -111,31 +120,27 @@ func (s *sequential) Fit(xTrain, yTrain t.Tensor, batchSize, epochs int, normali
 			YBatch.Reshape([]int{YBatch.Shape().Batches(), YBatch.Shape().Cols()})
 
 			// Forward pass
-			output := XBatch
-			for _, layer := range s.layers {
-				output, err = layer.Forward(output)
-
-				if err != nil {
-					return err
-				}
+			output, err := s.forward(XBatch)
+			if err != nil {
+				return err
 			}
 
 			// Calculate loss and loss gradient
 			loss, err := s.loss.CalcLoss(YBatch, output)
 			if err != nil {
-				return err
+				return fmt.Errorf("loss: %w", err)
 			}
 			totalLoss += loss
 
 			accuracy, err := s.loss.Accuracy(YBatch, output)
 			if err != nil {
-				return err
+				return fmt.Errorf("accuracy: %w", err)
 			}
 			totalAccuracy += accuracy
 
 			lossGradient, err := s.loss.Gradient(YBatch, output)
 			if err != nil {
-				return err
+				return fmt.Errorf("loss gradient: %w", err)
 			}
 
 			// Backward pass
@@ -143,14 +148,19 @@ func (s *sequential) Fit(xTrain, yTrain t.Tensor, batchSize, epochs int, normali
 			for i := len(s.layers) - 1; i >= 0; i-- {
 				grad, err = s.layers[i].Backward(grad)
 				if err != nil {
-					return err
+					return &la.LayerError{Index: i, Type: s.layers[i].Type(), Op: "Backward", Err: err}
 				}
 			}
 
 			// Update weights and biases for each layer
 			for i, layer := range s.layers {
-				s.optimizer.Apply(fmt.Sprintf("layer%d_weights", i+1), layer.Weights(), layer.WeightsGradient())
-				s.optimizer.Apply(fmt.Sprintf("layer%d_biases", i+1), layer.Biases(), layer.BiasesGradient())
+				err := s.optimizer.Apply(fmt.Sprintf("layer%d_weights", i+1), layer.Weights(), layer.WeightsGradient())
+				if err == nil {
+					err = s.optimizer.Apply(fmt.Sprintf("layer%d_biases", i+1), layer.Biases(), layer.BiasesGradient())
+				}
+				if err != nil {
+					return &la.LayerError{Index: i, Type: layer.Type(), Op: "Apply", Err: err}
+				}
 			}
 		}
 
@@ -177,13 +187,9 @@ func (s *sequential) History(metrics ...string) map[string]([]float64) {
 func (s *sequential) Evaluate(input t.Tensor) (t.Tensor, error) {
 	// Normalize data if normalization is used
 
-	output := input
-	var err error
-	for _, layer := range s.layers {
-		output, err = layer.Forward(output)
-		if err != nil {
-			return nil, err
-		}
+	output, err := s.forward(input)
+	if err != nil {
+		return nil, err
 	}
 	round := func(x float64) (float64, error) {
 		return math.Round(x*10000) / 10000, nil
@@ -193,3 +199,17 @@ func (s *sequential) Evaluate(input t.Tensor) (t.Tensor, error) {
 
 	return output, nil
 }
+
+// forward runs input through every layer, failures are wrapped in a
+// LayerError naming the layer.
+func (s *sequential) forward(input t.Tensor) (t.Tensor, error) {
+	output := input
+	for i, layer := range s.layers {
+		var err error
+		output, err = layer.Forward(output)
+		if err != nil {
+			return nil, &la.LayerError{Index: i, Type: layer.Type(), Op: "Forward", Err: err}
+		}
+	}
+	return output, nil
+}
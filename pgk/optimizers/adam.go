@@ -29,8 +29,8 @@ func (a *Adam) Apply(key string, param, gradient t.Tensor) error {
 	if _, ok := a.MT[key]; !ok {
 		shape := param.Shape()
 
-		a.MT[key] = t.ZerosTensor(shape)
-		a.VT[key] = t.ZerosTensor(shape)
+		a.MT[key] = t.Zeros(shape, param.DType())
+		a.VT[key] = t.Zeros(shape, param.DType())
 	}
 
 	mt := a.MT[key]
@@ -101,6 +101,7 @@ func (a *Adam) Apply(key string, param, gradient t.Tensor) error {
 	}
 
 	scaledGrad, err := mtHat.MapBatch(scaleGrad, false, vtHat)
+	t.Release(mtHat, vtHat)
 	if err != nil {
 		fmt.Printf("err scaling grad: %v\n", err)
 		return err
@@ -108,6 +109,7 @@ func (a *Adam) Apply(key string, param, gradient t.Tensor) error {
 
 	// Update the parameter
 	_, err = param.Subtract(scaledGrad, true)
+	t.Release(scaledGrad)
 	if err != nil {
 		fmt.Printf("err updating params: %v\n", err)
 		return err
@@ -0,0 +1,33 @@
+package optimizers
+
+import (
+	"testing"
+
+	t "github.com/cangeroe7/giraffe/pgk/tensor"
+)
+
+// BenchmarkAdam times an Adam step on a million parameters, once with a
+// single elementwise worker and once with the default pool.
+func BenchmarkAdam(b *testing.B) {
+	defer t.SetElementwiseWorkers(0)
+
+	param, _ := t.RandTensor([]int{1000, 1000}, -1, 1)
+	gradient, _ := t.RandTensor([]int{1000, 1000}, -1, 1)
+
+	for _, workers := range []int{1, 0} {
+		name := "1_worker"
+		if workers == 0 {
+			name = "default_pool"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			t.SetElementwiseWorkers(workers)
+			adam := &Adam{}
+			adam.Initialize()
+
+			for range b.N {
+				adam.Apply("weights", param, gradient)
+			}
+		})
+	}
+}
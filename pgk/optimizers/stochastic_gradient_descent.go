@@ -5,6 +5,8 @@ import (
 
 	t "github.com/cangeroe7/giraffe/pgk/tensor"
 )
+
+// SGD subtracts the gradient scaled by LearningRate from every parameter.
 type SGD struct {
   LearningRate float64
 }
@@ -0,0 +1,597 @@
+package tensor
+
+import (
+	"errors"
+	"math"
+)
+
+// Tape records the operations applied to Variables during a forward pass so
+// the gradients can be computed by walking the operations in reverse.
+type Tape struct {
+	nodes []*Variable
+}
+
+// Variable is a Tensor that is tracked by a Tape.
+type Variable struct {
+	Value Tensor
+	Grad  Tensor
+
+	RequiresGrad bool
+
+	parents  []*Variable
+	backward func(gradient Tensor) ([]Tensor, error)
+}
+
+func NewTape() *Tape {
+	return &Tape{}
+}
+
+// Variable registers a leaf tensor whose gradient should be computed.
+func (tp *Tape) Variable(value Tensor) *Variable {
+	v := &Variable{Value: value, RequiresGrad: true}
+	tp.nodes = append(tp.nodes, v)
+	return v
+}
+
+// Constant registers a leaf tensor that does not need a gradient.
+func (tp *Tape) Constant(value Tensor) *Variable {
+	v := &Variable{Value: value}
+	tp.nodes = append(tp.nodes, v)
+	return v
+}
+
+// Reset forgets all the recorded operations so the tape can be reused.
+func (tp *Tape) Reset() {
+	tp.nodes = nil
+}
+
+func (tp *Tape) record(value Tensor, backward func(gradient Tensor) ([]Tensor, error), parents ...*Variable) *Variable {
+	requiresGrad := false
+	for _, parent := range parents {
+		if parent.RequiresGrad {
+			requiresGrad = true
+		}
+	}
+
+	v := &Variable{Value: value, RequiresGrad: requiresGrad, parents: parents}
+	if requiresGrad {
+		v.backward = backward
+	}
+
+	tp.nodes = append(tp.nodes, v)
+	return v
+}
+
+// Backward propagates gradient from root back through every recorded
+// operation. When gradient is nil root has to hold a single value and a
+// gradient of one is used. The gradients of the intermediate results are
+// cleared first, so calling Backward again on the same tape gives them the
+// same values. The gradients of the leaves made by Variable accumulate over
+// calls, call ZeroGrad to start them over.
+func (tp *Tape) Backward(root *Variable, gradient Tensor) error {
+	if root == nil {
+		return errors.New("backward: root cannot be nil")
+	}
+
+	if gradient == nil {
+		if root.Value.Size() != 1 {
+			return errors.New("backward: gradient can only be omitted for single value roots")
+		}
+		gradient = Zeros(root.Value.Shape(), root.Value.DType())
+		gradient.SetValueAt(0, 1.0)
+	}
+
+	if !root.Value.Shape().Eq(gradient.Shape()) {
+		return shapeMismatch("backward", root.Value.Shape(), gradient.Shape())
+	}
+
+	// A gradient left on an intermediate result by an earlier call would be
+	// propagated to the leaves a second time
+	for _, node := range tp.nodes {
+		if node.parents != nil {
+			node.Grad = nil
+		}
+	}
+
+	if err := root.accumulate(gradient); err != nil {
+		return err
+	}
+
+	// Nodes are appended in the order they are created, so walking the tape
+	// backwards always visits a node after everything that depends on it.
+	for i := len(tp.nodes) - 1; i >= 0; i-- {
+		node := tp.nodes[i]
+		if node.backward == nil || node.Grad == nil {
+			continue
+		}
+
+		parentGrads, err := node.backward(node.Grad)
+		if err != nil {
+			return err
+		}
+
+		for j, parent := range node.parents {
+			if !parent.RequiresGrad || parentGrads[j] == nil {
+				continue
+			}
+
+			if err := parent.accumulate(parentGrads[j]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *Variable) accumulate(gradient Tensor) error {
+	if v.Grad == nil {
+		v.Grad = fromFloat64s(v.Value.Shape(), gradient.DataCopy(), v.Value.DType())
+		return nil
+	}
+
+	_, err := v.Grad.Add(gradient, true)
+	return err
+}
+
+// ZeroGrad clears the gradient of the variable.
+func (v *Variable) ZeroGrad() {
+	v.Grad = nil
+}
+
+// ZeroGrad clears the gradients of every variable on the tape, the leaves
+// included.
+func (tp *Tape) ZeroGrad() {
+	for _, node := range tp.nodes {
+		node.ZeroGrad()
+	}
+}
+
+func (tp *Tape) Add(a, b *Variable) (*Variable, error) {
+	value, err := a.Value.Add(b.Value, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		return []Tensor{sumToShape(gradient, a.Value.Shape()), sumToShape(gradient, b.Value.Shape())}, nil
+	}, a, b), nil
+}
+
+func (tp *Tape) Subtract(a, b *Variable) (*Variable, error) {
+	value, err := a.Value.Subtract(b.Value, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		gradB := gradient.ScalarMultiply(-1.0, false)
+		return []Tensor{sumToShape(gradient, a.Value.Shape()), sumToShape(gradB, b.Value.Shape())}, nil
+	}, a, b), nil
+}
+
+func (tp *Tape) Multiply(a, b *Variable) (*Variable, error) {
+	value, err := a.Value.Multiply(b.Value, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		gradA, err := gradient.Multiply(b.Value, false)
+		if err != nil {
+			return nil, err
+		}
+
+		gradB, err := gradient.Multiply(a.Value, false)
+		if err != nil {
+			return nil, err
+		}
+
+		return []Tensor{sumToShape(gradA, a.Value.Shape()), sumToShape(gradB, b.Value.Shape())}, nil
+	}, a, b), nil
+}
+
+func (tp *Tape) Divide(a, b *Variable) (*Variable, error) {
+	value, err := a.Value.Divide(b.Value, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		// d(a/b)/da = 1/b
+		gradA, err := gradient.Divide(b.Value, false)
+		if err != nil {
+			return nil, err
+		}
+
+		// d(a/b)/db = -(a/b)/b
+		gradB, err := gradA.Multiply(value, false)
+		if err != nil {
+			return nil, err
+		}
+		gradB.ScalarMultiply(-1.0, true)
+
+		return []Tensor{sumToShape(gradA, a.Value.Shape()), sumToShape(gradB, b.Value.Shape())}, nil
+	}, a, b), nil
+}
+
+// RepAdd records a.RepAdd(b), the gradient of b is summed over the
+// positions it was repeated over.
+func (tp *Tape) RepAdd(a, b *Variable) (*Variable, error) {
+	value, err := a.Value.RepAdd(b.Value, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		return []Tensor{gradient, reduceRepeated(gradient, b.Value.Shape())}, nil
+	}, a, b), nil
+}
+
+func (tp *Tape) MatMul(a, b *Variable) (*Variable, error) {
+	value, err := a.Value.MatMul(b.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		gradA, err := gradient.MatMul(b.Value.Transpose(false))
+		if err != nil {
+			return nil, err
+		}
+
+		gradB, err := a.Value.Transpose(false).MatMul(gradient)
+		if err != nil {
+			return nil, err
+		}
+
+		// Matrices broadcast over leading dimensions get the sum of their gradients
+		return []Tensor{sumToShape(gradA, a.Value.Shape()), sumToShape(gradB, b.Value.Shape())}, nil
+	}, a, b), nil
+}
+
+func (tp *Tape) ScalarAdd(a *Variable, x float64) *Variable {
+	return tp.record(a.Value.ScalarAdd(x, false), func(gradient Tensor) ([]Tensor, error) {
+		return []Tensor{gradient}, nil
+	}, a)
+}
+
+func (tp *Tape) ScalarMultiply(a *Variable, x float64) *Variable {
+	return tp.record(a.Value.ScalarMultiply(x, false), func(gradient Tensor) ([]Tensor, error) {
+		return []Tensor{gradient.ScalarMultiply(x, false)}, nil
+	}, a)
+}
+
+func (tp *Tape) Transpose(a *Variable) *Variable {
+	return tp.record(a.Value.Transpose(false), func(gradient Tensor) ([]Tensor, error) {
+		return []Tensor{gradient.Transpose(false)}, nil
+	}, a)
+}
+
+func (tp *Tape) Reshape(a *Variable, shape Shape) (*Variable, error) {
+	value := a.Value.AsType(a.Value.DType())
+	if err := value.Reshape(shape); err != nil {
+		return nil, err
+	}
+
+	inShape := a.Value.Shape().Clone()
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		return []Tensor{fromFloat64s(inShape, gradient.DataCopy(), gradient.DType())}, nil
+	}, a), nil
+}
+
+// Sum reduces a to a 1x1 tensor holding the sum of all its values.
+func (tp *Tape) Sum(a *Variable) *Variable {
+	value := fromFloat64s([]int{1, 1}, []float64{a.Value.Sum()}, a.Value.DType())
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		grad := Zeros(a.Value.Shape(), gradient.DType())
+		grad.ScalarAdd(gradient.ValueAt(0), true)
+		return []Tensor{grad}, nil
+	}, a)
+}
+
+// Mean reduces a to a 1x1 tensor holding the average of all its values.
+func (tp *Tape) Mean(a *Variable) *Variable {
+	return tp.ScalarMultiply(tp.Sum(a), 1.0/float64(a.Value.Size()))
+}
+
+// Map records an elementwise function, derivative receives the input value
+// and the output value and returns the local derivative.
+func (tp *Tape) Map(a *Variable, fn func(float64) (float64, error), derivative func(x, y float64) float64) (*Variable, error) {
+	value, err := a.Value.Map(fn, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		grad, err := gradient.MapBatch(func(vals ...float64) (float64, error) {
+			return vals[0] * derivative(vals[1], vals[2]), nil
+		}, false, a.Value, value)
+		if err != nil {
+			return nil, err
+		}
+		return []Tensor{grad}, nil
+	}, a), nil
+}
+
+func (tp *Tape) Exp(a *Variable) (*Variable, error) {
+	return tp.Map(a, func(x float64) (float64, error) {
+		return math.Exp(x), nil
+	}, func(x, y float64) float64 {
+		return y
+	})
+}
+
+func (tp *Tape) Log(a *Variable) (*Variable, error) {
+	return tp.Map(a, func(x float64) (float64, error) {
+		if x <= 0 {
+			return 0.0, errors.New("Cannot take the log of a non positive value")
+		}
+		return math.Log(x), nil
+	}, func(x, y float64) float64 {
+		return 1 / x
+	})
+}
+
+func (tp *Tape) Square(a *Variable) (*Variable, error) {
+	return tp.Map(a, func(x float64) (float64, error) {
+		return x * x, nil
+	}, func(x, y float64) float64 {
+		return 2 * x
+	})
+}
+
+func (tp *Tape) Sqrt(a *Variable) (*Variable, error) {
+	return tp.Map(a, func(x float64) (float64, error) {
+		if x < 0 {
+			return 0.0, errors.New("Cannot take the square root of a negative value")
+		}
+		return math.Sqrt(x), nil
+	}, func(x, y float64) float64 {
+		return 0.5 / y
+	})
+}
+
+func (tp *Tape) Relu(a *Variable) (*Variable, error) {
+	return tp.Map(a, func(x float64) (float64, error) {
+		return math.Max(x, 0.0), nil
+	}, func(x, y float64) float64 {
+		if x > 0 {
+			return 1.0
+		}
+		return 0.0
+	})
+}
+
+func (tp *Tape) Sigmoid(a *Variable) (*Variable, error) {
+	return tp.Map(a, func(x float64) (float64, error) {
+		return 1 / (1 + math.Exp(-x)), nil
+	}, func(x, y float64) float64 {
+		return y * (1 - y)
+	})
+}
+
+func (tp *Tape) Tanh(a *Variable) (*Variable, error) {
+	return tp.Map(a, func(x float64) (float64, error) {
+		return math.Tanh(x), nil
+	}, func(x, y float64) float64 {
+		return 1 - y*y
+	})
+}
+
+// Softmax applies a softmax over every row of a.
+func (tp *Tape) Softmax(a *Variable) (*Variable, error) {
+	shape := a.Value.Shape().Clone()
+	cols := shape.Cols()
+	data := a.Value.DataCopy()
+	for start := 0; start < len(data); start += cols {
+		row := data[start : start+cols]
+
+		maxVal := row[0]
+		for _, x := range row {
+			maxVal = math.Max(maxVal, x)
+		}
+
+		sum := 0.0
+		for i, x := range row {
+			row[i] = math.Exp(x - maxVal)
+			sum += row[i]
+		}
+
+		for i := range row {
+			row[i] /= sum
+		}
+	}
+
+	value := fromFloat64s(shape, data, a.Value.DType())
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		// dx_i = y_i * (g_i - sum_j g_j * y_j)
+		gradData := make([]float64, len(data))
+		gData := float64s(gradient)
+		for start := 0; start < len(data); start += cols {
+			dot := 0.0
+			for i := start; i < start+cols; i++ {
+				dot += gData[i] * data[i]
+			}
+
+			for i := start; i < start+cols; i++ {
+				gradData[i] = data[i] * (gData[i] - dot)
+			}
+		}
+
+		return []Tensor{fromFloat64s(shape, gradData, gradient.DType())}, nil
+	}, a), nil
+}
+
+// CrossCorrelate slides kernel over input, both shaped (channels, rows, cols),
+// summing the result of every channel into a single output matrix.
+func (tp *Tape) CrossCorrelate(input, kernel *Variable, strides [2]int) (*Variable, error) {
+	if input.Value.Shape().Channels() != kernel.Value.Shape().Channels() {
+		return nil, errors.New("input doesn't have the same amount of channels as filters")
+	}
+
+	if strides[0] <= 0 || strides[1] <= 0 {
+		return nil, errors.New("strides have to be positive")
+	}
+
+	inShape, kShape := input.Value.Shape(), kernel.Value.Shape()
+	outRows := (inShape.Rows()-kShape.Rows())/strides[0] + 1
+	outCols := (inShape.Cols()-kShape.Cols())/strides[1] + 1
+
+	value := Zeros([]int{outRows, outCols}, input.Value.DType())
+
+	inIter, err := IterMatrices(input.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	kIter, err := IterMatrices(kernel.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	for inMat, ok := inIter.Next(); ok; inMat, ok = inIter.Next() {
+		kMat, _ := kIter.Next()
+
+		if _, err := inMat.CrossCorrelate(kMat, strides, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		inData := float64s(input.Value)
+		kData := float64s(kernel.Value)
+		gData := float64s(gradient)
+		gradInData := make([]float64, inShape.TotalSize())
+		gradKData := make([]float64, kShape.TotalSize())
+
+		inRows, inCols := inShape.Rows(), inShape.Cols()
+		kRows, kCols := kShape.Rows(), kShape.Cols()
+
+		for c := range inShape.Channels() {
+			inChannel := c * inRows * inCols
+			kChannel := c * kRows * kCols
+
+			for i := range outRows {
+				for j := range outCols {
+					g := gData[i*outCols+j]
+
+					for ki := range kRows {
+						for kj := range kCols {
+							inIdx := inChannel + (i*strides[0]+ki)*inCols + j*strides[1] + kj
+							kIdx := kChannel + ki*kCols + kj
+
+							gradKData[kIdx] += g * inData[inIdx]
+							gradInData[inIdx] += g * kData[kIdx]
+						}
+					}
+				}
+			}
+		}
+
+		gradInput := fromFloat64s(inShape, gradInData, input.Value.DType())
+		gradKernel := fromFloat64s(kShape, gradKData, kernel.Value.DType())
+
+		return []Tensor{gradInput, gradKernel}, nil
+	}, input, kernel), nil
+}
+
+// Take picks values of a by row major index, the gradient of each value goes
+// back to where it was taken from.
+func (tp *Tape) Take(a *Variable, indices []int) (*Variable, error) {
+	value, err := a.Value.Take(indices)
+	if err != nil {
+		return nil, err
+	}
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		gData := float64s(gradient)
+		resData := make([]float64, a.Value.Size())
+		for i, idx := range indices {
+			resData[idx] += gData[i]
+		}
+		return []Tensor{fromFloat64s(a.Value.Shape(), resData, gradient.DType())}, nil
+	}, a), nil
+}
+
+// IndexSelect picks the slices of a along axis at indices, the gradients of
+// repeated indices are summed.
+func (tp *Tape) IndexSelect(a *Variable, axis int, indices []int) (*Variable, error) {
+	value, err := a.Value.IndexSelect(axis, indices)
+	if err != nil {
+		return nil, err
+	}
+
+	// Checked by IndexSelect
+	axis, _ = tensorAxis(a.Value.Dims(), axis)
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		gData := float64s(gradient)
+		resData := make([]float64, a.Value.Size())
+		indexLoop(gradient.Shape(), a.Value.Shape().CalcStrides(), axis, indices, func(i, offset int) {
+			resData[offset] += gData[i]
+		})
+		return []Tensor{fromFloat64s(a.Value.Shape(), resData, gradient.DType())}, nil
+	}, a), nil
+}
+
+// Gather picks a value of a along axis for every element of index, its
+// gradient is scattered back with ScatterAdd.
+func (tp *Tape) Gather(a *Variable, axis int, index Tensor) (*Variable, error) {
+	value, err := a.Value.Gather(axis, index)
+	if err != nil {
+		return nil, err
+	}
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		gradA, err := Zeros(a.Value.Shape(), gradient.DType()).ScatterAdd(axis, index, gradient, true)
+		if err != nil {
+			return nil, err
+		}
+		return []Tensor{gradA}, nil
+	}, a), nil
+}
+
+// ScatterAdd adds src into a at the positions along axis given by index. The
+// gradient of src is gathered from the same positions.
+func (tp *Tape) ScatterAdd(a *Variable, axis int, index Tensor, src *Variable) (*Variable, error) {
+	value, err := a.Value.ScatterAdd(axis, index, src.Value, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return tp.record(value, func(gradient Tensor) ([]Tensor, error) {
+		gradSrc, err := gradient.Gather(axis, index)
+		if err != nil {
+			return nil, err
+		}
+		return []Tensor{gradient, gradSrc}, nil
+	}, a, src), nil
+}
+
+// reduceRepeated sums gradient into shape, undoing the index wrapping used by
+// RepAdd and RepMultiply.
+func reduceRepeated(gradient Tensor, shape Shape) Tensor {
+	resData := make([]float64, shape.TotalSize())
+	gData := float64s(gradient)
+
+	gShape := gradient.Shape()
+	bats, chas, rows, cols := gShape.Batches(), gShape.Channels(), gShape.Rows(), gShape.Cols()
+	oBats, oChs, oRows, oCols := shape.Batches(), shape.Channels(), shape.Rows(), shape.Cols()
+
+	for b := range bats {
+		for c := range chas {
+			for i := range rows {
+				for j := range cols {
+					gIdx := ((b*chas+c)*rows+i)*cols + j
+					resIdx := (((b%oBats)*oChs+c%oChs)*oRows+i%oRows)*oCols + j%oCols
+					resData[resIdx] += gData[gIdx]
+				}
+			}
+		}
+	}
+
+	return fromFloat64s(shape, resData, gradient.DType())
+}
@@ -0,0 +1,179 @@
+package tensor
+
+import (
+	"math"
+	"testing"
+)
+
+// squaredSum records sum(x * w) squared, so the gradients of x and w depend on
+// the gradients of the intermediate results.
+func squaredSum(t *testing.T) (*Tape, *Variable, *Variable, *Variable) {
+	tp := NewTape()
+	x := tp.Variable(fromFloat64s(Shape{2, 2}, []float64{1, 2, 3, 4}, Float64))
+	w := tp.Variable(fromFloat64s(Shape{2, 2}, []float64{0.5, -1, 2, 0}, Float64))
+
+	product, err := tp.Multiply(x, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	square, err := tp.Square(tp.Sum(product))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tp, x, w, square
+}
+
+func TestBackwardTwice(t *testing.T) {
+	tp, x, w, root := squaredSum(t)
+
+	if err := tp.Backward(root, nil); err != nil {
+		t.Fatal(err)
+	}
+	first := Values[float64](x.Grad)
+
+	// Without clearing, the gradient of the intermediate results would be
+	// propagated again on top of their old one
+	tp.ZeroGrad()
+	if err := tp.Backward(root, nil); err != nil {
+		t.Fatal(err)
+	}
+	for i, val := range Values[float64](x.Grad) {
+		if val != first[i] {
+			t.Fatalf("gradient %d of x is %v after ZeroGrad and a second Backward, want %v", i, val, first[i])
+		}
+	}
+
+	// Without ZeroGrad the leaves accumulate, exactly twice the gradient
+	if err := tp.Backward(root, nil); err != nil {
+		t.Fatal(err)
+	}
+	for i, val := range Values[float64](x.Grad) {
+		if val != 2*first[i] {
+			t.Fatalf("gradient %d of x is %v after a third Backward, want %v", i, val, 2*first[i])
+		}
+	}
+
+	// d/dw sum(x*w)^2 = 2 * sum(x*w) * x, with sum(x*w) = 0.5 - 2 + 6 + 0
+	want := []float64{9, 18, 27, 36}
+	tp.ZeroGrad()
+	if err := tp.Backward(root, nil); err != nil {
+		t.Fatal(err)
+	}
+	for i, val := range Values[float64](w.Grad) {
+		if val != want[i] {
+			t.Fatalf("gradient %d of w is %v, want %v", i, val, want[i])
+		}
+	}
+}
+
+// checkGradients runs objective on a tape with a Variable for every value,
+// then compares the gradients Backward gives with central differences of the
+// objective.
+func checkGradients(t *testing.T, objective func(tp *Tape, vars []*Variable) (*Variable, error), values ...Tensor) {
+	t.Helper()
+
+	run := func() (float64, []*Variable) {
+		tp := NewTape()
+		vars := make([]*Variable, len(values))
+		for i, val := range values {
+			vars[i] = tp.Variable(val)
+		}
+
+		root, err := objective(tp, vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tp.Backward(root, nil); err != nil {
+			t.Fatal(err)
+		}
+		return root.Value.ValueAt(0), vars
+	}
+
+	_, vars := run()
+
+	const eps = 1e-6
+	for v, val := range values {
+		analytical := Values[float64](vars[v].Grad)
+		if !vars[v].Grad.Shape().DeepEq(val.Shape()) {
+			t.Fatalf("gradient %d has shape %v, want %v", v, vars[v].Grad.Shape(), val.Shape())
+		}
+
+		for i := range val.Size() {
+			orig := val.ValueAt(i)
+
+			val.SetValueAt(i, orig+eps)
+			plus, _ := run()
+			val.SetValueAt(i, orig-eps)
+			minus, _ := run()
+			val.SetValueAt(i, orig)
+
+			numerical := (plus - minus) / (2 * eps)
+			if diff := math.Abs(numerical - analytical[i]); diff > 1e-5*math.Max(1, math.Abs(numerical)) {
+				t.Fatalf("gradient %d, value %d: got %v, numerical %v", v, i, analytical[i], numerical)
+			}
+		}
+	}
+}
+
+// weightedSum returns the sum of v times weights, with weights fixed, so every
+// value of v gets its own gradient.
+func weightedSum(tp *Tape, v *Variable, weights Tensor) (*Variable, error) {
+	weighted, err := tp.Multiply(v, tp.Constant(weights))
+	if err != nil {
+		return nil, err
+	}
+	return tp.Sum(weighted), nil
+}
+
+func TestTapeGradients(t *testing.T) {
+	rng := NewRNG(4)
+	random := func(shape ...int) Tensor {
+		ten, _ := rng.RandTensor(shape, -1, 1)
+		return ten
+	}
+
+	binary := func(op func(tp *Tape, a, b *Variable) (*Variable, error), weights Tensor) func(tp *Tape, vars []*Variable) (*Variable, error) {
+		return func(tp *Tape, vars []*Variable) (*Variable, error) {
+			res, err := op(tp, vars[0], vars[1])
+			if err != nil {
+				return nil, err
+			}
+			return weightedSum(tp, res, weights)
+		}
+	}
+
+	crossCorrelate := func(strides [2]int) func(tp *Tape, a, b *Variable) (*Variable, error) {
+		return func(tp *Tape, a, b *Variable) (*Variable, error) {
+			return tp.CrossCorrelate(a, b, strides)
+		}
+	}
+
+	cases := []struct {
+		name      string
+		objective func(tp *Tape, vars []*Variable) (*Variable, error)
+		values    []Tensor
+	}{
+		{"matmul", binary((*Tape).MatMul, random(3, 2)), []Tensor{random(3, 4), random(4, 2)}},
+		{"add broadcast row", binary((*Tape).Add, random(2, 3)), []Tensor{random(2, 3), random(1, 3)}},
+		{"add broadcast column", binary((*Tape).Add, random(2, 3)), []Tensor{random(2, 1), random(2, 3)}},
+		{"add broadcast both", binary((*Tape).Add, random(3, 4)), []Tensor{random(3, 1), random(1, 4)}},
+		{"add broadcast leading", binary((*Tape).Add, random(2, 3, 4)), []Tensor{random(2, 3, 4), random(4)}},
+		{"softmax", func(tp *Tape, vars []*Variable) (*Variable, error) {
+			// A plain sum of the rows is always 1, the weights make it depend on x
+			res, err := tp.Softmax(vars[0])
+			if err != nil {
+				return nil, err
+			}
+			return weightedSum(tp, res, fromFloat64s(Shape{3, 4}, []float64{1, -2, 3, 0.5, 0, 1, -1, 2, 4, 0, -3, 1}, Float64))
+		}, []Tensor{random(3, 4)}},
+		{"cross correlate", binary(crossCorrelate([2]int{1, 1}), random(3, 3)), []Tensor{random(2, 5, 5), random(2, 3, 3)}},
+		{"cross correlate strided", binary(crossCorrelate([2]int{2, 1}), random(2, 4)), []Tensor{random(2, 5, 6), random(2, 3, 3)}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			checkGradients(t, tc.objective, tc.values...)
+		})
+	}
+}
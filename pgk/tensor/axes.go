@@ -0,0 +1,202 @@
+package tensor
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AxisIter walks the sub-tensors of a tensor along a set of axes. Every step
+// fixes a position on the iterated axes and returns a view over the remaining
+// axes, like indexing t[i, j] in NumPy, so writes to a sub-tensor go to the
+// tensor. Positions are visited in row major order of the axes as given, the
+// last axis moving fastest.
+type AxisIter struct {
+	t Tensor
+
+	// Iterated axes with their sizes and strides in t
+	axes    []int
+	sizes   []int
+	strides []int
+
+	// Shape and strides of every sub-tensor
+	shape      Shape
+	subStrides []int
+
+	step  int
+	index []int
+}
+
+// IterAxes returns an iterator over the sub-tensors of t along the given
+// axes, negative axes count from the end. Without axes the only sub-tensor is
+// t itself, iterating all axes gives every value as a tensor of shape (1).
+func IterAxes(t Tensor, axes ...int) (*AxisIter, error) {
+	if t == nil {
+		return nil, errors.New("tensor cannot be nil")
+	}
+
+	shape, strides := t.Shape(), t.Strides()
+	iterated := make([]bool, shape.Dims())
+
+	it := &AxisIter{t: t, axes: make([]int, len(axes))}
+	for i, given := range axes {
+		axis, err := tensorAxis(shape.Dims(), given)
+		if err != nil {
+			return nil, err
+		}
+		if iterated[axis] {
+			return nil, fmt.Errorf("axis %d given more than once", given)
+		}
+		iterated[axis] = true
+
+		it.axes[i] = axis
+		it.sizes = append(it.sizes, shape[axis])
+		it.strides = append(it.strides, strides[axis])
+	}
+
+	for d, dim := range shape {
+		if !iterated[d] {
+			it.shape = append(it.shape, dim)
+			it.subStrides = append(it.subStrides, strides[d])
+		}
+	}
+
+	if len(it.shape) == 0 {
+		it.shape, it.subStrides = Shape{1}, []int{1}
+	}
+
+	return it, nil
+}
+
+// IterRows returns an iterator over the rows of t, the sub-tensors along
+// every axis but the last.
+func IterRows(t Tensor) (*AxisIter, error) {
+	if t == nil {
+		return nil, errors.New("tensor cannot be nil")
+	}
+	return IterAxes(t, leadingAxes(t.Dims(), 1)...)
+}
+
+// IterMatrices returns an iterator over the matrices in the last two axes of
+// t, for a 4D tensor one per batch and channel.
+func IterMatrices(t Tensor) (*AxisIter, error) {
+	if t == nil {
+		return nil, errors.New("tensor cannot be nil")
+	}
+	if t.Dims() < 2 {
+		return nil, fmt.Errorf("cannot iterate matrices of a tensor with %d dimensions", t.Dims())
+	}
+	return IterAxes(t, leadingAxes(t.Dims(), 2)...)
+}
+
+// leadingAxes returns the axes of a tensor with dims dimensions that come
+// before its last inner axes.
+func leadingAxes(dims, inner int) []int {
+	axes := make([]int, max(dims-inner, 0))
+	for i := range axes {
+		axes[i] = i
+	}
+	return axes
+}
+
+// Len returns the number of sub-tensors.
+func (it *AxisIter) Len() int {
+	size := 1
+	for _, dim := range it.sizes {
+		size *= dim
+	}
+	return size
+}
+
+// Shape returns the shape of every sub-tensor.
+func (it *AxisIter) Shape() Shape {
+	return it.shape.Clone()
+}
+
+// HasNext reports if Next has sub-tensors left.
+func (it *AxisIter) HasNext() bool {
+	return it.step < it.Len()
+}
+
+// Next returns the next sub-tensor, or false when all have been visited.
+func (it *AxisIter) Next() (Tensor, bool) {
+	if !it.HasNext() {
+		return nil, false
+	}
+
+	it.index = it.unravel(it.step)
+	it.step++
+	return it.sub(it.index), true
+}
+
+// Index returns the position on the iterated axes of the sub-tensor last
+// returned by Next, nil before the first call.
+func (it *AxisIter) Index() []int {
+	return append([]int(nil), it.index...)
+}
+
+// Reset starts the iteration over from the first sub-tensor.
+func (it *AxisIter) Reset() {
+	it.step = 0
+	it.index = nil
+}
+
+// At returns the sub-tensor at the given position on the iterated axes. It
+// does not move the iterator, so it can be used from Each and ParallelEach to
+// pick the matching sub-tensor of another tensor.
+func (it *AxisIter) At(index ...int) (Tensor, error) {
+	if len(index) != len(it.sizes) {
+		return nil, fmt.Errorf("index has %d positions for %d iterated axes", len(index), len(it.sizes))
+	}
+
+	for k, i := range index {
+		if i < 0 || i >= it.sizes[k] {
+			return nil, fmt.Errorf("index %d out of range for axis %d of size %d", i, it.axes[k], it.sizes[k])
+		}
+	}
+
+	return it.sub(index), nil
+}
+
+// Each calls fn with the position and the view of every sub-tensor in order,
+// stopping at the first error. It walks all sub-tensors regardless of where
+// Next is.
+func (it *AxisIter) Each(fn func(index []int, sub Tensor) error) error {
+	for step := range it.Len() {
+		index := it.unravel(step)
+		if err := fn(index, it.sub(index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParallelEach is Each with the sub-tensors handed out to the elementwise
+// workers, when the tensor has at least ElementwiseThreshold values. The
+// order of the calls is undefined, so fn has to be safe to run on different
+// sub-tensors at once. It returns the error of the first sub-tensor that
+// failed, the others still run.
+func (it *AxisIter) ParallelEach(fn func(index []int, sub Tensor) error) error {
+	return parallelItems(it.Len(), it.t.Size(), func(step int) error {
+		index := it.unravel(step)
+		return fn(index, it.sub(index))
+	})
+}
+
+// unravel turns a step into the position on the iterated axes.
+func (it *AxisIter) unravel(step int) []int {
+	index := make([]int, len(it.sizes))
+	for k := len(it.sizes) - 1; k >= 0; k-- {
+		index[k] = step % it.sizes[k]
+		step /= it.sizes[k]
+	}
+	return index
+}
+
+// sub returns the view of the sub-tensor at index.
+func (it *AxisIter) sub(index []int) Tensor {
+	start := 0
+	for k, i := range index {
+		start += i * it.strides[k]
+	}
+	return it.t.view(start, it.shape.Clone(), append([]int(nil), it.subStrides...))
+}
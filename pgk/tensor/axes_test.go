@@ -0,0 +1,169 @@
+package tensor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// assertVisitsOnce walks it with Next and checks every value of t shows up in
+// exactly one sub-tensor, each of the shape of the iterator, and that At gives
+// the same sub-tensor for the position reported by Index.
+func assertVisitsOnce(t *testing.T, it *AxisIter, ten Tensor) {
+	t.Helper()
+
+	seen := []float64{}
+	steps := 0
+	for sub, ok := it.Next(); ok; sub, ok = it.Next() {
+		if !sub.Shape().DeepEq(it.Shape()) {
+			t.Fatalf("step %d has shape %v, want %v", steps, sub.Shape(), it.Shape())
+		}
+
+		at, err := it.At(it.Index()...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(Values[float64](at)) != fmt.Sprint(Values[float64](sub)) {
+			t.Fatalf("At(%v) gives %v, Next gave %v", it.Index(), Values[float64](at), Values[float64](sub))
+		}
+
+		seen = append(seen, Values[float64](sub)...)
+		steps++
+	}
+
+	if steps != it.Len() {
+		t.Errorf("%d steps, want Len %d", steps, it.Len())
+	}
+
+	want := Values[float64](ten)
+	sort.Float64s(seen)
+	sort.Float64s(want)
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Errorf("visited %v, want every value once: %v", seen, want)
+	}
+}
+
+func TestAxisIterVisitsEverySliceOnce(t *testing.T) {
+	contiguous := arange(2, 3, 4)
+	permuted, _ := arange(3, 4, 2).Permute(2, 0, 1)
+	transposed := arange(2, 4, 3).Transpose(false)
+
+	iterators := map[string]func(x Tensor) (*AxisIter, error){
+		"axis 0":        func(x Tensor) (*AxisIter, error) { return IterAxes(x, 0) },
+		"axes 2 and 0":  func(x Tensor) (*AxisIter, error) { return IterAxes(x, 2, 0) },
+		"negative axis": func(x Tensor) (*AxisIter, error) { return IterAxes(x, -2) },
+		"no axes":       func(x Tensor) (*AxisIter, error) { return IterAxes(x) },
+		"all axes":      func(x Tensor) (*AxisIter, error) { return IterAxes(x, 0, 1, 2) },
+		"rows":          IterRows,
+		"matrices":      IterMatrices,
+	}
+
+	for _, x := range []struct {
+		name string
+		ten  Tensor
+	}{{"contiguous", contiguous}, {"permuted view", permuted}, {"transposed view", transposed}} {
+		for name, iter := range iterators {
+			t.Run(x.name+"/"+name, func(t *testing.T) {
+				it, err := iter(x.ten)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assertVisitsOnce(t, it, x.ten)
+			})
+		}
+	}
+}
+
+func TestAxisIterOrder(t *testing.T) {
+	// x[a][b][c] = 12a + 4b + c, with axis 0 moving fastest
+	it, err := IterAxes(arange(2, 3, 4), 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !it.Shape().DeepEq(Shape{3}) {
+		t.Fatalf("shape %v, want (3)", it.Shape())
+	}
+
+	wantIndices := [][]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}}
+	wantFirst := []float64{0, 12, 1, 13}
+	for step := range 4 {
+		sub, _ := it.Next()
+		if fmt.Sprint(it.Index()) != fmt.Sprint(wantIndices[step]) {
+			t.Fatalf("step %d at %v, want %v", step, it.Index(), wantIndices[step])
+		}
+		if first := sub.ValueAt(0); first != wantFirst[step] {
+			t.Fatalf("step %d starts with %v, want %v", step, first, wantFirst[step])
+		}
+	}
+
+	it.Reset()
+	if it.Index() != nil || !it.HasNext() {
+		t.Error("Reset should start the iteration over")
+	}
+}
+
+func TestParallelEachWritesReachParent(t *testing.T) {
+	defer SetElementwiseThreshold(ElementwiseThreshold())
+	SetElementwiseThreshold(1)
+
+	base := arange(4, 3, 5)
+	view, _ := base.Permute(2, 0, 1)
+
+	it, err := IterMatrices(view)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every matrix is a (4, 3) slice of base along its last axis
+	err = it.ParallelEach(func(index []int, sub Tensor) error {
+		sub.ScalarMultiply(-1, true)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, val := range Values[float64](base) {
+		if val != -float64(i) {
+			t.Fatalf("value %d of the base is %v after negating every matrix, want %v", i, val, -float64(i))
+		}
+	}
+
+	failed := errors.New("failed")
+	err = it.ParallelEach(func(index []int, sub Tensor) error {
+		if index[0] == 3 {
+			return failed
+		}
+		return nil
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("error %v, want the error of the failing matrix", err)
+	}
+}
+
+func TestAxisIterErrors(t *testing.T) {
+	x := arange(2, 3, 4)
+
+	it, err := IterAxes(x, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, index := range [][]int{{0}, {0, 0, 0}, {2, 0}, {0, 4}, {-1, 0}} {
+		if _, err := it.At(index...); err == nil {
+			t.Errorf("At(%v): expected an error", index)
+		}
+	}
+
+	for _, axes := range [][]int{{3}, {-4}, {1, -2}} {
+		if _, err := IterAxes(x, axes...); err == nil {
+			t.Errorf("IterAxes(%v): expected an error", axes)
+		}
+	}
+
+	if _, err := IterMatrices(arange(5)); err == nil {
+		t.Error("IterMatrices of a vector: expected an error")
+	}
+}
@@ -0,0 +1,177 @@
+package tensor
+
+import (
+	"errors"
+)
+
+// BroadcastShapes returns the shape the given shapes broadcast to. Like NumPy
+// the shapes are aligned on their trailing dimensions, which have to either
+// be equal or 1. Missing leading dimensions are treated as 1.
+func BroadcastShapes(shapes ...Shape) (Shape, error) {
+	dims := 0
+	for _, shape := range shapes {
+		dims = max(dims, len(shape))
+	}
+
+	outShape := make(Shape, dims)
+	for i := range outShape {
+		outShape[i] = 1
+	}
+
+	for _, shape := range shapes {
+		offset := dims - len(shape)
+		for i, dim := range shape {
+			if dim != 1 && outShape[offset+i] != 1 && dim != outShape[offset+i] {
+				// Expected is the broadcast of the shapes before this one
+				return nil, shapeMismatch("broadcast", outShape[offset:], shape)
+			}
+		}
+
+		for i, dim := range shape {
+			if dim != 1 {
+				outShape[offset+i] = dim
+			}
+		}
+	}
+
+	return outShape, nil
+}
+
+// broadcastStrides gives the strides to walk a tensor of shape with the given
+// strides as if it had outShape. Broadcast dimensions get a stride of 0.
+func broadcastStrides(shape Shape, strides []int, outShape Shape) []int {
+	resStrides := make([]int, len(outShape))
+	offset := len(outShape) - len(shape)
+	for i, dim := range shape {
+		if dim != 1 {
+			resStrides[offset+i] = strides[i]
+		}
+	}
+	return resStrides
+}
+
+// broadcastLoop walks every index of outShape in row major order and calls fn
+// with the flat output index and the matching flat index of every operand,
+// where the operands are described by their broadcast strides.
+func broadcastLoop(outShape Shape, strides [][]int, fn func(i int, offsets []int)) {
+	broadcastRange(outShape, strides, 0, outShape.TotalSize(), fn)
+}
+
+// broadcastRange is broadcastLoop over the flat output indices from start up
+// to end, so a loop can be split into chunks.
+func broadcastRange(outShape Shape, strides [][]int, start, end int, fn func(i int, offsets []int)) {
+	if start >= end {
+		return
+	}
+
+	dims := len(outShape)
+	counter := make([]int, dims)
+	offsets := make([]int, len(strides))
+
+	// Set the counter and the offsets to the position of start
+	rest := start
+	for d := dims - 1; d >= 0; d-- {
+		counter[d] = rest % outShape[d]
+		rest /= outShape[d]
+		for j := range strides {
+			offsets[j] += counter[d] * strides[j][d]
+		}
+	}
+
+	for i := start; i < end; i++ {
+		fn(i, offsets)
+
+		// Advance the counter like an odometer and move the offsets with it
+		for d := dims - 1; d >= 0; d-- {
+			counter[d]++
+			for j := range strides {
+				offsets[j] += strides[j][d]
+			}
+
+			if counter[d] < outShape[d] {
+				break
+			}
+
+			for j := range strides {
+				offsets[j] -= strides[j][d] * counter[d]
+			}
+			counter[d] = 0
+		}
+	}
+}
+
+// broadcastBinary applies op to every pair of elements of t and other after
+// broadcasting them against each other.
+func (t *tensor[E]) broadcastBinary(other Tensor, inPlace bool, op func(x, y E) E) (Tensor, error) {
+	if other == nil {
+		return nil, errors.New("other tensor cannot be nil")
+	}
+
+	outShape, err := BroadcastShapes(t.Shape(), other.Shape())
+	if err != nil {
+		return nil, err
+	}
+
+	o := asType[E](other)
+
+	var resTen *tensor[E]
+	if inPlace {
+		if outShape.TotalSize() != t.Size() {
+			return nil, shapeMismatch("in place broadcast", t.Shape(), outShape)
+		}
+		resTen = t
+	} else {
+		resTen = zeros[E](outShape)
+	}
+
+	// Fast path when no broadcasting is needed
+	if t.Size() == o.Size() && t.Size() == outShape.TotalSize() && t.isContiguous() && o.isContiguous() {
+		for i := range resTen.Data[:t.Size()] {
+			resTen.Data[i] = op(t.Data[i], o.Data[i])
+		}
+		return resTen, nil
+	}
+
+	tStrides := broadcastStrides(t.Shape(), t.Strides(), outShape)
+	oStrides := broadcastStrides(o.Shape(), o.Strides(), outShape)
+
+	// Walk the outer dimensions and run over the last one in a tight loop
+	last := len(outShape) - 1
+	cols, tStep, oStep := outShape[last], tStrides[last], oStrides[last]
+	outer := append(Shape{1}, outShape[:last]...)
+	strides := [][]int{append([]int{0}, tStrides[:last]...), append([]int{0}, oStrides[:last]...)}
+
+	broadcastLoop(outer, strides, func(row int, offsets []int) {
+		tIdx, oIdx := offsets[0], offsets[1]
+		for i := row * cols; i < (row+1)*cols; i++ {
+			// In place results go to the position of the element in t
+			if inPlace {
+				t.Data[tIdx] = op(t.Data[tIdx], o.Data[oIdx])
+			} else {
+				resTen.Data[i] = op(t.Data[tIdx], o.Data[oIdx])
+			}
+			tIdx += tStep
+			oIdx += oStep
+		}
+	})
+
+	return resTen, nil
+}
+
+// sumToShape sums a broadcast gradient back down to shape, the reverse of
+// broadcasting a tensor of shape up to the shape of gradient.
+func sumToShape(gradient Tensor, shape Shape) Tensor {
+	if gradient.Size() == shape.TotalSize() {
+		return gradient
+	}
+
+	resData := make([]float64, shape.TotalSize())
+	gData := float64s(gradient)
+
+	strides := [][]int{broadcastStrides(shape, shape.CalcStrides(), gradient.Shape())}
+	broadcastLoop(gradient.Shape(), strides, func(i int, offsets []int) {
+		resData[offsets[0]] += gData[i]
+	})
+
+	return fromFloat64s(shape, resData, gradient.DType())
+}
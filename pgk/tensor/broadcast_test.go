@@ -0,0 +1,101 @@
+package tensor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBroadcastShapes(t *testing.T) {
+	tests := []struct {
+		shapes   []Shape
+		expected Shape
+	}{
+		{[]Shape{{2, 3}, {2, 3}}, Shape{2, 3}},
+		{[]Shape{{4, 3}, {1, 3}}, Shape{4, 3}},
+		{[]Shape{{4, 3}, {3}}, Shape{4, 3}},
+		{[]Shape{{2, 1, 4}, {3, 1}}, Shape{2, 3, 4}},
+		{[]Shape{{8, 16, 5, 5}, {16, 1, 1}}, Shape{8, 16, 5, 5}},
+	}
+
+	for _, test := range tests {
+		shape, err := BroadcastShapes(test.shapes...)
+		if err != nil {
+			t.Fatalf("%v: %v", test.shapes, err)
+		}
+		if !shape.DeepEq(test.expected) {
+			t.Errorf("%v broadcast to %v, want %v", test.shapes, shape, test.expected)
+		}
+	}
+
+	var mismatch *ShapeMismatchError
+	if _, err := BroadcastShapes(Shape{4, 3}, Shape{2, 3}); !errors.As(err, &mismatch) {
+		t.Errorf("broadcasting (4, 3) with (2, 3) gave %v, want a ShapeMismatchError", err)
+	}
+}
+
+func TestBroadcastAdd(t *testing.T) {
+	x, _ := TensorFrom([]int{2, 3}, []float64{1, 2, 3, 4, 5, 6})
+	row, _ := TensorFrom([]int{1, 3}, []float64{10, 20, 30})
+	col, _ := TensorFrom([]int{2, 1}, []float64{100, 200})
+
+	tests := []struct {
+		name     string
+		other    Tensor
+		expected []float64
+	}{
+		{"row", row, []float64{11, 22, 33, 14, 25, 36}},
+		{"column", col, []float64{101, 102, 103, 204, 205, 206}},
+	}
+
+	for _, test := range tests {
+		res, err := x.Add(test.other, false)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		for i, val := range Values[float64](res) {
+			if val != test.expected[i] {
+				t.Fatalf("%s: value %d is %v, want %v", test.name, i, val, test.expected[i])
+			}
+		}
+	}
+}
+
+// BenchmarkBroadcast adds and multiplies operands of the same shape, a row
+// like the biases of a Dense layer and one value per channel like the biases
+// of a Conv2D layer.
+func BenchmarkBroadcast(b *testing.B) {
+	dense, _ := RandTensor(Shape{64, 512}, -1, 1)
+	same, _ := RandTensor(Shape{64, 512}, -1, 1)
+	row, _ := RandTensor(Shape{1, 512}, -1, 1)
+
+	conv, _ := RandTensor(Shape{32, 32, 26, 26}, -1, 1)
+	channel, _ := RandTensor(Shape{32, 1, 1}, -1, 1)
+
+	tests := []struct {
+		name string
+		x, y Tensor
+	}{
+		{"same_shape", dense, same},
+		{"row", dense, row},
+		{"channel", conv, channel},
+	}
+
+	for _, test := range tests {
+		b.Run("Add/"+test.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				res, _ := test.x.Add(test.y, false)
+				Release(res)
+			}
+		})
+
+		b.Run("Multiply/"+test.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				res, _ := test.x.Multiply(test.y, false)
+				Release(res)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package tensor
+
+import (
+	"fmt"
+)
+
+// Float is the set of element types a tensor can hold.
+type Float interface {
+	float32 | float64
+}
+
+type DType string
+
+const (
+	Float32 DType = "float32"
+	Float64 DType = "float64"
+)
+
+var defaultDType = Float64
+
+// SetDefaultDType sets the element type used by constructors that don't take
+// one, like ZerosTensor, RandTensor and TensorFrom.
+func SetDefaultDType(dtype DType) error {
+	if dtype != Float32 && dtype != Float64 {
+		return fmt.Errorf("unsupported dtype %q", dtype)
+	}
+
+	defaultDType = dtype
+	return nil
+}
+
+func DefaultDType() DType {
+	return defaultDType
+}
+
+func dtypeOf[E Float]() DType {
+	var zero E
+	switch any(zero).(type) {
+	case float32:
+		return Float32
+	default:
+		return Float64
+	}
+}
+
+// Zeros creates a tensor of zeros with the given element type, unknown
+// dtypes fall back to Float64. Large tensors take their buffer from the pool,
+// see Release.
+func Zeros(shape Shape, dtype DType) Tensor {
+	if dtype == Float32 {
+		return zeros[float32](shape)
+	}
+	return zeros[float64](shape)
+}
+
+func zeros[E Float](shape Shape) *tensor[E] {
+	data, pooled := buffersOf[E]().get(shape.TotalSize())
+	return &tensor[E]{TShape: shape.Clone(), Data: data, pooled: pooled}
+}
+
+// FromSlice creates a tensor that uses input as its data without copying it.
+func FromSlice[E Float](shape Shape, input []E) (Tensor, error) {
+	if shape.TotalSize() != len(input) {
+		return nil, shapeMismatch("FromSlice", shape, Shape{len(input)})
+	}
+
+	return &tensor[E]{TShape: shape.Clone(), Data: input}, nil
+}
+
+// Values returns a copy of the values of a tensor in row major order
+// converted to E.
+func Values[E Float](t Tensor) []E {
+	values := asType[E](t).values()
+	resData := make([]E, len(values))
+	copy(resData, values)
+	return resData
+}
+
+// fromFloat64s creates a tensor of the given dtype from float64 values, the
+// values are used without copying when dtype is Float64.
+func fromFloat64s(shape Shape, values []float64, dtype DType) Tensor {
+	if dtype == Float32 {
+		return &tensor[float32]{TShape: shape.Clone(), Data: convertSlice[float64, float32](values)}
+	}
+	return &tensor[float64]{TShape: shape.Clone(), Data: values}
+}
+
+// float64s returns the values of a tensor as float64 in row major order,
+// only float64 tensors that are contiguous are not copied.
+func float64s(t Tensor) []float64 {
+	return asType[float64](t).values()
+}
+
+// asType gives access to the storage of a Tensor with element type E,
+// tensors of another type are converted into a new tensor.
+func asType[E Float](t Tensor) *tensor[E] {
+	switch ten := t.(type) {
+	case *tensor[E]:
+		return ten
+	case *tensor[float32]:
+		return convertTensor[float32, E](ten)
+	case *tensor[float64]:
+		return convertTensor[float64, E](ten)
+	default:
+		panic("unknown tensor implementation")
+	}
+}
+
+func convertTensor[S, E Float](t *tensor[S]) *tensor[E] {
+	resTen := zeros[E](t.TShape)
+	for i, val := range t.values() {
+		resTen.Data[i] = E(val)
+	}
+	return resTen
+}
+
+func convertSlice[S, E Float](values []S) []E {
+	resData := make([]E, len(values))
+	for i, val := range values {
+		resData[i] = E(val)
+	}
+	return resData
+}
+
+func (t *tensor[E]) DType() DType {
+	return dtypeOf[E]()
+}
+
+// AsType returns a copy of the tensor with the given element type.
+func (t *tensor[E]) AsType(dtype DType) Tensor {
+	if dtype == Float32 {
+		return convertTensor[E, float32](t)
+	}
+	return convertTensor[E, float64](t)
+}
@@ -0,0 +1,404 @@
+package tensor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Einsum subscripts are letters, one per dimension, with an optional "..."
+// standing for the dimensions without a letter. The dimensions under "..."
+// broadcast against each other like the operands of Add do, following
+// BroadcastShapes, they get labels above ellipsisLabel so they can't clash
+// with letters.
+const ellipsisLabel rune = 0x100
+
+// einsumSpec is a parsed Einsum spec, the labels of every operand and of the
+// output.
+type einsumSpec struct {
+	inputs [][]rune
+	output []rune
+	sizes  map[rune]int
+}
+
+// Einsum multiplies tensors written in Einstein notation. Every operand gets a
+// subscript of letters, one per dimension, and the letters after "->" give the
+// dimensions of the result. Letters missing from the result are summed over,
+// e.g. "ij,jk->ik" is a matrix product, "bij,bjk->bik" a batched one,
+// "ij->ji" a transpose and "ii->i" a diagonal. Without "->" the result holds
+// the letters used only once in alphabetical order, like NumPy.
+//
+// Operands are contracted pairwise from left to right with the blocked MatMul
+// kernel. The result has the dtype of the first operand, a result without
+// dimensions has a shape of (1).
+func Einsum(spec string, operands ...Tensor) (Tensor, error) {
+	if len(operands) == 0 {
+		return nil, errors.New("no tensors to multiply")
+	}
+
+	for _, ten := range operands {
+		if ten == nil {
+			return nil, errors.New("Tensors cannot be nil")
+		}
+	}
+
+	parsed, err := parseEinsum(spec, operands)
+	if err != nil {
+		return nil, err
+	}
+
+	if operands[0].DType() == Float32 {
+		return einsum[float32](parsed, operands), nil
+	}
+	return einsum[float64](parsed, operands), nil
+}
+
+// parseEinsum checks spec against the shapes of the operands and finds the
+// size of every label.
+func parseEinsum(spec string, operands []Tensor) (*einsumSpec, error) {
+	spec = strings.ReplaceAll(spec, " ", "")
+
+	inputSpec, outputSpec, explicit := strings.Cut(spec, "->")
+	subscripts := strings.Split(inputSpec, ",")
+	if len(subscripts) != len(operands) {
+		return nil, fmt.Errorf("einsum spec %q has %d operands, got %d tensors", spec, len(subscripts), len(operands))
+	}
+
+	parsed := &einsumSpec{sizes: map[rune]int{}}
+
+	// Letters of every operand, with the number of dimensions under "..."
+	letters := make([][]rune, len(operands))
+	ellipsisAt := make([]int, len(operands))
+	ellipsisDims := 0
+	for i, subscript := range subscripts {
+		var err error
+		letters[i], ellipsisAt[i], err = parseSubscript(subscript)
+		if err != nil {
+			return nil, err
+		}
+
+		dims := operands[i].Dims()
+		switch {
+		case ellipsisAt[i] < 0 && len(letters[i]) != dims:
+			return nil, fmt.Errorf("subscript %q doesn't match the %d dimensions of %v", subscript, dims, operands[i].Shape())
+		case ellipsisAt[i] >= 0 && len(letters[i]) > dims:
+			return nil, fmt.Errorf("subscript %q has more letters than the %d dimensions of %v", subscript, dims, operands[i].Shape())
+		case ellipsisAt[i] >= 0:
+			ellipsisDims = max(ellipsisDims, dims-len(letters[i]))
+		}
+	}
+
+	for i, ten := range operands {
+		labels := letters[i]
+		if ellipsisAt[i] >= 0 {
+			// Dimensions under "..." are aligned from the right
+			count := ten.Dims() - len(letters[i])
+			labels = append([]rune(nil), letters[i][:ellipsisAt[i]]...)
+			for d := 0; d < count; d++ {
+				labels = append(labels, ellipsisLabel+rune(ellipsisDims-count+d))
+			}
+			labels = append(labels, letters[i][ellipsisAt[i]:]...)
+		}
+
+		for d, label := range labels {
+			size := ten.Shape()[d]
+			known, ok := parsed.sizes[label]
+			switch {
+			case !ok:
+				parsed.sizes[label] = size
+			case label >= ellipsisLabel && (known == 1 || size == 1):
+				parsed.sizes[label] = max(known, size)
+			case label >= ellipsisLabel && known != size:
+				return nil, fmt.Errorf("einsum %q: dimensions under ... of %v don't broadcast", spec, ten.Shape())
+			case known != size:
+				return nil, fmt.Errorf("einsum %q: label %q has size %d in %v and %d elsewhere", spec, label, size, ten.Shape(), known)
+			}
+		}
+		parsed.inputs = append(parsed.inputs, labels)
+	}
+
+	if explicit {
+		outLetters, outEllipsis, err := parseSubscript(outputSpec)
+		if err != nil {
+			return nil, err
+		}
+
+		seen := map[rune]bool{}
+		for _, label := range outLetters {
+			if _, ok := parsed.sizes[label]; !ok {
+				return nil, fmt.Errorf("output label %q of einsum %q is not in the inputs", label, spec)
+			}
+			if seen[label] {
+				return nil, fmt.Errorf("output label %q of einsum %q is given more than once", label, spec)
+			}
+			seen[label] = true
+		}
+
+		parsed.output = append([]rune(nil), outLetters...)
+		if outEllipsis >= 0 {
+			parsed.output = append([]rune(nil), outLetters[:outEllipsis]...)
+			for d := 0; d < ellipsisDims; d++ {
+				parsed.output = append(parsed.output, ellipsisLabel+rune(d))
+			}
+			parsed.output = append(parsed.output, outLetters[outEllipsis:]...)
+		}
+
+		return parsed, nil
+	}
+
+	// The implicit output keeps "..." first and the letters used once
+	count := map[rune]int{}
+	for _, labels := range letters {
+		for _, label := range labels {
+			count[label]++
+		}
+	}
+
+	for d := 0; d < ellipsisDims; d++ {
+		parsed.output = append(parsed.output, ellipsisLabel+rune(d))
+	}
+
+	once := []rune{}
+	for label, n := range count {
+		if n == 1 {
+			once = append(once, label)
+		}
+	}
+	sort.Slice(once, func(i, j int) bool { return once[i] < once[j] })
+	parsed.output = append(parsed.output, once...)
+
+	return parsed, nil
+}
+
+// parseSubscript returns the letters of a subscript and the position of the
+// "..." among them, or -1 without one.
+func parseSubscript(subscript string) ([]rune, int, error) {
+	ellipsisAt := -1
+	before, after, found := strings.Cut(subscript, "...")
+	if found {
+		if strings.Contains(after, "...") {
+			return nil, 0, fmt.Errorf("subscript %q has more than one ellipsis", subscript)
+		}
+		ellipsisAt = len(before)
+		subscript = before + after
+	}
+
+	letters := []rune(subscript)
+	for _, letter := range letters {
+		if !('a' <= letter && letter <= 'z' || 'A' <= letter && letter <= 'Z') {
+			return nil, 0, fmt.Errorf("invalid einsum subscript %q", subscript)
+		}
+	}
+
+	return letters, ellipsisAt, nil
+}
+
+// einsumOperand is a tensor with a label for each of its dimensions, an
+// operand without labels holds a single value in a shape of (1).
+type einsumOperand[E Float] struct {
+	labels []rune
+	ten    *tensor[E]
+}
+
+func einsum[E Float](spec *einsumSpec, operands []Tensor) Tensor {
+	ops := make([]einsumOperand[E], len(operands))
+	for i, ten := range operands {
+		ops[i] = labelOperand(asType[E](ten), spec.inputs[i], spec.sizes)
+	}
+
+	// Labels that have to survive the contraction of the operands up to i
+	keepAfter := func(i int) map[rune]bool {
+		keep := map[rune]bool{}
+		for _, label := range spec.output {
+			keep[label] = true
+		}
+		for _, op := range ops[i+1:] {
+			for _, label := range op.labels {
+				keep[label] = true
+			}
+		}
+		return keep
+	}
+
+	acc := ops[0]
+	for i := 1; i < len(ops); i++ {
+		keep := keepAfter(i)
+		for _, label := range ops[i].labels {
+			keep[label] = true
+		}
+		acc = acc.sumOut(keep)
+
+		keep = keepAfter(i)
+		for _, label := range acc.labels {
+			keep[label] = true
+		}
+		other := ops[i].sumOut(keep)
+
+		acc = contract(acc, other, keepAfter(i), spec.sizes)
+	}
+
+	acc = acc.sumOut(keepAfter(len(ops) - 1))
+
+	resShape := Shape{}
+	for _, label := range spec.output {
+		resShape = append(resShape, spec.sizes[label])
+	}
+	if len(resShape) == 0 {
+		resShape = Shape{1}
+	}
+
+	return &tensor[E]{TShape: resShape, Data: acc.arrange(spec.output)}
+}
+
+// labelOperand views t with one dimension per distinct label. Repeated labels
+// become a diagonal by adding up their strides, and dimensions of size 1 under
+// "..." are broadcast with a stride of 0.
+func labelOperand[E Float](t *tensor[E], labels []rune, sizes map[rune]int) einsumOperand[E] {
+	strides := t.Strides()
+
+	op := einsumOperand[E]{}
+	newShape := Shape{}
+	newStrides := []int{}
+	for d, label := range labels {
+		pos := -1
+		for j, seen := range op.labels {
+			if seen == label {
+				pos = j
+			}
+		}
+
+		if pos >= 0 {
+			newStrides[pos] += strides[d]
+			continue
+		}
+
+		stride := strides[d]
+		if t.TShape[d] == 1 {
+			stride = 0
+		}
+
+		op.labels = append(op.labels, label)
+		newShape = append(newShape, sizes[label])
+		newStrides = append(newStrides, stride)
+	}
+
+	if len(op.labels) == 0 {
+		op.ten = t
+		return op
+	}
+
+	op.ten = t.newView(0, newShape, newStrides)
+	return op
+}
+
+// sumOut sums the operand over the labels not in keep.
+func (op einsumOperand[E]) sumOut(keep map[rune]bool) einsumOperand[E] {
+	axes := []int{}
+	labels := []rune{}
+	for d, label := range op.labels {
+		if keep[label] {
+			labels = append(labels, label)
+		} else {
+			axes = append(axes, d)
+		}
+	}
+
+	if len(axes) == 0 {
+		return op
+	}
+
+	// Axes come from the operand, so the sum can't fail
+	summed, _ := op.ten.SumAxes(false, axes...)
+	return einsumOperand[E]{labels: labels, ten: asType[E](summed)}
+}
+
+// arrange returns the values of the operand in row major order with its
+// dimensions ordered like labels, which have to be the labels of the operand.
+func (op einsumOperand[E]) arrange(labels []rune) []E {
+	if len(labels) == 0 {
+		return op.ten.values()
+	}
+
+	axes := make([]int, len(labels))
+	for i, label := range labels {
+		for d, own := range op.labels {
+			if own == label {
+				axes[i] = d
+			}
+		}
+	}
+
+	// The axes are a permutation of the dimensions of the operand
+	permuted, _ := op.ten.Permute(axes...)
+	return asType[E](permuted).values()
+}
+
+// contract multiplies two operands. Labels in both that are kept become batch
+// dimensions, the other shared labels are summed over and the rest stay. Both
+// are laid out as batches of matrices and multiplied with gemm.
+func contract[E Float](a, b einsumOperand[E], keep map[rune]bool, sizes map[rune]int) einsumOperand[E] {
+	inB := map[rune]bool{}
+	for _, label := range b.labels {
+		inB[label] = true
+	}
+
+	var batch, freeA, summed, freeB []rune
+	inA := map[rune]bool{}
+	for _, label := range a.labels {
+		inA[label] = true
+		switch {
+		case inB[label] && keep[label]:
+			batch = append(batch, label)
+		case inB[label]:
+			summed = append(summed, label)
+		default:
+			freeA = append(freeA, label)
+		}
+	}
+
+	for _, label := range b.labels {
+		if !inA[label] {
+			freeB = append(freeB, label)
+		}
+	}
+
+	product := func(labels []rune) int {
+		size := 1
+		for _, label := range labels {
+			size *= sizes[label]
+		}
+		return size
+	}
+
+	batches, m, n, p := product(batch), product(freeA), product(summed), product(freeB)
+
+	aData := a.arrange(concatLabels(batch, freeA, summed))
+	bData := b.arrange(concatLabels(batch, summed, freeB))
+
+	resLabels := concatLabels(batch, freeA, freeB)
+	resShape := Shape{}
+	for _, label := range resLabels {
+		resShape = append(resShape, sizes[label])
+	}
+	if len(resShape) == 0 {
+		resShape = Shape{1}
+	}
+
+	resTen := zeros[E](resShape)
+	for i := 0; i < batches; i++ {
+		aMat := matrix[E]{data: aData[i*m*n:], rows: m, cols: n, rowStride: n, colStride: 1}
+		bMat := matrix[E]{data: bData[i*n*p:], rows: n, cols: p, rowStride: p, colStride: 1}
+		gemm(aMat, bMat, resTen.Data[i*m*p:(i+1)*m*p])
+	}
+
+	return einsumOperand[E]{labels: resLabels, ten: resTen}
+}
+
+func concatLabels(parts ...[]rune) []rune {
+	labels := []rune{}
+	for _, part := range parts {
+		labels = append(labels, part...)
+	}
+	return labels
+}
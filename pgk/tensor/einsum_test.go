@@ -0,0 +1,113 @@
+package tensor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEinsumMatchesMatMul(t *testing.T) {
+	x, _ := RandTensor(Shape{4, 5}, -1, 1)
+	y, _ := RandTensor(Shape{5, 3}, -1, 1)
+	bx, _ := RandTensor(Shape{2, 4, 5}, -1, 1)
+	by, _ := RandTensor(Shape{2, 5, 3}, -1, 1)
+	z, _ := RandTensor(Shape{3, 6}, -1, 1)
+
+	xy, _ := x.MatMul(y)
+	bxby, _ := bx.MatMul(by)
+	xyz, _ := xy.MatMul(z)
+	xT := x.Transpose(false)
+	yTxT, _ := y.Transpose(false).MatMul(xT)
+
+	tests := []struct {
+		spec     string
+		operands []Tensor
+		shape    Shape
+		expected Tensor
+	}{
+		{"ij,jk->ik", []Tensor{x, y}, Shape{4, 3}, xy},
+		{"ij,jk", []Tensor{x, y}, Shape{4, 3}, xy},
+		{"ij,jk->ki", []Tensor{x, y}, Shape{3, 4}, yTxT},
+		{"bij,bjk->bik", []Tensor{bx, by}, Shape{2, 4, 3}, bxby},
+		{"...ij,...jk->...ik", []Tensor{bx, by}, Shape{2, 4, 3}, bxby},
+		{"ij,jk,kl->il", []Tensor{x, y, z}, Shape{4, 6}, xyz},
+		{"ij->ji", []Tensor{x}, Shape{5, 4}, xT},
+	}
+
+	for _, test := range tests {
+		res, err := Einsum(test.spec, test.operands...)
+		if err != nil {
+			t.Fatalf("%s: %v", test.spec, err)
+		}
+
+		if !res.Shape().DeepEq(test.shape) {
+			t.Fatalf("%s: shape %v, want %v", test.spec, res.Shape(), test.shape)
+		}
+
+		expected := test.expected.DataCopy()
+		for i, val := range res.DataCopy() {
+			if math.Abs(val-expected[i]) > 1e-12 {
+				t.Fatalf("%s: value %d is %v, want %v", test.spec, i, val, expected[i])
+			}
+		}
+	}
+}
+
+func TestEinsumReductions(t *testing.T) {
+	a, _ := TensorFrom(Shape{3, 3}, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9})
+	u, _ := TensorFrom(Shape{2}, []float64{1, 2})
+	v, _ := TensorFrom(Shape{3}, []float64{3, 4, 5})
+
+	tests := []struct {
+		spec     string
+		operands []Tensor
+		shape    Shape
+		expected []float64
+	}{
+		{"ii->i", []Tensor{a}, Shape{3}, []float64{1, 5, 9}},
+		{"ii->", []Tensor{a}, Shape{1}, []float64{15}},
+		{"ij->i", []Tensor{a}, Shape{3}, []float64{6, 15, 24}},
+		{"ij->j", []Tensor{a}, Shape{3}, []float64{12, 15, 18}},
+		{"i,j->ij", []Tensor{u, v}, Shape{2, 3}, []float64{3, 4, 5, 6, 8, 10}},
+		{"i,i->", []Tensor{v, v}, Shape{1}, []float64{50}},
+		{"ij,j->i", []Tensor{a, v}, Shape{3}, []float64{26, 62, 98}},
+	}
+
+	for _, test := range tests {
+		res, err := Einsum(test.spec, test.operands...)
+		if err != nil {
+			t.Fatalf("%s: %v", test.spec, err)
+		}
+
+		if !res.Shape().DeepEq(test.shape) {
+			t.Fatalf("%s: shape %v, want %v", test.spec, res.Shape(), test.shape)
+		}
+
+		for i, val := range res.DataCopy() {
+			if math.Abs(val-test.expected[i]) > 1e-12 {
+				t.Fatalf("%s: value %d is %v, want %v", test.spec, i, val, test.expected[i])
+			}
+		}
+	}
+}
+
+func TestEinsumErrors(t *testing.T) {
+	x, _ := RandTensor(Shape{4, 5}, -1, 1)
+	y, _ := RandTensor(Shape{4, 3}, -1, 1)
+
+	tests := []struct {
+		spec     string
+		operands []Tensor
+	}{
+		{"ij,jk->ik", []Tensor{x, y}},
+		{"ij,jk->ik", []Tensor{x}},
+		{"ijk->ik", []Tensor{x}},
+		{"ij->iz", []Tensor{x}},
+		{"i1->i", []Tensor{x}},
+	}
+
+	for _, test := range tests {
+		if _, err := Einsum(test.spec, test.operands...); err == nil {
+			t.Errorf("%s: no error", test.spec)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package tensor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The math below runs in plain loops over chunks of the values instead of
+// calling a function for every element like Map. Like the scalar operations
+// they either update t in place or return a new tensor, in both cases the
+// result is returned.
+
+// chunked runs fn over matching chunks of src and dst, in parallel for large
+// tensors.
+func chunked[E Float](src, dst []E, fn func(src, dst []E)) {
+	parallelFor(len(src), func(start, end int) error {
+		fn(src[start:end], dst[start:end])
+		return nil
+	})
+}
+
+// elementwise returns the tensor the results go in, the values of t and the
+// slice to write the results to, which has to be flushed into the result.
+func (t *tensor[E]) elementwise(inPlace bool) (*tensor[E], []E, []E) {
+	if inPlace {
+		buf := t.buffer()
+		return t, buf, buf
+	}
+
+	resTen := zeros[E](t.TShape)
+	return resTen, t.values(), resTen.Data
+}
+
+// Exp raises e to every value.
+func (t *tensor[E]) Exp(inPlace bool) Tensor {
+	resTen, tData, resData := t.elementwise(inPlace)
+	chunked(tData, resData, func(src, dst []E) {
+		for i, x := range src {
+			dst[i] = E(math.Exp(float64(x)))
+		}
+	})
+	resTen.flush(resData)
+	return resTen
+}
+
+// Log takes the natural logarithm of every value, negative values give NaN
+// and zeros -Inf.
+func (t *tensor[E]) Log(inPlace bool) Tensor {
+	resTen, tData, resData := t.elementwise(inPlace)
+	chunked(tData, resData, func(src, dst []E) {
+		for i, x := range src {
+			dst[i] = E(math.Log(float64(x)))
+		}
+	})
+	resTen.flush(resData)
+	return resTen
+}
+
+// Sqrt takes the square root of every value, negative values give NaN.
+func (t *tensor[E]) Sqrt(inPlace bool) Tensor {
+	resTen, tData, resData := t.elementwise(inPlace)
+	chunked(tData, resData, func(src, dst []E) {
+		for i, x := range src {
+			dst[i] = E(math.Sqrt(float64(x)))
+		}
+	})
+	resTen.flush(resData)
+	return resTen
+}
+
+// Pow raises every value to the power exponent.
+func (t *tensor[E]) Pow(exponent float64, inPlace bool) Tensor {
+	resTen, tData, resData := t.elementwise(inPlace)
+	chunked(tData, resData, func(src, dst []E) {
+		// Common exponents skip math.Pow
+		switch exponent {
+		case 2:
+			for i, x := range src {
+				dst[i] = x * x
+			}
+		case -1:
+			for i, x := range src {
+				dst[i] = 1 / x
+			}
+		case 0.5:
+			for i, x := range src {
+				dst[i] = E(math.Sqrt(float64(x)))
+			}
+		default:
+			for i, x := range src {
+				dst[i] = E(math.Pow(float64(x), exponent))
+			}
+		}
+	})
+	resTen.flush(resData)
+	return resTen
+}
+
+// Abs takes the absolute value of every value.
+func (t *tensor[E]) Abs(inPlace bool) Tensor {
+	resTen, tData, resData := t.elementwise(inPlace)
+	chunked(tData, resData, func(src, dst []E) {
+		for i, x := range src {
+			if x < 0 {
+				x = -x
+			}
+			dst[i] = x
+		}
+	})
+	resTen.flush(resData)
+	return resTen
+}
+
+// Tanh takes the hyperbolic tangent of every value.
+func (t *tensor[E]) Tanh(inPlace bool) Tensor {
+	resTen, tData, resData := t.elementwise(inPlace)
+	chunked(tData, resData, func(src, dst []E) {
+		for i, x := range src {
+			dst[i] = E(math.Tanh(float64(x)))
+		}
+	})
+	resTen.flush(resData)
+	return resTen
+}
+
+// Sign gives -1 for negative values, 1 for positive values and 0 for zeros.
+// NaN stays NaN.
+func (t *tensor[E]) Sign(inPlace bool) Tensor {
+	resTen, tData, resData := t.elementwise(inPlace)
+	chunked(tData, resData, func(src, dst []E) {
+		for i, x := range src {
+			switch {
+			case x > 0:
+				dst[i] = 1
+			case x < 0:
+				dst[i] = -1
+			default:
+				dst[i] = x
+			}
+		}
+	})
+	resTen.flush(resData)
+	return resTen
+}
+
+// Clip limits every value to [minVal, maxVal].
+func (t *tensor[E]) Clip(minVal, maxVal float64, inPlace bool) (Tensor, error) {
+	if minVal > maxVal {
+		return nil, errors.New("minVal bigger than maxVal")
+	}
+
+	lo, hi := E(minVal), E(maxVal)
+	resTen, tData, resData := t.elementwise(inPlace)
+	chunked(tData, resData, func(src, dst []E) {
+		for i, x := range src {
+			switch {
+			case x < lo:
+				dst[i] = lo
+			case x > hi:
+				dst[i] = hi
+			default:
+				dst[i] = x
+			}
+		}
+	})
+	resTen.flush(resData)
+	return resTen, nil
+}
+
+// Maximum takes the larger value of t and other elementwise, broadcasting the
+// shapes against each other. A NaN on either side gives NaN.
+func (t *tensor[E]) Maximum(other Tensor, inPlace bool) (Tensor, error) {
+	return t.broadcastBinary(other, inPlace, func(x, y E) E {
+		if y > x || y != y {
+			return y
+		}
+		return x
+	})
+}
+
+// Minimum takes the smaller value of t and other elementwise, broadcasting
+// the shapes against each other. A NaN on either side gives NaN.
+func (t *tensor[E]) Minimum(other Tensor, inPlace bool) (Tensor, error) {
+	return t.broadcastBinary(other, inPlace, func(x, y E) E {
+		if y < x || y != y {
+			return y
+		}
+		return x
+	})
+}
+
+// Equal gives a mask holding 1 where t equals other and 0 elsewhere,
+// broadcasting the shapes against each other.
+func (t *tensor[E]) Equal(other Tensor) (Tensor, error) {
+	return t.broadcastBinary(other, false, func(x, y E) E {
+		if x == y {
+			return 1
+		}
+		return 0
+	})
+}
+
+// Greater gives a mask holding 1 where t is greater than other and 0
+// elsewhere, broadcasting the shapes against each other.
+func (t *tensor[E]) Greater(other Tensor) (Tensor, error) {
+	return t.broadcastBinary(other, false, func(x, y E) E {
+		if x > y {
+			return 1
+		}
+		return 0
+	})
+}
+
+// Less gives a mask holding 1 where t is less than other and 0 elsewhere,
+// broadcasting the shapes against each other.
+func (t *tensor[E]) Less(other Tensor) (Tensor, error) {
+	return t.broadcastBinary(other, false, func(x, y E) E {
+		if x < y {
+			return 1
+		}
+		return 0
+	})
+}
+
+// Where picks the value of x where cond is nonzero and the value of y
+// elsewhere, e.g. with a mask from Greater. The three shapes broadcast against
+// each other and the result has the dtype of x.
+func Where(cond, x, y Tensor) (Tensor, error) {
+	if cond == nil || x == nil || y == nil {
+		return nil, errors.New("Tensors cannot be nil")
+	}
+
+	if x.DType() == Float32 {
+		return where(cond, asType[float32](x), asType[float32](y))
+	}
+	return where(cond, asType[float64](x), asType[float64](y))
+}
+
+func where[E Float](cond Tensor, x, y *tensor[E]) (Tensor, error) {
+	outShape, err := BroadcastShapes(cond.Shape(), x.Shape(), y.Shape())
+	if err != nil {
+		return nil, fmt.Errorf("where: %w", err)
+	}
+
+	c := asType[E](cond)
+	strides := [][]int{
+		broadcastStrides(c.Shape(), c.Strides(), outShape),
+		broadcastStrides(x.Shape(), x.Strides(), outShape),
+		broadcastStrides(y.Shape(), y.Strides(), outShape),
+	}
+
+	resTen := zeros[E](outShape)
+	broadcastLoop(outShape, strides, func(i int, offsets []int) {
+		if c.Data[offsets[0]] != 0 {
+			resTen.Data[i] = x.Data[offsets[1]]
+		} else {
+			resTen.Data[i] = y.Data[offsets[2]]
+		}
+	})
+
+	return resTen, nil
+}
@@ -0,0 +1,179 @@
+package tensor
+
+import (
+	"math"
+	"testing"
+)
+
+var nan = math.NaN()
+
+// assertValuesNaN compares values exactly, with NaN only matching NaN.
+func assertValuesNaN(t *testing.T, got Tensor, shape Shape, want []float64) {
+	t.Helper()
+
+	if !got.Shape().DeepEq(shape) {
+		t.Fatalf("shape %v, want %v", got.Shape(), shape)
+	}
+
+	values := Values[float64](got)
+	for i, val := range values {
+		if val != want[i] && !(math.IsNaN(val) && math.IsNaN(want[i])) {
+			t.Fatalf("values %v, want %v", values, want)
+		}
+	}
+}
+
+func TestUnaryElementwise(t *testing.T) {
+	x := fromFloat64s(Shape{1, 6}, []float64{-2, -0.5, 0, 0.5, 4, nan}, Float64)
+
+	tests := []struct {
+		name string
+		op   func(x Tensor) (Tensor, error)
+		want []float64
+	}{
+		{"Abs", func(x Tensor) (Tensor, error) { return x.Abs(false), nil },
+			[]float64{2, 0.5, 0, 0.5, 4, nan}},
+		{"Sign", func(x Tensor) (Tensor, error) { return x.Sign(false), nil },
+			[]float64{-1, -1, 0, 1, 1, nan}},
+		{"Clip", func(x Tensor) (Tensor, error) { return x.Clip(-1, 1, false) },
+			[]float64{-1, -0.5, 0, 0.5, 1, nan}},
+		{"Sqrt", func(x Tensor) (Tensor, error) { return x.Sqrt(false), nil },
+			[]float64{nan, nan, 0, math.Sqrt(0.5), 2, nan}},
+		{"Pow 2", func(x Tensor) (Tensor, error) { return x.Pow(2, false), nil },
+			[]float64{4, 0.25, 0, 0.25, 16, nan}},
+		{"Pow -1", func(x Tensor) (Tensor, error) { return x.Pow(-1, false), nil },
+			[]float64{-0.5, -2, math.Inf(1), 2, 0.25, nan}},
+		{"Log", func(x Tensor) (Tensor, error) { return x.Log(false), nil },
+			[]float64{nan, nan, math.Inf(-1), math.Log(0.5), math.Log(4), nan}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			before := Values[float64](x)
+
+			res, err := tc.op(x)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertValuesNaN(t, res, Shape{1, 6}, tc.want)
+
+			// Not in place, so x keeps its values
+			assertValuesNaN(t, x, Shape{1, 6}, before)
+		})
+	}
+
+	// In place on a transposed view writes back through the view
+	view := fromFloat64s(Shape{2, 3}, []float64{-1, 2, -3, 4, -5, 6}, Float64).Transpose(false)
+	view.Abs(true)
+	assertValuesNaN(t, view, Shape{3, 2}, []float64{1, 4, 2, 5, 3, 6})
+
+	if _, err := x.Clip(1, -1, false); err == nil {
+		t.Error("Clip with min above max: expected an error")
+	}
+}
+
+func TestBinaryElementwise(t *testing.T) {
+	// A column against a row broadcasts to a (3, 2) result
+	col := fromFloat64s(Shape{3, 1}, []float64{1, nan, 3}, Float64)
+	row := fromFloat64s(Shape{1, 2}, []float64{2, 1}, Float64)
+
+	tests := []struct {
+		name string
+		op   func() (Tensor, error)
+		want []float64
+	}{
+		{"Maximum", func() (Tensor, error) { return col.Maximum(row, false) },
+			[]float64{2, 1, nan, nan, 3, 3}},
+		{"Maximum NaN on the right", func() (Tensor, error) { return row.Maximum(col, false) },
+			[]float64{2, 1, nan, nan, 3, 3}},
+		{"Minimum", func() (Tensor, error) { return col.Minimum(row, false) },
+			[]float64{1, 1, nan, nan, 2, 1}},
+		{"Minimum NaN on the right", func() (Tensor, error) { return row.Minimum(col, false) },
+			[]float64{1, 1, nan, nan, 2, 1}},
+		{"Equal", func() (Tensor, error) { return col.Equal(row) },
+			[]float64{0, 1, 0, 0, 0, 0}},
+		{"Greater", func() (Tensor, error) { return col.Greater(row) },
+			[]float64{0, 0, 0, 0, 1, 1}},
+		{"Less", func() (Tensor, error) { return col.Less(row) },
+			[]float64{1, 0, 0, 0, 0, 0}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.op()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertValuesNaN(t, res, Shape{3, 2}, tc.want)
+		})
+	}
+
+	if _, err := col.Maximum(fromFloat64s(Shape{2, 1}, []float64{1, 2}, Float64), false); err == nil {
+		t.Error("Maximum of shapes that don't broadcast: expected an error")
+	}
+}
+
+func TestWhere(t *testing.T) {
+	x := fromFloat64s(Shape{2, 3}, []float64{1, 2, 3, 4, 5, 6}, Float64)
+
+	tests := []struct {
+		name  string
+		cond  Tensor
+		y     Tensor
+		shape Shape
+		want  []float64
+	}{
+		{
+			name:  "same shapes",
+			cond:  fromFloat64s(Shape{2, 3}, []float64{1, 0, 1, 0, 1, 0}, Float64),
+			y:     Zeros(Shape{2, 3}, Float64),
+			shape: Shape{2, 3},
+			want:  []float64{1, 0, 3, 0, 5, 0},
+		},
+		{
+			name:  "broadcast condition and scalar y",
+			cond:  fromFloat64s(Shape{2, 1}, []float64{0, 1}, Float64),
+			y:     fromFloat64s(Shape{1}, []float64{-1}, Float64),
+			shape: Shape{2, 3},
+			want:  []float64{-1, -1, -1, 4, 5, 6},
+		},
+		{
+			// NaN is nonzero, so it picks x like a true condition
+			name:  "NaN condition",
+			cond:  fromFloat64s(Shape{1, 3}, []float64{nan, 0, 2}, Float64),
+			y:     fromFloat64s(Shape{1, 3}, []float64{nan, nan, nan}, Float64),
+			shape: Shape{2, 3},
+			want:  []float64{1, nan, 3, 4, nan, 6},
+		},
+		{
+			name:  "mask from Greater",
+			cond:  must(x.Greater(fromFloat64s(Shape{1}, []float64{3.5}, Float64))),
+			y:     x.ScalarMultiply(-1, false),
+			shape: Shape{2, 3},
+			want:  []float64{-1, -2, -3, 4, 5, 6},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Where(tc.cond, x, tc.y)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertValuesNaN(t, res, tc.shape, tc.want)
+		})
+	}
+
+	// The result has the dtype of x
+	res, err := Where(Zeros(Shape{2, 3}, Float64).ScalarAdd(1, false), x.AsType(Float32), x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.DType() != Float32 {
+		t.Errorf("dtype %v, want float32 like x", res.DType())
+	}
+
+	if _, err := Where(Zeros(Shape{3, 1}, Float64), x, x); err == nil {
+		t.Error("condition that doesn't broadcast: expected an error")
+	}
+}
@@ -0,0 +1,27 @@
+package tensor
+
+import "fmt"
+
+// ShapeMismatchError is returned when an operation gets a tensor whose shape
+// doesn't fit, e.g. the right operand of MatMul. Use errors.As to get the
+// shapes from an error that was wrapped on its way up:
+//
+//	var shapeErr *tensor.ShapeMismatchError
+//	if errors.As(err, &shapeErr) {
+//		fmt.Println(shapeErr.Op, shapeErr.Expected, shapeErr.Actual)
+//	}
+type ShapeMismatchError struct {
+	Op       string // Operation that failed, like "MatMul"
+	Expected Shape  // Shape that would have fit
+	Actual   Shape  // Shape that was given
+}
+
+func (e *ShapeMismatchError) Error() string {
+	return fmt.Sprintf("%s: expected shape %v, got %v", e.Op, e.Expected, e.Actual)
+}
+
+// shapeMismatch creates a ShapeMismatchError, cloning the shapes so later
+// changes to the tensors don't show up in the error.
+func shapeMismatch(op string, expected, actual Shape) *ShapeMismatchError {
+	return &ShapeMismatchError{Op: op, Expected: expected.Clone(), Actual: actual.Clone()}
+}
@@ -0,0 +1,222 @@
+package tensor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// PrintOptions control how tensors are formatted by String, Print and the
+// fmt verbs.
+type PrintOptions struct {
+	Precision int // Digits after the decimal point
+	Threshold int // Tensors with more values are summarized
+	EdgeItems int // Values kept at both ends of an axis when summarizing
+}
+
+var printOptions = PrintOptions{Precision: 4, Threshold: 1000, EdgeItems: 3}
+
+// SetPrintOptions sets the options used to format tensors.
+func SetPrintOptions(opts PrintOptions) error {
+	if opts.Precision < 0 || opts.Threshold < 0 || opts.EdgeItems < 1 {
+		return errors.New("invalid print options")
+	}
+
+	printOptions = opts
+	return nil
+}
+
+// CurrentPrintOptions returns the options used to format tensors.
+func CurrentPrintOptions() PrintOptions {
+	return printOptions
+}
+
+// String formats the tensor like NumPy, nested brackets under a header with
+// the shape and dtype:
+//
+//	Tensor shape=(2, 3) dtype=float64
+//	[[1.00 2.50 3.00]
+//	 [4.00 5.00 6.25]]
+//
+// Tensors with more values than the threshold of the print options only show
+// the values at the edges of every axis, with "..." for the rest.
+func (t *tensor[E]) String() string {
+	return t.format('v', printOptions.Precision)
+}
+
+// Format implements fmt.Formatter. %v and %s give String, %f, %e and %g
+// format the values with that verb, and a precision like %.2f overrides the
+// one of the print options.
+func (t *tensor[E]) Format(f fmt.State, verb rune) {
+	precision, ok := f.Precision()
+	if !ok {
+		precision = printOptions.Precision
+	}
+
+	switch verb {
+	case 'v', 's', 'f', 'e', 'g':
+		fmt.Fprint(f, t.format(byte(verb), precision))
+	default:
+		fmt.Fprintf(f, "%%!%c(tensor shape=%s)", verb, shapeString(t.TShape))
+	}
+}
+
+// Print writes the tensor to stdout.
+func (t *tensor[E]) Print() {
+	fmt.Println(t.String())
+}
+
+func shapeString(shape Shape) string {
+	dims := make([]string, len(shape))
+	for i, dim := range shape {
+		dims[i] = strconv.Itoa(dim)
+	}
+
+	if len(dims) == 1 {
+		return "(" + dims[0] + ",)"
+	}
+	return "(" + strings.Join(dims, ", ") + ")"
+}
+
+// tensorPrinter lays out the values of a tensor, shown holds the indices kept
+// along every axis with -1 standing for the skipped ones.
+type tensorPrinter struct {
+	shape   Shape
+	strides []int
+	values  []float64
+	shown   [][]int
+	texts   map[int]string
+	width   int
+}
+
+func (t *tensor[E]) format(verb byte, precision int) string {
+	header := fmt.Sprintf("Tensor shape=%s dtype=%s", shapeString(t.TShape), t.DType())
+	if t.Size() == 0 {
+		return header + "\n[]"
+	}
+
+	p := &tensorPrinter{
+		shape:   t.TShape,
+		strides: t.TShape.CalcStrides(),
+		values:  float64s(t),
+		texts:   map[int]string{},
+	}
+
+	summarize := t.Size() > printOptions.Threshold
+	edge := printOptions.EdgeItems
+	for _, dim := range t.TShape {
+		shown := []int{}
+		for i := 0; i < dim; i++ {
+			switch {
+			case !summarize || dim <= 2*edge || i < edge || i >= dim-edge:
+				shown = append(shown, i)
+			case i == edge:
+				shown = append(shown, -1)
+			}
+		}
+		p.shown = append(p.shown, shown)
+	}
+
+	p.formatValues(verb, precision)
+
+	var sb strings.Builder
+	sb.WriteString(header)
+	sb.WriteString("\n")
+	p.write(&sb, 0, 0)
+	return sb.String()
+}
+
+// formatValues formats every shown value the same way, so they line up.
+func (p *tensorPrinter) formatValues(verb byte, precision int) {
+	offsets := []int{0}
+	for axis, shown := range p.shown {
+		next := []int{}
+		for _, offset := range offsets {
+			for _, i := range shown {
+				if i >= 0 {
+					next = append(next, offset+i*p.strides[axis])
+				}
+			}
+		}
+		offsets = next
+	}
+
+	auto := verb == 'v' || verb == 's'
+	if auto {
+		verb = 'f'
+
+		// Use scientific notation for very large or very small values, like NumPy
+		for _, offset := range offsets {
+			abs := math.Abs(p.values[offset])
+			if !math.IsInf(abs, 0) && (abs >= 1e8 || abs != 0 && abs < 1e-4) {
+				verb = 'e'
+				break
+			}
+		}
+	}
+
+	// Fixed point values share the fewest decimals that show all of them
+	decimals := precision
+	if auto && verb == 'f' {
+		decimals = 0
+		for _, offset := range offsets {
+			text := strconv.FormatFloat(p.values[offset], 'f', precision, 64)
+			if dot := strings.IndexByte(text, '.'); dot >= 0 {
+				decimals = max(decimals, len(strings.TrimRight(text[dot+1:], "0")))
+			}
+		}
+	}
+
+	for _, offset := range offsets {
+		val := p.values[offset]
+
+		var text string
+		switch {
+		case math.IsNaN(val):
+			text = "nan"
+		case math.IsInf(val, 1):
+			text = "inf"
+		case math.IsInf(val, -1):
+			text = "-inf"
+		case auto && verb == 'f' && decimals == 0:
+			text = strconv.FormatFloat(val, 'f', 0, 64) + "."
+		default:
+			text = strconv.FormatFloat(val, verb, decimals, 64)
+		}
+
+		p.texts[offset] = text
+		p.width = max(p.width, len(text))
+	}
+}
+
+// write writes the block of axis starting at offset in the values.
+func (p *tensorPrinter) write(sb *strings.Builder, axis, offset int) {
+	sb.WriteString("[")
+
+	last := axis == len(p.shape)-1
+	separator := " "
+	if !last {
+		separator = strings.Repeat("\n", len(p.shape)-1-axis) + strings.Repeat(" ", axis+1)
+	}
+
+	for n, i := range p.shown[axis] {
+		if n > 0 {
+			sb.WriteString(separator)
+		}
+
+		switch {
+		case i < 0:
+			sb.WriteString("...")
+		case last:
+			text := p.texts[offset+i*p.strides[axis]]
+			sb.WriteString(strings.Repeat(" ", p.width-len(text)))
+			sb.WriteString(text)
+		default:
+			p.write(sb, axis+1, offset+i*p.strides[axis])
+		}
+	}
+
+	sb.WriteString("]")
+}
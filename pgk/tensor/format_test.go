@@ -0,0 +1,113 @@
+package tensor
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	small := fromFloat64s(Shape{2, 3}, []float64{1, 2.5, 3, 4, 5, 6.25}, Float64)
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{
+			name: "small",
+			got:  small.String(),
+			want: "Tensor shape=(2, 3) dtype=float64\n" +
+				"[[1.00 2.50 3.00]\n" +
+				" [4.00 5.00 6.25]]",
+		},
+		{
+			name: "summarized",
+			got:  arange(40, 40).String(),
+			want: "Tensor shape=(40, 40) dtype=float64\n" +
+				"[[   0.    1.    2. ...   37.   38.   39.]\n" +
+				" [  40.   41.   42. ...   77.   78.   79.]\n" +
+				" [  80.   81.   82. ...  117.  118.  119.]\n" +
+				" ...\n" +
+				" [1480. 1481. 1482. ... 1517. 1518. 1519.]\n" +
+				" [1520. 1521. 1522. ... 1557. 1558. 1559.]\n" +
+				" [1560. 1561. 1562. ... 1597. 1598. 1599.]]",
+		},
+		{
+			name: "more than 4 dimensions",
+			got:  arange(1, 2, 1, 2, 1, 2).String(),
+			want: "Tensor shape=(1, 2, 1, 2, 1, 2) dtype=float64\n" +
+				"[[[[[[0. 1.]]\n" +
+				"\n" +
+				"    [[2. 3.]]]]\n" +
+				"\n" +
+				"\n" +
+				"\n" +
+				"  [[[[4. 5.]]\n" +
+				"\n" +
+				"    [[6. 7.]]]]]]",
+		},
+		{
+			// The float32 rounding of 0.1 doesn't show up as extra digits
+			name: "float32",
+			got:  fromFloat64s(Shape{1, 3}, []float64{0.1, 0.5, 2}, Float32).String(),
+			want: "Tensor shape=(1, 3) dtype=float32\n" +
+				"[[0.1 0.5 2.0]]",
+		},
+		{
+			name: "scientific",
+			got:  fromFloat64s(Shape{3}, []float64{1e-5, 2, -300}, Float64).String(),
+			want: "Tensor shape=(3,) dtype=float64\n" +
+				"[ 1.0000e-05  2.0000e+00 -3.0000e+02]",
+		},
+		{
+			name: "verb precision",
+			got:  fmt.Sprintf("%.1e", small),
+			want: "Tensor shape=(2, 3) dtype=float64\n" +
+				"[[1.0e+00 2.5e+00 3.0e+00]\n" +
+				" [4.0e+00 5.0e+00 6.2e+00]]",
+		},
+		{
+			name: "unsupported verb",
+			got:  fmt.Sprintf("%d", small),
+			want: "%!d(tensor shape=(2, 3))",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.got != tc.want {
+				t.Errorf("got\n%s\nwant\n%s", tc.got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPrintOptions(t *testing.T) {
+	defer SetPrintOptions(CurrentPrintOptions())
+
+	if err := SetPrintOptions(PrintOptions{Precision: 2, Threshold: 10, EdgeItems: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	x := fromFloat64s(Shape{2, 2}, []float64{1.23456, -2, 3.5, 100}, Float64)
+	want := "Tensor shape=(2, 2) dtype=float64\n" +
+		"[[  1.23  -2.00]\n" +
+		" [  3.50 100.00]]"
+	if got := x.String(); got != want {
+		t.Errorf("precision 2: got\n%s\nwant\n%s", got, want)
+	}
+
+	want = "Tensor shape=(3, 4) dtype=float64\n" +
+		"[[ 0. ...  3.]\n" +
+		" ...\n" +
+		" [ 8. ... 11.]]"
+	if got := arange(3, 4).String(); got != want {
+		t.Errorf("threshold 10, 1 edge item: got\n%s\nwant\n%s", got, want)
+	}
+
+	for _, opts := range []PrintOptions{{Precision: -1, EdgeItems: 1}, {Threshold: -1, EdgeItems: 1}, {EdgeItems: 0}} {
+		if err := SetPrintOptions(opts); err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package tensor
+
+import (
+	"errors"
+)
+
+// im2colDims gives the number of patches along the rows and cols of an input
+// for a kernel sliding with the given strides.
+func im2colDims(shape Shape, kernelSize, strides [2]int) (outRows, outCols int, err error) {
+	if kernelSize[0] <= 0 || kernelSize[1] <= 0 || strides[0] <= 0 || strides[1] <= 0 {
+		return 0, 0, errors.New("kernel size and strides have to be positive")
+	}
+
+	if kernelSize[0] > shape.Rows() || kernelSize[1] > shape.Cols() {
+		return 0, 0, errors.New("kernel is bigger than the input")
+	}
+
+	outRows = (shape.Rows()-kernelSize[0])/strides[0] + 1
+	outCols = (shape.Cols()-kernelSize[1])/strides[1] + 1
+	return outRows, outCols, nil
+}
+
+// Im2Col unfolds every patch a kernel of kernelSize visits, sliding over t
+// with the given strides, into a row of a matrix. Cross correlating with a set
+// of kernels then becomes a matrix multiplication with the flattened kernels.
+// An input of (batches, channels, rows, cols) gives (batches, 1, patches,
+// channels*kernelRows*kernelCols), with the patches in row major order.
+func (t *tensor[E]) Im2Col(kernelSize, strides [2]int) (Tensor, error) {
+	outRows, outCols, err := im2colDims(t.Shape(), kernelSize, strides)
+	if err != nil {
+		return nil, err
+	}
+
+	batches, channels := t.Shape().Batches(), t.Shape().Channels()
+	rows, cols := t.Shape().Rows(), t.Shape().Cols()
+	kRows, kCols := kernelSize[0], kernelSize[1]
+
+	patchSize := channels * kRows * kCols
+	resTen := zeros[E]([]int{batches, 1, outRows * outCols, patchSize})
+
+	tData := t.values()
+	resData := resTen.Data
+
+	idx := 0
+	for b := range batches {
+		for i := range outRows {
+			for j := range outCols {
+				for ch := range channels {
+					start := ((b*channels+ch)*rows+i*strides[0])*cols + j*strides[1]
+					for ki := range kRows {
+						row := start + ki*cols
+						copy(resData[idx:idx+kCols], tData[row:row+kCols])
+						idx += kCols
+					}
+				}
+			}
+		}
+	}
+
+	return resTen, nil
+}
+
+// Col2Im is the reverse of Im2Col, it folds the patches in the rows of t back
+// into a tensor of shape. Values of overlapping patches are summed, which
+// makes it the gradient of Im2Col.
+func (t *tensor[E]) Col2Im(shape Shape, kernelSize, strides [2]int) (Tensor, error) {
+	outRows, outCols, err := im2colDims(shape, kernelSize, strides)
+	if err != nil {
+		return nil, err
+	}
+
+	batches, channels := shape.Batches(), shape.Channels()
+	rows, cols := shape.Rows(), shape.Cols()
+	kRows, kCols := kernelSize[0], kernelSize[1]
+
+	if t.Size() != batches*outRows*outCols*channels*kRows*kCols {
+		return nil, errors.New("patches don't match the shape and kernel size")
+	}
+
+	resTen := zeros[E](shape)
+
+	tData := t.values()
+	resData := resTen.Data
+
+	idx := 0
+	for b := range batches {
+		for i := range outRows {
+			for j := range outCols {
+				for ch := range channels {
+					start := ((b*channels+ch)*rows+i*strides[0])*cols + j*strides[1]
+					for ki := range kRows {
+						row := resData[start+ki*cols : start+ki*cols+kCols]
+						for kj := range row {
+							row[kj] += tData[idx]
+							idx++
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return resTen, nil
+}
@@ -0,0 +1,181 @@
+package tensor
+
+import (
+	"errors"
+	"fmt"
+)
+
+// indexValues reads the values of an index tensor as ints, they have to be
+// whole numbers.
+func indexValues(index Tensor) ([]int, error) {
+	values := float64s(index)
+	indices := make([]int, len(values))
+	for i, val := range values {
+		indices[i] = int(val)
+		if float64(indices[i]) != val {
+			return nil, fmt.Errorf("index %v is not a whole number", val)
+		}
+	}
+	return indices, nil
+}
+
+// checkIndices makes sure every index lies in [0, size).
+func checkIndices(indices []int, size int) error {
+	for _, idx := range indices {
+		if idx < 0 || idx >= size {
+			return fmt.Errorf("index %d out of range for size %d", idx, size)
+		}
+	}
+	return nil
+}
+
+// indexLoop walks a tensor of shape whose positions along axis come from
+// indices, calling fn with the row major index into shape and the matching
+// offset into a tensor with the given strides.
+func indexLoop(shape Shape, strides []int, axis int, indices []int, fn func(i, offset int)) {
+	walkStrides := append([]int(nil), strides...)
+	walkStrides[axis] = 0
+
+	// Row major stride of axis in shape, to find the position along axis
+	inner := 1
+	for _, dim := range shape[axis+1:] {
+		inner *= dim
+	}
+
+	broadcastLoop(shape, [][]int{walkStrides}, func(i int, offsets []int) {
+		fn(i, offsets[0]+indices[(i/inner)%shape[axis]]*strides[axis])
+	})
+}
+
+// Take returns the values at the given row major indices as a tensor of shape
+// (len(indices)).
+func (t *tensor[E]) Take(indices []int) (Tensor, error) {
+	if err := checkIndices(indices, t.Size()); err != nil {
+		return nil, err
+	}
+
+	resTen := zeros[E]([]int{len(indices)})
+	for i, idx := range indices {
+		resTen.Data[i] = t.Data[t.offset(idx)]
+	}
+
+	return resTen, nil
+}
+
+// IndexSelect picks the slices along axis at the given indices, e.g. the rows
+// of an embedding matrix with IndexSelect(0, ids). Indices can repeat.
+func (t *tensor[E]) IndexSelect(axis int, indices []int) (Tensor, error) {
+	axis, err := tensorAxis(t.Dims(), axis)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkIndices(indices, t.TShape[axis]); err != nil {
+		return nil, err
+	}
+
+	newShape := t.Shape().Clone()
+	newShape[axis] = len(indices)
+
+	resTen := zeros[E](newShape)
+	indexLoop(newShape, t.Strides(), axis, indices, func(i, offset int) {
+		resTen.Data[i] = t.Data[offset]
+	})
+
+	return resTen, nil
+}
+
+// Gather picks a value along axis for every element of index, which has the
+// same number of dimensions as t and gives the result its shape. For a matrix
+// and axis 1 that is res[i][j] = t[i][index[i][j]].
+func (t *tensor[E]) Gather(axis int, index Tensor) (Tensor, error) {
+	axis, indices, err := t.checkScatterIndex(axis, index)
+	if err != nil {
+		return nil, err
+	}
+
+	resTen := zeros[E](index.Shape().Clone())
+	scatterLoop(index.Shape(), t.Strides(), axis, indices, func(i, offset int) {
+		resTen.Data[i] = t.Data[offset]
+	})
+
+	return resTen, nil
+}
+
+// ScatterAdd is the reverse of Gather, it adds every value of src to t at the
+// position along axis given by index, which has the shape of src. Values sent
+// to the same position are summed.
+func (t *tensor[E]) ScatterAdd(axis int, index Tensor, src Tensor, inPlace bool) (Tensor, error) {
+	if src == nil {
+		return nil, errors.New("src cannot be nil")
+	}
+
+	axis, indices, err := t.checkScatterIndex(axis, index)
+	if err != nil {
+		return nil, err
+	}
+
+	if !src.Shape().DeepEq(index.Shape()) {
+		return nil, fmt.Errorf("src shape %v doesn't match index shape %v", src.Shape(), index.Shape())
+	}
+
+	resTen := t
+	if !inPlace {
+		resTen = zeros[E](t.Shape())
+		copy(resTen.Data, t.values())
+	}
+
+	srcData := asType[E](src).values()
+	scatterLoop(index.Shape(), resTen.Strides(), axis, indices, func(i, offset int) {
+		resTen.Data[offset] += srcData[i]
+	})
+
+	return resTen, nil
+}
+
+// checkScatterIndex checks an index for Gather and ScatterAdd, it needs the
+// dimensions of t, can't be bigger than t outside of axis, and has to point
+// inside of t along axis.
+func (t *tensor[E]) checkScatterIndex(axis int, index Tensor) (int, []int, error) {
+	if index == nil {
+		return 0, nil, errors.New("index cannot be nil")
+	}
+
+	axis, err := tensorAxis(t.Dims(), axis)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if index.Dims() != t.Dims() {
+		return 0, nil, fmt.Errorf("index shape %v doesn't have the dimensions of %v", index.Shape(), t.Shape())
+	}
+
+	for d, dim := range index.Shape() {
+		if d != axis && dim > t.TShape[d] {
+			return 0, nil, fmt.Errorf("index shape %v is bigger than %v outside of axis %d", index.Shape(), t.Shape(), axis)
+		}
+	}
+
+	indices, err := indexValues(index)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := checkIndices(indices, t.TShape[axis]); err != nil {
+		return 0, nil, err
+	}
+
+	return axis, indices, nil
+}
+
+// scatterLoop walks the elements of an index of shape, calling fn with the
+// row major index into it and the offset of the element it points to in a
+// tensor with the given strides.
+func scatterLoop(shape Shape, strides []int, axis int, indices []int, fn func(i, offset int)) {
+	walkStrides := append([]int(nil), strides...)
+	walkStrides[axis] = 0
+
+	broadcastLoop(shape, [][]int{walkStrides}, func(i int, offsets []int) {
+		fn(i, offsets[0]+indices[i]*strides[axis])
+	})
+}
@@ -0,0 +1,216 @@
+package tensor
+
+import (
+	"fmt"
+	"testing"
+)
+
+// arange returns a Float64 tensor of shape holding 0, 1, 2, ...
+func arange(shape ...int) Tensor {
+	values := make([]float64, Shape(shape).TotalSize())
+	for i := range values {
+		values[i] = float64(i)
+	}
+	return fromFloat64s(shape, values, Float64)
+}
+
+func indexTensor(shape Shape, values ...float64) Tensor {
+	return fromFloat64s(shape, values, Float64)
+}
+
+func assertExact(t *testing.T, name string, got Tensor, shape Shape, want ...float64) {
+	t.Helper()
+
+	if !got.Shape().DeepEq(shape) {
+		t.Fatalf("%s: shape %v, want %v", name, got.Shape(), shape)
+	}
+	if fmt.Sprint(Values[float64](got)) != fmt.Sprint(want) {
+		t.Fatalf("%s: values %v, want %v", name, Values[float64](got), want)
+	}
+}
+
+func TestTake(t *testing.T) {
+	x := arange(3, 4)
+	res, err := x.Take([]int{0, 11, 5, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExact(t, "Take", res, Shape{4}, 0, 11, 5, 5)
+
+	// Indices are row major in the view, not in the data behind it
+	res, err = x.Transpose(false).Take([]int{1, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExact(t, "Take of a transpose", res, Shape{2}, 4, 1)
+
+	for _, indices := range [][]int{{-1}, {12}, {0, 40}} {
+		if _, err := x.Take(indices); err == nil {
+			t.Errorf("Take(%v): expected an error", indices)
+		}
+	}
+}
+
+func TestIndexSelect(t *testing.T) {
+	x := arange(3, 4)
+
+	tests := []struct {
+		name    string
+		axis    int
+		indices []int
+		shape   Shape
+		want    []float64
+	}{
+		{"rows", 0, []int{2, 0}, Shape{2, 4}, []float64{8, 9, 10, 11, 0, 1, 2, 3}},
+		{"repeated rows", 0, []int{1, 1}, Shape{2, 4}, []float64{4, 5, 6, 7, 4, 5, 6, 7}},
+		{"columns", 1, []int{3, 0}, Shape{3, 2}, []float64{3, 0, 7, 4, 11, 8}},
+		{"negative axis", -1, []int{1}, Shape{3, 1}, []float64{1, 5, 9}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := x.IndexSelect(tc.axis, tc.indices)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertExact(t, "IndexSelect", res, tc.shape, tc.want...)
+		})
+	}
+
+	errTests := []struct {
+		name    string
+		axis    int
+		indices []int
+	}{
+		{"negative index", 0, []int{-1}},
+		{"index out of range", 1, []int{4}},
+		{"axis out of range", 2, []int{0}},
+	}
+
+	for _, tc := range errTests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := x.IndexSelect(tc.axis, tc.indices); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestGather(t *testing.T) {
+	x := arange(2, 3)
+
+	res, err := x.Gather(1, indexTensor(Shape{2, 2}, 2, 0, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExact(t, "Gather axis 1", res, Shape{2, 2}, 2, 0, 4, 4)
+
+	res, err = x.Gather(0, indexTensor(Shape{1, 3}, 1, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExact(t, "Gather axis 0", res, Shape{1, 3}, 3, 1, 5)
+
+	errTests := []struct {
+		name  string
+		index Tensor
+	}{
+		{"negative index", indexTensor(Shape{2, 1}, -1, 0)},
+		{"index out of range", indexTensor(Shape{2, 1}, 0, 3)},
+		{"fractional index", indexTensor(Shape{2, 1}, 0, 0.5)},
+		{"fewer dimensions", indexTensor(Shape{2}, 0, 1)},
+		{"bigger outside of axis", indexTensor(Shape{3, 1}, 0, 0, 0)},
+	}
+
+	for _, tc := range errTests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := x.Gather(1, tc.index); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestScatterAdd(t *testing.T) {
+	x := Zeros(Shape{2, 3}, Float64)
+	index := indexTensor(Shape{2, 3}, 0, 0, 2, 1, 1, 1)
+	src := indexTensor(Shape{2, 3}, 1, 2, 3, 4, 5, 6)
+
+	// Repeated positions accumulate
+	res, err := x.ScatterAdd(1, index, src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExact(t, "ScatterAdd", res, Shape{2, 3}, 3, 0, 3, 0, 15, 0)
+	assertExact(t, "t after ScatterAdd", x, Shape{2, 3}, 0, 0, 0, 0, 0, 0)
+
+	if _, err := x.ScatterAdd(1, index, src, true); err != nil {
+		t.Fatal(err)
+	}
+	assertExact(t, "ScatterAdd in place", x, Shape{2, 3}, 3, 0, 3, 0, 15, 0)
+
+	if _, err := x.ScatterAdd(1, index, Zeros(Shape{2, 2}, Float64), false); err == nil {
+		t.Error("src of another shape: expected an error")
+	}
+	if _, err := x.ScatterAdd(1, indexTensor(Shape{1, 1}, -2), indexTensor(Shape{1, 1}, 1), false); err == nil {
+		t.Error("negative index: expected an error")
+	}
+}
+
+func TestIndexGradients(t *testing.T) {
+	rng := NewRNG(1)
+	rand := func(shape ...int) Tensor {
+		ten, _ := rng.RandTensor(shape, -1, 1)
+		return ten
+	}
+
+	// Every objective weights its output, so each position gets its own
+	// gradient
+	weights := map[string]Tensor{}
+	weighted := func(tp *Tape, v *Variable, err error) (*Variable, error) {
+		if err != nil {
+			return nil, err
+		}
+
+		key := fmt.Sprint(v.Value.Shape())
+		if weights[key] == nil {
+			weights[key] = rand(v.Value.Shape()...)
+		}
+		product, err := tp.Multiply(v, tp.Constant(weights[key]))
+		if err != nil {
+			return nil, err
+		}
+		return tp.Sum(product), nil
+	}
+
+	index := indexTensor(Shape{2, 3}, 0, 0, 2, 3, 1, 3)
+
+	tests := []struct {
+		name      string
+		objective func(tp *Tape, vars []*Variable) (*Variable, error)
+		values    []Tensor
+	}{
+		{"Take", func(tp *Tape, vars []*Variable) (*Variable, error) {
+			v, err := tp.Take(vars[0], []int{5, 0, 5, 11})
+			return weighted(tp, v, err)
+		}, []Tensor{rand(3, 4)}},
+		{"IndexSelect", func(tp *Tape, vars []*Variable) (*Variable, error) {
+			v, err := tp.IndexSelect(vars[0], -1, []int{3, 1, 3})
+			return weighted(tp, v, err)
+		}, []Tensor{rand(3, 4)}},
+		{"Gather", func(tp *Tape, vars []*Variable) (*Variable, error) {
+			v, err := tp.Gather(vars[0], 1, index)
+			return weighted(tp, v, err)
+		}, []Tensor{rand(2, 4)}},
+		{"ScatterAdd", func(tp *Tape, vars []*Variable) (*Variable, error) {
+			v, err := tp.ScatterAdd(vars[0], 1, index, vars[1])
+			return weighted(tp, v, err)
+		}, []Tensor{rand(2, 4), rand(2, 3)}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			checkGradients(t, tc.objective, tc.values...)
+		})
+	}
+}
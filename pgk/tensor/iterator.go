@@ -10,58 +10,48 @@ type TensorIter interface {
   HasNext() bool
 }
 
-type tensorIter struct {
-  t Tensor
-  shape Shape
-  step int
-}
-
-func (ti *tensorIter) Next() (Tensor, bool) {
-  start := ti.shape.TotalSize() * ti.step
-  end := ti.shape.TotalSize() * (ti.step + 1)
-
-  if ti.t.Shape().TotalSize() < end {
-    return nil, false
-  }
-
-  ti.step++
- 
-  return &tensor{TShape: ti.shape.Clone(), Data: (*ti.t.data())[start:end]}, true
-}
-
-func (ti *tensorIter) HasNext() bool {
-  end := ti.shape.TotalSize() * (ti.step + 1)
-
-  if ti.t.Shape().TotalSize() < end {
-    return false
-  }
-  return true
-}
-
+// IterFromTensor returns an iterator over the batches, rows, columns or
+// matrices of t, picked by what. Any other mode iterates the matrices, like
+// "matrices" does. Rows are of shape (1, cols) and columns step over every
+// value as a (1, 1) tensor.
+//
+// Deprecated: Use IterAxes, IterRows or IterMatrices, which take the axes to
+// iterate instead of a string.
 func IterFromTensor(t Tensor, what string) (TensorIter, error) {
   if t.Dims() < 2 {
     return nil, errors.New("Cannot make iter over scalars")
   }
 
+  var axes []int
   var shape Shape
   switch strings.ToLower(what) {
   case "b", "batch", "batches":
-    if t.Shape().IsMatrix() {
-      shape = t.Shape().Clone()
-      break
+    if !t.Shape().IsMatrix() {
+      axes = []int{0}
     }
-    shape = t.Shape().Clone()[1:]
 
   case "r", "row", "rows":
-    shape = []int{1, t.Shape().Cols()}
+    axes = leadingAxes(t.Dims(), 1)
+    shape = Shape{1, t.Shape().Cols()}
 
   case "c", "col", "column", "columns":
-    shape = []int{1,1}
+    axes = leadingAxes(t.Dims(), 0)
+    shape = Shape{1, 1}
 
-  // Defaults to matrices
   default:
-    shape = t.Shape().Clone()[len(t.Shape())-2:]
+    axes = leadingAxes(t.Dims(), 2)
+  }
+
+  it, err := IterAxes(t, axes...)
+  if err != nil {
+    return nil, err
+  }
+
+  // Keep the leading dimensions of size 1 the sub-tensors used to have
+  for len(it.shape) < len(shape) {
+    it.shape = append(Shape{1}, it.shape...)
+    it.subStrides = append([]int{0}, it.subStrides...)
   }
 
-  return &tensorIter{t: t, shape: shape}, nil
+  return it, nil
 }
@@ -0,0 +1,69 @@
+package tensor
+
+import "testing"
+
+func TestIterFromTensor(t *testing.T) {
+	values := make([]float64, 2*3*2*4)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	ten, _ := TensorFrom([]int{2, 3, 2, 4}, values)
+
+	tests := []struct {
+		mode  string
+		steps int
+		shape Shape
+	}{
+		{"batches", 2, Shape{3, 2, 4}},
+		{"rows", 12, Shape{1, 4}},
+		{"columns", 48, Shape{1, 1}},
+		{"matrices", 6, Shape{2, 4}},
+		{"", 6, Shape{2, 4}},
+
+		// Unknown modes fall back to the matrices
+		{"channel", 6, Shape{2, 4}},
+	}
+
+	for _, test := range tests {
+		it, err := IterFromTensor(ten, test.mode)
+		if err != nil {
+			t.Fatalf("%q: %v", test.mode, err)
+		}
+
+		// The sub-tensors walk the values in order
+		steps, next := 0, 0.0
+		for sub, ok := it.Next(); ok; sub, ok = it.Next() {
+			if !sub.Shape().DeepEq(test.shape) {
+				t.Fatalf("%q: step %d has shape %v, want %v", test.mode, steps, sub.Shape(), test.shape)
+			}
+
+			for _, val := range sub.DataCopy() {
+				if val != next {
+					t.Fatalf("%q: step %d has value %v, want %v", test.mode, steps, val, next)
+				}
+				next++
+			}
+			steps++
+		}
+
+		if steps != test.steps {
+			t.Errorf("%q: %d steps, want %d", test.mode, steps, test.steps)
+		}
+	}
+}
+
+func TestIterFromTensorMatrixBatches(t *testing.T) {
+	ten := Zeros(Shape{1, 1, 3, 4}, Float64)
+	it, err := IterFromTensor(ten, "batches")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub, ok := it.Next()
+	if !ok || !sub.Shape().DeepEq(ten.Shape()) {
+		t.Fatalf("first batch of a matrix is %v, want the whole tensor", sub)
+	}
+	if it.HasNext() {
+		t.Errorf("a matrix has a single batch")
+	}
+}
@@ -0,0 +1,168 @@
+package tensor
+
+import (
+	"errors"
+	"fmt"
+)
+
+// tensorAxis checks axis against the dimensions of a tensor, turning a
+// negative axis into its positive position.
+func tensorAxis(dims, given int) (int, error) {
+	axis := given
+	if axis < 0 {
+		axis += dims
+	}
+
+	if axis < 0 || axis >= dims {
+		return 0, fmt.Errorf("axis %d out of range for %d dimensions", given, dims)
+	}
+	return axis, nil
+}
+
+// Concatenate joins tensors along an existing axis. The tensors need the same
+// number of dimensions and the same sizes except along axis, the result has
+// the dtype of the first tensor.
+func Concatenate(axis int, tensors ...Tensor) (Tensor, error) {
+	if len(tensors) == 0 {
+		return nil, errors.New("no tensors to concatenate")
+	}
+
+	for _, ten := range tensors {
+		if ten == nil {
+			return nil, errors.New("Tensors cannot be nil")
+		}
+	}
+
+	first := tensors[0].Shape()
+	axis, err := tensorAxis(first.Dims(), axis)
+	if err != nil {
+		return nil, err
+	}
+
+	newShape := first.Clone()
+	newShape[axis] = 0
+	for _, ten := range tensors {
+		shape := ten.Shape()
+		if shape.Dims() != first.Dims() {
+			return nil, shapeMismatch("Concatenate", first, shape)
+		}
+
+		// Only the size along axis can differ
+		expected := first.Clone()
+		expected[axis] = shape[axis]
+		if !shape.DeepEq(expected) {
+			return nil, shapeMismatch("Concatenate", expected, shape)
+		}
+
+		newShape[axis] += shape[axis]
+	}
+
+	resTen := Zeros(newShape, tensors[0].DType())
+	strides := resTen.Strides()
+
+	// Copy every tensor into its part of the result
+	start := 0
+	for _, ten := range tensors {
+		part := resTen.view(start*strides[axis], ten.Shape().Clone(), strides)
+		part.assignFrom(ten)
+		start += ten.Shape()[axis]
+	}
+
+	return resTen, nil
+}
+
+// Stack joins tensors of the same shape along a new axis inserted at axis.
+func Stack(axis int, tensors ...Tensor) (Tensor, error) {
+	if len(tensors) == 0 {
+		return nil, errors.New("no tensors to stack")
+	}
+
+	expanded := make([]Tensor, len(tensors))
+	for i, ten := range tensors {
+		if ten == nil {
+			return nil, errors.New("Tensors cannot be nil")
+		}
+
+		if !ten.Shape().DeepEq(tensors[0].Shape()) {
+			return nil, shapeMismatch("Stack", tensors[0].Shape(), ten.Shape())
+		}
+
+		var err error
+		expanded[i], err = ten.ExpandDims(axis)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if axis < 0 {
+		axis += tensors[0].Dims() + 1
+	}
+
+	return Concatenate(axis, expanded...)
+}
+
+// Split cuts a tensor along axis into parts of the given sizes, which have to
+// add up to the size of the axis. The parts are views sharing data with t.
+func Split(t Tensor, axis int, sizes ...int) ([]Tensor, error) {
+	if t == nil {
+		return nil, errors.New("Tensor cannot be nil")
+	}
+
+	axis, err := tensorAxis(t.Dims(), axis)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, size := range sizes {
+		if size < 0 {
+			return nil, errors.New("split sizes cannot be negative")
+		}
+		total += size
+	}
+
+	if total != t.Shape()[axis] {
+		return nil, fmt.Errorf("split sizes %v don't add up to %d", sizes, t.Shape()[axis])
+	}
+
+	strides := t.Strides()
+	parts := make([]Tensor, len(sizes))
+
+	start := 0
+	for i, size := range sizes {
+		shape := t.Shape().Clone()
+		shape[axis] = size
+
+		parts[i] = t.view(start*strides[axis], shape, append([]int(nil), strides...))
+		start += size
+	}
+
+	return parts, nil
+}
+
+// Chunk cuts a tensor along axis into the given number of parts of equal
+// size, only the last part can be smaller. Like Split the parts are views.
+func Chunk(t Tensor, axis int, chunks int) ([]Tensor, error) {
+	if t == nil {
+		return nil, errors.New("Tensor cannot be nil")
+	}
+
+	if chunks <= 0 {
+		return nil, errors.New("number of chunks has to be positive")
+	}
+
+	axis, err := tensorAxis(t.Dims(), axis)
+	if err != nil {
+		return nil, err
+	}
+
+	dim := t.Shape()[axis]
+	chunkSize := (dim + chunks - 1) / chunks
+
+	sizes := []int{}
+	for start := 0; start < dim; start += chunkSize {
+		sizes = append(sizes, min(chunkSize, dim-start))
+	}
+
+	return Split(t, axis, sizes...)
+}
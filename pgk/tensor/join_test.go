@@ -0,0 +1,144 @@
+package tensor
+
+import "testing"
+
+func TestSplitConcatenateRoundTrip(t *testing.T) {
+	transposed := arange(2, 4, 3).Transpose(false)
+	permuted, _ := arange(4, 2, 3).Permute(1, 0, 2)
+
+	tests := []struct {
+		name  string
+		x     Tensor
+		axis  int
+		sizes []int
+	}{
+		{"axis 0", arange(2, 3, 4), 0, []int{1, 1}},
+		{"axis 1", arange(2, 3, 4), 1, []int{2, 0, 1}},
+		{"negative axis", arange(2, 3, 4), -1, []int{1, 3}},
+		{"transposed view", transposed, 2, []int{3, 1}},
+		{"transposed view along its rows", transposed, -2, []int{1, 2}},
+		{"permuted view", permuted, 1, []int{1, 2, 1}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			want := Values[float64](tc.x)
+
+			parts, err := Split(tc.x, tc.axis, tc.sizes...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, part := range parts {
+				shape := tc.x.Shape().Clone()
+				axis, _ := tensorAxis(shape.Dims(), tc.axis)
+				shape[axis] = tc.sizes[i]
+				if !part.Shape().DeepEq(shape) {
+					t.Fatalf("part %d has shape %v, want %v", i, part.Shape(), shape)
+				}
+			}
+
+			res, err := Concatenate(tc.axis, parts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertExact(t, "Concatenate", res, tc.x.Shape(), want...)
+		})
+	}
+}
+
+func TestChunk(t *testing.T) {
+	parts, err := Chunk(arange(7, 2), 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Chunks of 3, the last one smaller
+	for i, rows := range []int{3, 3, 1} {
+		if parts[i].Shape()[0] != rows {
+			t.Errorf("chunk %d has %d rows, want %d", i, parts[i].Shape()[0], rows)
+		}
+	}
+	assertExact(t, "last chunk", parts[2], Shape{1, 2}, 12, 13)
+
+	if _, err := Chunk(arange(7, 2), 0, 0); err == nil {
+		t.Error("zero chunks: expected an error")
+	}
+}
+
+func TestStack(t *testing.T) {
+	a, b := arange(2, 2), arange(2, 2).ScalarAdd(10, false)
+
+	res, err := Stack(0, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExact(t, "Stack axis 0", res, Shape{2, 2, 2}, 0, 1, 2, 3, 10, 11, 12, 13)
+
+	res, err = Stack(-1, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExact(t, "Stack axis -1", res, Shape{2, 2, 2}, 0, 10, 1, 11, 2, 12, 3, 13)
+
+	// A transposed view stacks its values, not its data
+	res, err = Stack(0, a.Transpose(false), b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExact(t, "Stack of a transpose", res, Shape{2, 2, 2}, 0, 2, 1, 3, 10, 11, 12, 13)
+}
+
+func TestJoinErrors(t *testing.T) {
+	x := arange(2, 3)
+
+	tests := []struct {
+		name string
+		join func() error
+	}{
+		{"concatenate other dimensions", func() error {
+			_, err := Concatenate(0, x, arange(2, 3, 1))
+			return err
+		}},
+		{"concatenate other size outside of axis", func() error {
+			_, err := Concatenate(0, x, arange(2, 4))
+			return err
+		}},
+		{"concatenate axis out of range", func() error {
+			_, err := Concatenate(-3, x, x)
+			return err
+		}},
+		{"concatenate nothing", func() error {
+			_, err := Concatenate(0)
+			return err
+		}},
+		{"stack other shapes", func() error {
+			_, err := Stack(0, x, arange(3, 2))
+			return err
+		}},
+		{"stack axis out of range", func() error {
+			_, err := Stack(3, x, x)
+			return err
+		}},
+		{"split sizes not adding up", func() error {
+			_, err := Split(x, 1, 1, 1)
+			return err
+		}},
+		{"split negative size", func() error {
+			_, err := Split(x, 1, 4, -1)
+			return err
+		}},
+		{"split axis out of range", func() error {
+			_, err := Split(x, 2, 3)
+			return err
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.join(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
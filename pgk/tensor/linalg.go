@@ -0,0 +1,590 @@
+package tensor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// The linear algebra routines work on 2-D tensors, or tensors whose
+// dimensions in front of the last two are all 1. They compute in float64 and
+// return tensors with the dtype of their input.
+
+// Jacobi rotations stop once every pair of columns is orthogonal up to this
+// fraction of their norms.
+const (
+	jacobiTolerance = 1e-14
+	jacobiMaxSweeps = 100
+)
+
+// linalgMatrix returns a copy of the values of a matrix in row major order
+// together with its rows and cols.
+func linalgMatrix(op string, a Tensor) ([]float64, int, int, error) {
+	if a == nil {
+		return nil, 0, 0, errors.New("Tensor cannot be nil")
+	}
+
+	shape := a.Shape()
+	if shape.Dims() < 2 || !singleMatrix(shape) {
+		return nil, 0, 0, fmt.Errorf("%s needs a matrix, got %v", op, shape)
+	}
+
+	return Values[float64](a), shape.Rows(), shape.Cols(), nil
+}
+
+func squareMatrix(op string, a Tensor) ([]float64, int, error) {
+	data, rows, cols, err := linalgMatrix(op, a)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if rows != cols {
+		return nil, 0, shapeMismatch(op, Shape{rows, rows}, a.Shape())
+	}
+	return data, rows, nil
+}
+
+// luDecompose factors the n by n matrix lu in place into L and U with partial
+// pivoting, L below the diagonal without its ones and U on and above it. It
+// returns the original row of every row and the sign of that permutation.
+func luDecompose(lu []float64, n int) ([]int, float64, error) {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+
+	sign := 1.0
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(lu[i*n+k]) > math.Abs(lu[p*n+k]) {
+				p = i
+			}
+		}
+
+		if lu[p*n+k] == 0 {
+			return nil, 0, errors.New("matrix is singular")
+		}
+
+		if p != k {
+			for j := 0; j < n; j++ {
+				lu[k*n+j], lu[p*n+j] = lu[p*n+j], lu[k*n+j]
+			}
+			perm[k], perm[p] = perm[p], perm[k]
+			sign = -sign
+		}
+
+		for i := k + 1; i < n; i++ {
+			f := lu[i*n+k] / lu[k*n+k]
+			lu[i*n+k] = f
+			if f == 0 {
+				continue
+			}
+			for j := k + 1; j < n; j++ {
+				lu[i*n+j] -= f * lu[k*n+j]
+			}
+		}
+	}
+
+	return perm, sign, nil
+}
+
+// luSolve solves LU x = b for the k columns of the n by k matrix b, using the
+// factors and row order from luDecompose.
+func luSolve(lu []float64, n int, perm []int, b []float64, k int) []float64 {
+	x := make([]float64, n*k)
+	for i, p := range perm {
+		copy(x[i*k:(i+1)*k], b[p*k:(p+1)*k])
+	}
+
+	// Forward substitution with L, which has ones on its diagonal
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			if f := lu[i*n+j]; f != 0 {
+				for c := 0; c < k; c++ {
+					x[i*k+c] -= f * x[j*k+c]
+				}
+			}
+		}
+	}
+
+	// Back substitution with U
+	for i := n - 1; i >= 0; i-- {
+		for j := i + 1; j < n; j++ {
+			if f := lu[i*n+j]; f != 0 {
+				for c := 0; c < k; c++ {
+					x[i*k+c] -= f * x[j*k+c]
+				}
+			}
+		}
+		for c := 0; c < k; c++ {
+			x[i*k+c] /= lu[i*n+i]
+		}
+	}
+
+	return x
+}
+
+// Solve solves a x = b for x, with a a square matrix and b a vector of shape
+// (n) or a matrix with n rows, giving x in the shape of b. Singular matrices
+// give an error.
+func Solve(a, b Tensor) (Tensor, error) {
+	lu, n, err := squareMatrix("Solve", a)
+	if err != nil {
+		return nil, err
+	}
+
+	if b == nil {
+		return nil, errors.New("Tensor cannot be nil")
+	}
+
+	bShape := b.Shape()
+	k := bShape.Cols()
+	rows := bShape.Rows()
+	if bShape.Dims() == 1 {
+		k, rows = 1, bShape[0]
+	}
+	if !singleMatrix(bShape) || rows != n {
+		return nil, fmt.Errorf("cannot solve for %v with a %v matrix", bShape, a.Shape())
+	}
+
+	perm, _, err := luDecompose(lu, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromFloat64s(bShape, luSolve(lu, n, perm, Values[float64](b), k), a.DType()), nil
+}
+
+// Inverse returns the inverse of a square matrix, singular matrices give an
+// error. Solve is faster and more accurate for solving a x = b.
+func Inverse(a Tensor) (Tensor, error) {
+	lu, n, err := squareMatrix("Inverse", a)
+	if err != nil {
+		return nil, err
+	}
+
+	perm, _, err := luDecompose(lu, n)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		identity[i*n+i] = 1
+	}
+
+	return fromFloat64s(Shape{n, n}, luSolve(lu, n, perm, identity, n), a.DType()), nil
+}
+
+// Determinant returns the determinant of a square matrix.
+func Determinant(a Tensor) (float64, error) {
+	lu, n, err := squareMatrix("Determinant", a)
+	if err != nil {
+		return 0, err
+	}
+
+	_, sign, err := luDecompose(lu, n)
+	if err != nil {
+		// Singular
+		return 0, nil
+	}
+
+	det := sign
+	for i := 0; i < n; i++ {
+		det *= lu[i*n+i]
+	}
+	return det, nil
+}
+
+// Cholesky returns the lower triangular L with a = L L^T for a symmetric
+// positive definite matrix. Only the lower triangle of a is read.
+func Cholesky(a Tensor) (Tensor, error) {
+	data, n, err := squareMatrix("Cholesky", a)
+	if err != nil {
+		return nil, err
+	}
+
+	l := make([]float64, n*n)
+	for j := 0; j < n; j++ {
+		diag := data[j*n+j]
+		for k := 0; k < j; k++ {
+			diag -= l[j*n+k] * l[j*n+k]
+		}
+
+		// Also catches NaN
+		if !(diag > 0) {
+			return nil, errors.New("matrix is not positive definite")
+		}
+		l[j*n+j] = math.Sqrt(diag)
+
+		for i := j + 1; i < n; i++ {
+			sum := data[i*n+j]
+			for k := 0; k < j; k++ {
+				sum -= l[i*n+k] * l[j*n+k]
+			}
+			l[i*n+j] = sum / l[j*n+j]
+		}
+	}
+
+	return fromFloat64s(Shape{n, n}, l, a.DType()), nil
+}
+
+// QR factors an m by n matrix into q with orthonormal columns and upper
+// triangular r, with k = min(m, n) q is (m, k) and r is (k, n). The diagonal
+// of r is made nonnegative, which makes the factors unique for matrices of
+// full rank, e.g. for orthogonal initialization.
+func QR(a Tensor) (q, r Tensor, err error) {
+	data, m, n, err := linalgMatrix("QR", a)
+	if err != nil {
+		return nil, nil, err
+	}
+	k := min(m, n)
+
+	// Householder reflections zero every column below the diagonal, the
+	// reflection of column j is I - 2 v v^T / v^T v over rows j to m
+	reflectors := make([][]float64, k)
+	for j := 0; j < k; j++ {
+		norm := 0.0
+		for i := j; i < m; i++ {
+			norm = math.Hypot(norm, data[i*n+j])
+		}
+		if norm == 0 {
+			continue
+		}
+
+		v := make([]float64, m-j)
+		for i := j; i < m; i++ {
+			v[i-j] = data[i*n+j]
+		}
+		v[0] += math.Copysign(norm, data[j*n+j])
+
+		reflect(v, data, j, n, j)
+		reflectors[j] = v
+	}
+
+	// Build q by applying the reflections in reverse to the first k columns
+	// of the identity
+	qData := make([]float64, m*k)
+	for i := 0; i < k; i++ {
+		qData[i*k+i] = 1
+	}
+	for j := k - 1; j >= 0; j-- {
+		if reflectors[j] != nil {
+			reflect(reflectors[j], qData, j, k, 0)
+		}
+	}
+
+	rData := make([]float64, k*n)
+	for i := 0; i < k; i++ {
+		copy(rData[i*n+i:(i+1)*n], data[i*n+i:(i+1)*n])
+	}
+
+	for i := 0; i < k; i++ {
+		if rData[i*n+i] >= 0 {
+			continue
+		}
+		for j := i; j < n; j++ {
+			rData[i*n+j] = -rData[i*n+j]
+		}
+		for row := 0; row < m; row++ {
+			qData[row*k+i] = -qData[row*k+i]
+		}
+	}
+
+	return fromFloat64s(Shape{m, k}, qData, a.DType()), fromFloat64s(Shape{k, n}, rData, a.DType()), nil
+}
+
+// reflect applies the Householder reflection of v to the rows from start of
+// the columns from col on of a matrix with cols columns.
+func reflect(v, data []float64, start, cols, col int) {
+	vv := 0.0
+	for _, x := range v {
+		vv += x * x
+	}
+
+	for c := col; c < cols; c++ {
+		dot := 0.0
+		for i, x := range v {
+			dot += x * data[(start+i)*cols+c]
+		}
+
+		f := 2 * dot / vv
+		for i, x := range v {
+			data[(start+i)*cols+c] -= f * x
+		}
+	}
+}
+
+// jacobiRotation returns the cosine and sine of the rotation that zeros the
+// off diagonal value of the symmetric 2 by 2 matrix [[app, apq], [apq, aqq]].
+func jacobiRotation(app, aqq, apq float64) (float64, float64) {
+	theta := (aqq - app) / (2 * apq)
+
+	// tan of the angle, the smaller root of t^2 + 2 theta t - 1 = 0
+	var t float64
+	switch {
+	case theta == 0:
+		t = 1
+	case math.Abs(theta) > 1e150:
+		t = 1 / (2 * theta)
+	default:
+		t = math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+	}
+
+	c := 1 / math.Sqrt(t*t+1)
+	return c, t * c
+}
+
+// rotateColumns rotates columns p and q of a matrix with cols columns.
+func rotateColumns(data []float64, cols, p, q int, c, s float64) {
+	for i := p; i < len(data); i += cols {
+		xp, xq := data[i], data[i-p+q]
+		data[i] = c*xp - s*xq
+		data[i-p+q] = s*xp + c*xq
+	}
+}
+
+// SymmetricEigen returns the eigenvalues of a symmetric matrix in increasing
+// order as a vector, and the matching unit eigenvectors as the columns of a
+// matrix. Only the lower triangle of a is read, like Cholesky.
+func SymmetricEigen(a Tensor) (values, vectors Tensor, err error) {
+	data, n, err := squareMatrix("SymmetricEigen", a)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			data[i*n+j] = data[j*n+i]
+		}
+	}
+
+	v := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		v[i*n+i] = 1
+	}
+
+	// Off diagonal values below the rounding errors of the matrix count as zero
+	norm := 0.0
+	for _, x := range data {
+		norm = math.Hypot(norm, x)
+	}
+	floor := 1e-16 * norm
+
+	// Cyclic Jacobi, rotations zero the off diagonal values pair by pair until
+	// the matrix is diagonal
+	converged := false
+	for sweep := 0; sweep < jacobiMaxSweeps && !converged; sweep++ {
+		converged = true
+		for p := 0; p < n-1; p++ {
+			for q := p + 1; q < n; q++ {
+				apq := data[p*n+q]
+				app, aqq := data[p*n+p], data[q*n+q]
+				if math.Abs(apq) <= max(jacobiTolerance*math.Sqrt(math.Abs(app*aqq)), floor) {
+					continue
+				}
+				converged = false
+
+				c, s := jacobiRotation(app, aqq, apq)
+				rotateColumns(data, n, p, q, c, s)
+				for k := 0; k < n; k++ {
+					xp, xq := data[p*n+k], data[q*n+k]
+					data[p*n+k] = c*xp - s*xq
+					data[q*n+k] = s*xp + c*xq
+				}
+				rotateColumns(v, n, p, q, c, s)
+			}
+		}
+	}
+
+	if !converged {
+		return nil, nil, errors.New("eigendecomposition did not converge")
+	}
+
+	diag := make([]float64, n)
+	for i := range diag {
+		diag[i] = data[i*n+i]
+	}
+
+	order := sortedOrder(diag, false)
+	valData := make([]float64, n)
+	vecData := make([]float64, n*n)
+	for to, from := range order {
+		valData[to] = diag[from]
+		for i := 0; i < n; i++ {
+			vecData[i*n+to] = v[i*n+from]
+		}
+	}
+
+	return fromFloat64s(Shape{n}, valData, a.DType()), fromFloat64s(Shape{n, n}, vecData, a.DType()), nil
+}
+
+// SVD computes the thin singular value decomposition a = u diag(s) vt of an m
+// by n matrix. With k = min(m, n), u is (m, k) with orthonormal columns, s
+// holds the k singular values in decreasing order and vt is (k, n) with
+// orthonormal rows. Keeping the first singular values and vectors gives the
+// best low rank approximation of a.
+func SVD(a Tensor) (u, s, vt Tensor, err error) {
+	data, m, n, err := linalgMatrix("SVD", a)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Work on the transpose of wide matrices, a^T = u s vt gives a = vt^T s u^T
+	transposed := m < n
+	if transposed {
+		data = transposeValues(data, m, n)
+		m, n = n, m
+	}
+
+	v := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		v[i*n+i] = 1
+	}
+
+	// Columns that shrink down to the rounding errors of a can't be made
+	// orthogonal to the others, so they are left alone and count as singular
+	// values of 0
+	total := 0.0
+	for _, x := range data {
+		total += x * x
+	}
+	negligible := math.Pow(float64(m)*1e-15, 2) * total
+
+	// One sided Jacobi, rotations make the columns of a orthogonal, and then
+	// they are u scaled by the singular values
+	converged := false
+	for sweep := 0; sweep < jacobiMaxSweeps && !converged; sweep++ {
+		converged = true
+		for p := 0; p < n-1; p++ {
+			for q := p + 1; q < n; q++ {
+				var alpha, beta, gamma float64
+				for i := 0; i < m; i++ {
+					xp, xq := data[i*n+p], data[i*n+q]
+					alpha += xp * xp
+					beta += xq * xq
+					gamma += xp * xq
+				}
+				if alpha <= negligible || beta <= negligible || math.Abs(gamma) <= jacobiTolerance*math.Sqrt(alpha*beta) {
+					continue
+				}
+				converged = false
+
+				c, s := jacobiRotation(alpha, beta, gamma)
+				rotateColumns(data, n, p, q, c, s)
+				rotateColumns(v, n, p, q, c, s)
+			}
+		}
+	}
+
+	if !converged {
+		return nil, nil, nil, errors.New("SVD did not converge")
+	}
+
+	sigma := make([]float64, n)
+	for j := 0; j < n; j++ {
+		for i := 0; i < m; i++ {
+			sigma[j] = math.Hypot(sigma[j], data[i*n+j])
+		}
+	}
+
+	order := sortedOrder(sigma, true)
+	sData := make([]float64, n)
+	uData := make([]float64, m*n)
+	vData := make([]float64, n*n)
+	for to, from := range order {
+		sData[to] = sigma[from]
+		for i := 0; i < m; i++ {
+			uData[i*n+to] = data[i*n+from]
+		}
+		for i := 0; i < n; i++ {
+			vData[i*n+to] = v[i*n+from]
+		}
+	}
+
+	// Columns of negligible singular values are mostly rounding errors, they
+	// are replaced to keep the columns of u orthonormal
+	rank := n
+	for j := 0; j < n; j++ {
+		if sData[j]*sData[j] <= negligible {
+			rank = j
+			break
+		}
+		for i := 0; i < m; i++ {
+			uData[i*n+j] /= sData[j]
+		}
+	}
+	completeColumns(uData, m, n, rank)
+
+	// u is (m, n) and v is (n, n) here, so vt is v transposed
+	if transposed {
+		u = fromFloat64s(Shape{n, n}, vData, a.DType())
+		vt = fromFloat64s(Shape{n, m}, transposeValues(uData, m, n), a.DType())
+	} else {
+		u = fromFloat64s(Shape{m, n}, uData, a.DType())
+		vt = fromFloat64s(Shape{n, n}, transposeValues(vData, n, n), a.DType())
+	}
+	return u, fromFloat64s(Shape{n}, sData, a.DType()), vt, nil
+}
+
+// completeColumns replaces the columns from rank on of an m by n matrix with
+// orthonormal columns that are also orthogonal to the ones before.
+func completeColumns(data []float64, m, n, rank int) {
+	for j := rank; j < n; j++ {
+		var best []float64
+		bestNorm := 0.0
+
+		// The unit vector that sticks out most from the columns so far
+		for e := 0; e < m; e++ {
+			col := make([]float64, m)
+			col[e] = 1
+			for k := 0; k < j; k++ {
+				dot := data[e*n+k]
+				for i := 0; i < m; i++ {
+					col[i] -= dot * data[i*n+k]
+				}
+			}
+
+			norm := 0.0
+			for _, x := range col {
+				norm = math.Hypot(norm, x)
+			}
+			if norm > bestNorm {
+				best, bestNorm = col, norm
+			}
+		}
+
+		for i := 0; i < m; i++ {
+			data[i*n+j] = best[i] / bestNorm
+		}
+	}
+}
+
+// sortedOrder returns the indices of values in increasing order, or
+// decreasing when descending is set.
+func sortedOrder(values []float64, descending bool) []int {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if descending {
+			return values[order[i]] > values[order[j]]
+		}
+		return values[order[i]] < values[order[j]]
+	})
+	return order
+}
+
+func transposeValues(data []float64, rows, cols int) []float64 {
+	resData := make([]float64, len(data))
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			resData[j*rows+i] = data[i*cols+j]
+		}
+	}
+	return resData
+}
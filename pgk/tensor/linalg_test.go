@@ -0,0 +1,315 @@
+package tensor
+
+import (
+	"math"
+	"testing"
+)
+
+const linalgTolerance = 1e-9
+
+// matrixFrom creates a float64 matrix from its rows.
+func matrixFrom(rows ...[]float64) Tensor {
+	var values []float64
+	for _, row := range rows {
+		values = append(values, row...)
+	}
+	ten, _ := TensorFrom(Shape{len(rows), len(rows[0])}, values)
+	return ten
+}
+
+// product multiplies the (m, k) values a with the (k, n) values b.
+func product(a []float64, m, k int, b []float64, n int) []float64 {
+	res := make([]float64, m*n)
+	for i := range m {
+		for j := range n {
+			for p := range k {
+				res[i*n+j] += a[i*k+p] * b[p*n+j]
+			}
+		}
+	}
+	return res
+}
+
+func assertClose(t *testing.T, name string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: %d values, want %d", name, len(got), len(want))
+	}
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > linalgTolerance {
+			t.Fatalf("%s: value %d is %v, want %v", name, i, got[i], want[i])
+		}
+	}
+}
+
+// assertOrthonormalColumns checks q^T q = I for the (m, n) values q.
+func assertOrthonormalColumns(t *testing.T, name string, q []float64, m, n int) {
+	t.Helper()
+	identity := make([]float64, n*n)
+	for i := range n {
+		identity[i*n+i] = 1
+	}
+	assertClose(t, name, product(transposeValues(q, m, n), n, m, q, n), identity)
+}
+
+var (
+	wellConditioned = matrixFrom(
+		[]float64{4, -2, 1},
+		[]float64{3, 6, -4},
+		[]float64{2, 1, 8},
+	)
+	singular = matrixFrom(
+		[]float64{1, 2, 3},
+		[]float64{2, 4, 6},
+		[]float64{1, 0, 1},
+	)
+	spd = matrixFrom(
+		[]float64{4, 12, -16},
+		[]float64{12, 37, -43},
+		[]float64{-16, -43, 98},
+	)
+)
+
+func TestSolve(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Tensor
+	}{
+		{"vector", wellConditioned, must(TensorFrom(Shape{3}, []float64{1, 2, 3}))},
+		{"matrix", wellConditioned, matrixFrom([]float64{1, 0}, []float64{0, 1}, []float64{2, -1})},
+		{"needs pivoting", matrixFrom([]float64{0, 1}, []float64{1, 0}), must(TensorFrom(Shape{2}, []float64{3, 4}))},
+	}
+
+	for _, test := range tests {
+		x, err := Solve(test.a, test.b)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !x.Shape().DeepEq(test.b.Shape()) {
+			t.Fatalf("%s: x has shape %v, want %v", test.name, x.Shape(), test.b.Shape())
+		}
+
+		n, k := test.a.Shape().Rows(), test.b.Size()/test.a.Shape().Rows()
+		assertClose(t, test.name, product(Values[float64](test.a), n, n, Values[float64](x), k), Values[float64](test.b))
+	}
+
+	errTests := []struct {
+		name string
+		a, b Tensor
+	}{
+		{"singular", singular, must(TensorFrom(Shape{3}, []float64{1, 2, 3}))},
+		{"zero", matrixFrom([]float64{0, 0}, []float64{0, 0}), must(TensorFrom(Shape{2}, []float64{1, 1}))},
+		{"not square", matrixFrom([]float64{1, 2, 3}, []float64{4, 5, 6}), must(TensorFrom(Shape{2}, []float64{1, 1}))},
+		{"rows of b", wellConditioned, must(TensorFrom(Shape{2}, []float64{1, 1}))},
+	}
+
+	for _, test := range errTests {
+		if _, err := Solve(test.a, test.b); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+func TestInverse(t *testing.T) {
+	for _, a := range []Tensor{wellConditioned, spd, matrixFrom([]float64{0, 2}, []float64{3, 0})} {
+		inverse, err := Inverse(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		n := a.Shape().Rows()
+		identity := make([]float64, n*n)
+		for i := range n {
+			identity[i*n+i] = 1
+		}
+		assertClose(t, "a inverse", product(Values[float64](a), n, n, Values[float64](inverse), n), identity)
+	}
+
+	if _, err := Inverse(singular); err == nil {
+		t.Errorf("singular matrix has an inverse")
+	}
+}
+
+func TestDeterminant(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        Tensor
+		expected float64
+	}{
+		{"well conditioned", wellConditioned, 4*(48+4) + 2*(24+8) + (3 - 12)},
+		{"spd", spd, 36},
+		{"permutation", matrixFrom([]float64{0, 1}, []float64{1, 0}), -1},
+		{"singular", singular, 0},
+		{"single value", matrixFrom([]float64{-3}), -3},
+	}
+
+	for _, test := range tests {
+		det, err := Determinant(test.a)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if math.Abs(det-test.expected) > linalgTolerance*math.Max(1, math.Abs(test.expected)) {
+			t.Errorf("%s: determinant %v, want %v", test.name, det, test.expected)
+		}
+	}
+}
+
+func TestCholesky(t *testing.T) {
+	l, err := Cholesky(spd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := Values[float64](l)
+	for i := range 3 {
+		for j := i + 1; j < 3; j++ {
+			if values[i*3+j] != 0 {
+				t.Fatalf("L has %v above the diagonal at (%d, %d)", values[i*3+j], i, j)
+			}
+		}
+	}
+	assertClose(t, "L L^T", product(values, 3, 3, transposeValues(values, 3, 3), 3), Values[float64](spd))
+
+	errTests := []struct {
+		name string
+		a    Tensor
+	}{
+		{"indefinite", matrixFrom([]float64{1, 2}, []float64{2, 1})},
+		{"negative definite", matrixFrom([]float64{-4, 0}, []float64{0, -1})},
+		{"semidefinite", matrixFrom([]float64{1, 1}, []float64{1, 1})},
+		{"singular", singular},
+		{"not square", matrixFrom([]float64{1, 0, 0}, []float64{0, 1, 0})},
+	}
+
+	for _, test := range errTests {
+		if _, err := Cholesky(test.a); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+// factorTests are matrices of every shape the factorizations handle.
+var factorTests = []struct {
+	name string
+	a    Tensor
+}{
+	{"square", wellConditioned},
+	{"singular", singular},
+	{"tall", matrixFrom([]float64{1, 2}, []float64{3, 4}, []float64{5, 6}, []float64{7, 8})},
+	{"wide", matrixFrom([]float64{1, 0, 2, -1}, []float64{3, 1, 0, 2})},
+	{"rank one", matrixFrom([]float64{1, 2, 3}, []float64{2, 4, 6})},
+	{"zero", matrixFrom([]float64{0, 0}, []float64{0, 0})},
+	{"rank two", must(TensorFrom(Shape{6, 4}, product(
+		[]float64{1, 2, -1, 0.5, 3, 1, 0, -2, 2, 2, -1, 4}, 6, 2,
+		[]float64{1, 0, 2, -3, 0.5, 1, -1, 2}, 4,
+	)))},
+}
+
+func TestQR(t *testing.T) {
+	for _, test := range factorTests {
+		q, r, err := QR(test.a)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		m, n := test.a.Shape().Rows(), test.a.Shape().Cols()
+		k := min(m, n)
+		if !q.Shape().DeepEq(Shape{m, k}) || !r.Shape().DeepEq(Shape{k, n}) {
+			t.Fatalf("%s: q %v and r %v, want (%d, %d) and (%d, %d)", test.name, q.Shape(), r.Shape(), m, k, k, n)
+		}
+
+		qValues, rValues := Values[float64](q), Values[float64](r)
+		for i := range k {
+			if rValues[i*n+i] < 0 {
+				t.Errorf("%s: r has %v on the diagonal", test.name, rValues[i*n+i])
+			}
+			for j := range min(i, n) {
+				if math.Abs(rValues[i*n+j]) > linalgTolerance {
+					t.Fatalf("%s: r has %v below the diagonal at (%d, %d)", test.name, rValues[i*n+j], i, j)
+				}
+			}
+		}
+
+		assertOrthonormalColumns(t, test.name+": q^T q", qValues, m, k)
+		assertClose(t, test.name+": q r", product(qValues, m, k, rValues, n), Values[float64](test.a))
+	}
+}
+
+func TestSVD(t *testing.T) {
+	for _, test := range factorTests {
+		u, s, vt, err := SVD(test.a)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		m, n := test.a.Shape().Rows(), test.a.Shape().Cols()
+		k := min(m, n)
+		if !u.Shape().DeepEq(Shape{m, k}) || !s.Shape().DeepEq(Shape{k}) || !vt.Shape().DeepEq(Shape{k, n}) {
+			t.Fatalf("%s: u %v, s %v and vt %v", test.name, u.Shape(), s.Shape(), vt.Shape())
+		}
+
+		sValues := Values[float64](s)
+		for i, val := range sValues {
+			if val < 0 || (i > 0 && val > sValues[i-1]) {
+				t.Fatalf("%s: singular values %v are not nonnegative and decreasing", test.name, sValues)
+			}
+		}
+
+		uValues, vtValues := Values[float64](u), Values[float64](vt)
+		assertOrthonormalColumns(t, test.name+": u^T u", uValues, m, k)
+		assertOrthonormalColumns(t, test.name+": v^T v", transposeValues(vtValues, k, n), n, k)
+
+		scaled := make([]float64, m*k)
+		for i := range m {
+			for j := range k {
+				scaled[i*k+j] = uValues[i*k+j] * sValues[j]
+			}
+		}
+		assertClose(t, test.name+": u s vt", product(scaled, m, k, vtValues, n), Values[float64](test.a))
+	}
+}
+
+func TestSymmetricEigen(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Tensor
+	}{
+		{"spd", spd},
+		{"indefinite", matrixFrom([]float64{1, 2}, []float64{2, 1})},
+		{"repeated", matrixFrom([]float64{2, 0, 0}, []float64{0, 2, 0}, []float64{0, 0, 5})},
+		{"singular", matrixFrom([]float64{1, 1}, []float64{1, 1})},
+	}
+
+	for _, test := range tests {
+		values, vectors, err := SymmetricEigen(test.a)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		n := test.a.Shape().Rows()
+		eigenvalues, eigenvectors := Values[float64](values), Values[float64](vectors)
+		for i := 1; i < n; i++ {
+			if eigenvalues[i] < eigenvalues[i-1] {
+				t.Fatalf("%s: eigenvalues %v are not increasing", test.name, eigenvalues)
+			}
+		}
+
+		assertOrthonormalColumns(t, test.name+": v^T v", eigenvectors, n, n)
+
+		// a v = v diag(values)
+		scaled := make([]float64, n*n)
+		for i := range n {
+			for j := range n {
+				scaled[i*n+j] = eigenvectors[i*n+j] * eigenvalues[j]
+			}
+		}
+		assertClose(t, test.name+": a v", product(Values[float64](test.a), n, n, eigenvectors, n), scaled)
+	}
+}
+
+func must(ten Tensor, err error) Tensor {
+	if err != nil {
+		panic(err)
+	}
+	return ten
+}
@@ -0,0 +1,278 @@
+package tensor
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Block sizes of the tiled matrix multiplication. For every tile of the
+// output a block of matMulBlockK rows of the right operand is packed into a
+// buffer small enough to stay in cache while the rows of the left operand are
+// multiplied with it.
+const (
+	matMulBlockM = 64
+	matMulBlockN = 256
+	matMulBlockK = 128
+)
+
+// Products with fewer multiply-adds than this are computed on the calling
+// goroutine, the workers would cost more than they save.
+const matMulParallelThreshold = 1 << 16
+
+var matMulWorkers = 0
+
+// SetMatMulWorkers sets how many goroutines MatMul uses, 0 uses GOMAXPROCS.
+func SetMatMulWorkers(workers int) error {
+	if workers < 0 {
+		return errors.New("number of workers cannot be negative")
+	}
+
+	matMulWorkers = workers
+	return nil
+}
+
+// MatMulWorkers returns how many goroutines MatMul uses.
+func MatMulWorkers() int {
+	if matMulWorkers > 0 {
+		return matMulWorkers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// matrix describes a matrix in the data of a tensor by the strides of its
+// rows and columns, which lets transposed views be read without copying.
+type matrix[E Float] struct {
+	data       []E
+	rows, cols int
+	rowStride  int
+	colStride  int
+}
+
+func matrixOf[E Float](t *tensor[E]) matrix[E] {
+	strides := t.Strides()
+	mat := matrix[E]{data: t.Data, rows: t.TShape.Rows(), cols: t.TShape.Cols()}
+	if len(strides) > 0 {
+		mat.colStride = strides[len(strides)-1]
+	}
+	if len(strides) > 1 {
+		mat.rowStride = strides[len(strides)-2]
+	}
+	return mat
+}
+
+// MatMul multiplies two matrices. Either operand can be a transposed view,
+// so a.Transpose(false).MatMul(b) doesn't copy a.
+//
+// Operands with more than two dimensions are stacks of matrices in their last
+// two dimensions. The leading dimensions, like batches, heads or channels,
+// broadcast against each other, so [B, H, m, n] times [n, p] or [1, H, n, p]
+// gives [B, H, m, p].
+func (t *tensor[E]) MatMul(other Tensor) (Tensor, error) {
+	if other == nil {
+		return nil, errors.New("Tensor cannot be nil")
+	}
+
+	if t.Shape().Cols() != other.Shape().Rows() {
+		// The right operand needs as many rows as the left one has columns
+		expected := other.Shape().Clone()
+		if expected.Dims() < 2 {
+			expected = append(Shape{1}, expected...)
+		}
+		expected[expected.Dims()-2] = t.Shape().Cols()
+		return nil, shapeMismatch("MatMul", expected, other.Shape())
+	}
+
+	if !singleMatrix(t.Shape()) || !singleMatrix(other.Shape()) {
+		return t.batchedMatMul(asType[E](other))
+	}
+
+	resTen := zeros[E]([]int{t.Shape().Rows(), other.Shape().Cols()})
+	gemm(matrixOf(t), matrixOf(asType[E](other)), resTen.Data)
+
+	return resTen, nil
+}
+
+// batchedMatMul multiplies the matrices of t and other pairwise over their
+// broadcast leading dimensions.
+func (t *tensor[E]) batchedMatMul(other *tensor[E]) (Tensor, error) {
+	leadA, stridesA := leadingDims(t)
+	leadB, stridesB := leadingDims(other)
+
+	batchShape, err := BroadcastShapes(leadA, leadB)
+	if err != nil {
+		return nil, fmt.Errorf("cannot multiply %v and %v: %w", t.Shape(), other.Shape(), err)
+	}
+
+	rows, cols := t.Shape().Rows(), other.Shape().Cols()
+	resShape := append(batchShape.Clone(), rows, cols)
+	resTen := zeros[E](resShape)
+
+	a, b := matrixOf(t), matrixOf(other)
+	strides := [][]int{
+		broadcastStrides(leadA, stridesA, batchShape),
+		broadcastStrides(leadB, stridesB, batchShape),
+	}
+
+	size := rows * cols
+	broadcastLoop(batchShape, strides, func(i int, offsets []int) {
+		a.data = t.Data[offsets[0]:]
+		b.data = other.Data[offsets[1]:]
+		gemm(a, b, resTen.Data[i*size:(i+1)*size])
+	})
+
+	return resTen, nil
+}
+
+// singleMatrix reports if shape holds one matrix, all its leading dimensions
+// are 1.
+func singleMatrix(shape Shape) bool {
+	for _, dim := range shape[:max(len(shape)-2, 0)] {
+		if dim != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// leadingDims returns the dimensions of t in front of its matrices and their
+// strides.
+func leadingDims[E Float](t *tensor[E]) (Shape, []int) {
+	lead := max(t.Dims()-2, 0)
+	return t.TShape[:lead], t.Strides()[:lead]
+}
+
+// gemm adds a x b to c, a contiguous rows of a by cols of b matrix. The output
+// is cut into tiles that a bounded pool of workers takes turns computing.
+func gemm[E Float](a, b matrix[E], c []E) {
+	m, n, p := a.rows, a.cols, b.cols
+
+	type tile struct{ i, j int }
+	var tiles []tile
+	for i := 0; i < m; i += matMulBlockM {
+		for j := 0; j < p; j += matMulBlockN {
+			tiles = append(tiles, tile{i, j})
+		}
+	}
+
+	workers := min(MatMulWorkers(), len(tiles))
+	if m*n*p < matMulParallelThreshold {
+		workers = 1
+	}
+
+	// The packed blocks of b are scratch space, so they go back to the pool
+	buffers := buffersOf[E]()
+	if workers <= 1 {
+		packed, pooled := buffers.get(matMulBlockK * matMulBlockN)
+		for _, tl := range tiles {
+			gemmTile(a, b, c, tl.i, tl.j, packed)
+		}
+		if pooled != nil {
+			buffers.put(pooled, packed)
+		}
+		return
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			packed, pooled := buffers.get(matMulBlockK * matMulBlockN)
+			if pooled != nil {
+				defer buffers.put(pooled, packed)
+			}
+			for {
+				idx := int(next.Add(1)) - 1
+				if idx >= len(tiles) {
+					return
+				}
+				gemmTile(a, b, c, tiles[idx].i, tiles[idx].j, packed)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// gemmTile computes the tile of c starting at row i0 and column j0. The sums
+// still run over k in order, the same as the naive triple loop.
+func gemmTile[E Float](a, b matrix[E], c []E, i0, j0 int, packed []E) {
+	n, p := a.cols, b.cols
+	iEnd := min(i0+matMulBlockM, a.rows)
+	jEnd := min(j0+matMulBlockN, p)
+	width := jEnd - j0
+
+	for k0 := 0; k0 < n; k0 += matMulBlockK {
+		kEnd := min(k0+matMulBlockK, n)
+
+		// Pack the block of b into contiguous rows
+		for k := k0; k < kEnd; k++ {
+			row := packed[(k-k0)*width : (k-k0+1)*width]
+			offset := k*b.rowStride + j0*b.colStride
+			if b.colStride == 1 {
+				copy(row, b.data[offset:offset+width])
+				continue
+			}
+			for j := range row {
+				row[j] = b.data[offset]
+				offset += b.colStride
+			}
+		}
+
+		// Four rows at a time share the loads of the packed block
+		i := i0
+		for ; i+4 <= iEnd; i += 4 {
+			c0 := c[i*p+j0 : i*p+jEnd]
+			c1 := c[(i+1)*p+j0 : (i+1)*p+jEnd]
+			c2 := c[(i+2)*p+j0 : (i+2)*p+jEnd]
+			c3 := c[(i+3)*p+j0 : (i+3)*p+jEnd]
+			offset := i*a.rowStride + k0*a.colStride
+			for k := k0; k < kEnd; k++ {
+				a0 := a.data[offset]
+				a1 := a.data[offset+a.rowStride]
+				a2 := a.data[offset+2*a.rowStride]
+				a3 := a.data[offset+3*a.rowStride]
+				offset += a.colStride
+
+				bRow := packed[(k-k0)*width : (k-k0+1)*width]
+				c0, c1, c2, c3 := c0[:len(bRow)], c1[:len(bRow)], c2[:len(bRow)], c3[:len(bRow)]
+				for j, bkj := range bRow {
+					c0[j] += a0 * bkj
+					c1[j] += a1 * bkj
+					c2[j] += a2 * bkj
+					c3[j] += a3 * bkj
+				}
+			}
+		}
+
+		for ; i < iEnd; i++ {
+			cRow := c[i*p+j0 : i*p+jEnd]
+			offset := i*a.rowStride + k0*a.colStride
+			for k := k0; k < kEnd; k++ {
+				aik := a.data[offset]
+				offset += a.colStride
+
+				bRow := packed[(k-k0)*width : (k-k0+1)*width]
+				axpy(aik, bRow, cRow)
+			}
+		}
+	}
+}
+
+// axpy adds alpha * x to y, unrolled by four.
+func axpy[E Float](alpha E, x, y []E) {
+	y = y[:len(x)]
+	j := 0
+	for ; j+4 <= len(x); j += 4 {
+		y[j] += alpha * x[j]
+		y[j+1] += alpha * x[j+1]
+		y[j+2] += alpha * x[j+2]
+		y[j+3] += alpha * x[j+3]
+	}
+	for ; j < len(x); j++ {
+		y[j] += alpha * x[j]
+	}
+}
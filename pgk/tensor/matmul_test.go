@@ -0,0 +1,233 @@
+package tensor
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+)
+
+// rowPerGoroutineMatMul is the matrix multiplication MatMul used before it was
+// blocked, one goroutine per output row over a naive triple loop.
+func rowPerGoroutineMatMul(x, y Tensor) Tensor {
+	m, n, p := x.Shape().Rows(), x.Shape().Cols(), y.Shape().Cols()
+	xData := x.DataCopy()
+	yData := y.Transpose(false).DataCopy()
+	resData := make([]float64, m*p)
+
+	var wg sync.WaitGroup
+	for i := range m {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := range p {
+				var sum float64
+				for k := range n {
+					sum += xData[i*n+k] * yData[j*n+k]
+				}
+				resData[i*p+j] = sum
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	resTen, _ := TensorFrom([]int{m, p}, resData)
+	return resTen
+}
+
+func TestMatMulMatchesNaive(t *testing.T) {
+	// Sizes around the block sizes, so partial blocks are covered
+	for _, size := range [][3]int{{1, 1, 1}, {3, 5, 7}, {65, 130, 33}, {200, 17, 300}} {
+		x, _ := RandTensor([]int{size[0], size[1]}, -1, 1)
+		y, _ := RandTensor([]int{size[1], size[2]}, -1, 1)
+
+		expected := Values[float64](rowPerGoroutineMatMul(x, y))
+		for _, transposed := range []bool{false, true} {
+			other := y
+			if transposed {
+				// Same values through a strided view of the transpose
+				yT, _ := TensorFrom([]int{size[2], size[1]}, y.Transpose(false).DataCopy())
+				other = yT.Transpose(false)
+			}
+
+			res, err := x.MatMul(other)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, val := range Values[float64](res) {
+				if math.Abs(val-expected[i]) > 1e-9 {
+					t.Fatalf("%v, transposed %v: value %d is %v, want %v", size, transposed, i, val, expected[i])
+				}
+			}
+		}
+	}
+}
+
+// naiveBatchedMatMul multiplies the matrices of x and y over their broadcast
+// leading dimensions with a triple loop, reading both through Values.
+func naiveBatchedMatMul(x, y Tensor) (Shape, []float64) {
+	xShape, yShape := x.Shape(), y.Shape()
+	xLead, yLead := xShape[:xShape.Dims()-2], yShape[:yShape.Dims()-2]
+	batchShape, _ := BroadcastShapes(xLead, yLead)
+
+	m, n, p := xShape.Rows(), xShape.Cols(), yShape.Cols()
+	xData, yData := Values[float64](x), Values[float64](y)
+
+	// The batch of an operand a batch of the output reads, with dimensions of
+	// size 1 repeated
+	batchOf := func(lead Shape, out int) int {
+		batch, stride := 0, 1
+		for i := len(batchShape) - 1; i >= 0; i-- {
+			coord := out % batchShape[i]
+			out /= batchShape[i]
+
+			j := i - (len(batchShape) - len(lead))
+			if j < 0 {
+				continue
+			}
+			if lead[j] != 1 {
+				batch += coord * stride
+			}
+			stride *= lead[j]
+		}
+		return batch
+	}
+
+	resData := make([]float64, batchShape.TotalSize()*m*p)
+	for b := range batchShape.TotalSize() {
+		xm := xData[batchOf(xLead, b)*m*n:]
+		ym := yData[batchOf(yLead, b)*n*p:]
+		for i := range m {
+			for j := range p {
+				var sum float64
+				for k := range n {
+					sum += xm[i*n+k] * ym[k*p+j]
+				}
+				resData[(b*m+i)*p+j] = sum
+			}
+		}
+	}
+
+	return append(batchShape.Clone(), m, p), resData
+}
+
+func TestBatchedMatMul(t *testing.T) {
+	rng := NewRNG(1)
+	rand := func(shape ...int) Tensor {
+		ten, _ := rng.RandTensor(shape, -1, 1)
+		return ten
+	}
+
+	// Views whose matrices aren't contiguous or whose batches are strided
+	transposed := func(shape ...int) Tensor {
+		return rand(shape...).Transpose(false)
+	}
+	permuted := func(shape ...int) Tensor {
+		ten, _ := rand(shape...).Permute(1, 0, 2)
+		return ten
+	}
+
+	tests := []struct {
+		name string
+		x, y Tensor
+		want Shape
+	}{
+		{"3D by 3D", rand(4, 3, 5), rand(4, 5, 2), Shape{4, 3, 2}},
+		{"broadcast leading dims", rand(2, 1, 3, 4), rand(3, 4, 5), Shape{2, 3, 3, 5}},
+		{"3D by 2D", rand(4, 3, 5), rand(5, 2), Shape{4, 3, 2}},
+		{"2D by 3D", rand(3, 5), rand(4, 5, 2), Shape{4, 3, 2}},
+		{"transposed batches", transposed(4, 5, 3), transposed(4, 2, 5), Shape{4, 3, 2}},
+		{"strided batches", permuted(3, 4, 5), permuted(5, 4, 2), Shape{4, 3, 2}},
+		{"large broadcast", rand(3, 1, 70, 40), rand(2, 40, 90), Shape{3, 2, 70, 90}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.x.MatMul(tc.y)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			shape, expected := naiveBatchedMatMul(tc.x, tc.y)
+			if !res.Shape().DeepEq(tc.want) || !shape.DeepEq(tc.want) {
+				t.Fatalf("shape %v, reference %v, want %v", res.Shape(), shape, tc.want)
+			}
+
+			for i, val := range Values[float64](res) {
+				if math.Abs(val-expected[i]) > 1e-9 {
+					t.Fatalf("value %d is %v, want %v", i, val, expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBatchedMatMulErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y Shape
+	}{
+		{"inner sizes", Shape{2, 3, 4}, Shape{2, 5, 3}},
+		{"leading dims", Shape{2, 3, 4}, Shape{3, 4, 5}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Zeros(tc.x, Float64).MatMul(Zeros(tc.y, Float64)); err == nil {
+				t.Errorf("%v times %v: expected an error", tc.x, tc.y)
+			}
+		})
+	}
+}
+
+// TestTapeMatMulBroadcastGradient checks the gradients of MatMul with
+// broadcast leading dimensions, which sumToShape reduces back to the shapes
+// of the operands.
+func TestTapeMatMulBroadcastGradient(t *testing.T) {
+	rng := NewRNG(2)
+	xValue, _ := rng.RandTensor(Shape{2, 1, 3, 4}, -1, 1)
+	yValue, _ := rng.RandTensor(Shape{3, 4, 5}, -1, 1)
+	weights, _ := rng.RandTensor(Shape{2, 3, 3, 5}, -1, 1)
+
+	objective := func(tp *Tape, vars []*Variable) (*Variable, error) {
+		product, err := tp.MatMul(vars[0], vars[1])
+		if err != nil {
+			return nil, err
+		}
+
+		weighted, err := tp.Multiply(product, tp.Constant(weights))
+		if err != nil {
+			return nil, err
+		}
+		return tp.Sum(weighted), nil
+	}
+
+	checkGradients(t, objective, xValue, yValue)
+}
+
+func BenchmarkMatMul(b *testing.B) {
+	for _, size := range []int{128, 512, 1024} {
+		x, _ := RandTensor([]int{size, size}, -1, 1)
+		y, _ := RandTensor([]int{size, size}, -1, 1)
+		yT := y.Transpose(false)
+
+		b.Run(fmt.Sprintf("%d/row_per_goroutine", size), func(b *testing.B) {
+			for range b.N {
+				rowPerGoroutineMatMul(x, y)
+			}
+		})
+
+		b.Run(fmt.Sprintf("%d/blocked", size), func(b *testing.B) {
+			for range b.N {
+				x.MatMul(y)
+			}
+		})
+
+		b.Run(fmt.Sprintf("%d/blocked_transposed", size), func(b *testing.B) {
+			for range b.N {
+				x.MatMul(yT)
+			}
+		})
+	}
+}
@@ -0,0 +1,483 @@
+package tensor
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The .npy format is a magic string, a version, the length of the header and
+// a header holding a Python dict with the dtype, the memory order and the
+// shape, followed by the raw values. An .npz file is a zip archive of .npy
+// files.
+
+const npyMagic = "\x93NUMPY"
+
+var (
+	npyDescrRegexp   = regexp.MustCompile(`['"]descr['"]\s*:\s*['"]([^'"]*)['"]`)
+	npyFortranRegexp = regexp.MustCompile(`['"]fortran_order['"]\s*:\s*(True|False)`)
+	npyShapeRegexp   = regexp.MustCompile(`['"]shape['"]\s*:\s*\(([^)]*)\)`)
+)
+
+// npyType describes how the values of an array are stored.
+type npyType struct {
+	kind  byte // f for floats, i for signed and u for unsigned ints, b for bools
+	size  int
+	order binary.ByteOrder
+}
+
+func parseNpyDescr(descr string) (npyType, error) {
+	if len(descr) < 3 {
+		return npyType{}, fmt.Errorf("unsupported npy dtype %q", descr)
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	switch descr[0] {
+	case '<', '|', '=':
+	case '>':
+		order = binary.BigEndian
+	default:
+		return npyType{}, fmt.Errorf("unsupported npy dtype %q", descr)
+	}
+
+	size, err := strconv.Atoi(descr[2:])
+	if err != nil {
+		return npyType{}, fmt.Errorf("unsupported npy dtype %q", descr)
+	}
+
+	dtype := npyType{kind: descr[1], size: size, order: order}
+	switch {
+	case dtype.kind == 'f' && (size == 4 || size == 8):
+	case (dtype.kind == 'i' || dtype.kind == 'u') && (size == 1 || size == 2 || size == 4 || size == 8):
+	case dtype.kind == 'b' && size == 1:
+	default:
+		return npyType{}, fmt.Errorf("unsupported npy dtype %q", descr)
+	}
+
+	return dtype, nil
+}
+
+// value reads the value at the start of b as a float64.
+func (n npyType) value(b []byte) float64 {
+	switch n.kind {
+	case 'f':
+		if n.size == 4 {
+			return float64(math.Float32frombits(n.order.Uint32(b)))
+		}
+		return math.Float64frombits(n.order.Uint64(b))
+
+	case 'i':
+		switch n.size {
+		case 1:
+			return float64(int8(b[0]))
+		case 2:
+			return float64(int16(n.order.Uint16(b)))
+		case 4:
+			return float64(int32(n.order.Uint32(b)))
+		default:
+			return float64(int64(n.order.Uint64(b)))
+		}
+
+	default:
+		switch n.size {
+		case 1:
+			return float64(b[0])
+		case 2:
+			return float64(n.order.Uint16(b))
+		case 4:
+			return float64(n.order.Uint32(b))
+		default:
+			return float64(n.order.Uint64(b))
+		}
+	}
+}
+
+// ReadNpy reads a tensor in the .npy format. Arrays of float32 become Float32
+// tensors, float64 become Float64 tensors and ints and bools are converted to
+// the default dtype. A scalar array gives a shape of (1). The data is read as
+// it arrives, so a header claiming more data than r holds gives an error
+// instead of allocating all of it.
+func ReadNpy(r io.Reader) (Tensor, error) {
+	prefix := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, fmt.Errorf("reading npy header: %w", err)
+	}
+
+	if string(prefix[:len(npyMagic)]) != npyMagic {
+		return nil, errors.New("not an npy file")
+	}
+
+	var headerLen int
+	switch major := prefix[len(npyMagic)]; major {
+	case 1:
+		var size [2]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil, fmt.Errorf("reading npy header: %w", err)
+		}
+		headerLen = int(binary.LittleEndian.Uint16(size[:]))
+
+	case 2, 3:
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil, fmt.Errorf("reading npy header: %w", err)
+		}
+		headerLen = int(binary.LittleEndian.Uint32(size[:]))
+
+	default:
+		return nil, fmt.Errorf("unsupported npy version %d", major)
+	}
+
+	header, err := readNpyBytes(r, headerLen, "header")
+	if err != nil {
+		return nil, err
+	}
+
+	dtype, fortranOrder, shape, err := parseNpyHeader(string(header))
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := readNpyBytes(r, shape.TotalSize()*dtype.size, "data")
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float64, shape.TotalSize())
+	for i := range values {
+		values[i] = dtype.value(raw[i*dtype.size:])
+	}
+
+	resDType := defaultDType
+	if dtype.kind == 'f' {
+		resDType = Float64
+		if dtype.size == 4 {
+			resDType = Float32
+		}
+	}
+
+	if !fortranOrder {
+		return fromFloat64s(shape, values, resDType), nil
+	}
+
+	// Column major values are row major for the reversed shape
+	reversed, _ := shape.Permute()
+	resTen, err := fromFloat64s(reversed, values, resDType).Permute()
+	if err != nil {
+		return nil, err
+	}
+	return resTen.AsType(resDType), nil
+}
+
+// readNpyBytes reads the next n bytes of r. The buffer grows as the bytes
+// arrive instead of being allocated up front, so a header claiming more than
+// the file holds fails at the end of the file rather than running out of
+// memory.
+func readNpyBytes(r io.Reader, n int, what string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(n)))
+	if err != nil {
+		return nil, fmt.Errorf("reading npy %s: %w", what, err)
+	}
+
+	if len(data) < n {
+		return nil, fmt.Errorf("reading npy %s: %w, %d of %d bytes", what, io.ErrUnexpectedEOF, len(data), n)
+	}
+	return data, nil
+}
+
+func parseNpyHeader(header string) (npyType, bool, Shape, error) {
+	descr := npyDescrRegexp.FindStringSubmatch(header)
+	fortran := npyFortranRegexp.FindStringSubmatch(header)
+	dims := npyShapeRegexp.FindStringSubmatch(header)
+	if descr == nil || fortran == nil || dims == nil {
+		return npyType{}, false, nil, fmt.Errorf("invalid npy header %q", header)
+	}
+
+	dtype, err := parseNpyDescr(descr[1])
+	if err != nil {
+		return npyType{}, false, nil, err
+	}
+
+	// The size in bytes of the data has to fit in an int
+	shape := Shape{}
+	total := dtype.size
+	for _, dim := range strings.Split(dims[1], ",") {
+		dim = strings.TrimSpace(dim)
+		if dim == "" {
+			continue
+		}
+
+		size, err := strconv.Atoi(dim)
+		if err != nil || size < 0 {
+			return npyType{}, false, nil, fmt.Errorf("invalid npy shape (%s)", dims[1])
+		}
+		if size > 0 && total > math.MaxInt/size {
+			return npyType{}, false, nil, fmt.Errorf("npy shape (%s) is too large", dims[1])
+		}
+		total *= size
+		shape = append(shape, size)
+	}
+
+	if len(shape) == 0 {
+		shape = Shape{1}
+	}
+
+	return dtype, fortran[1] == "True", shape, nil
+}
+
+// WriteNpy writes a tensor in the .npy format, as little endian float32 or
+// float64 values depending on its dtype.
+func WriteNpy(w io.Writer, t Tensor) error {
+	if t == nil {
+		return errors.New("Tensor cannot be nil")
+	}
+
+	descr := "<f8"
+	if t.DType() == Float32 {
+		descr = "<f4"
+	}
+	return WriteNpyAs(w, t, descr)
+}
+
+// WriteNpyAs writes a tensor in the .npy format with the given NumPy dtype,
+// one of "<f4", "<f8", "<i1", "<i2", "<i4", "<i8", "<u1", "<u2", "<u4", "<u8"
+// or "|b1". Values written as ints have to be whole and in the range of the
+// dtype, and values written as bools 0 or 1, anything else gives an error.
+func WriteNpyAs(w io.Writer, t Tensor, descr string) error {
+	if t == nil {
+		return errors.New("Tensor cannot be nil")
+	}
+
+	dtype, err := parseNpyDescr(descr)
+	if err != nil {
+		return err
+	}
+	if dtype.order != binary.LittleEndian || (dtype.size > 1 && descr[0] == '|') {
+		return fmt.Errorf("unsupported npy dtype %q, only little endian can be written", descr)
+	}
+
+	// Every value is checked before anything is written
+	values := Values[float64](t)
+	for i, val := range values {
+		if err := dtype.check(val); err != nil {
+			return fmt.Errorf("value %d: %w", i, err)
+		}
+	}
+
+	dims := make([]string, len(t.Shape()))
+	for i, dim := range t.Shape() {
+		dims[i] = strconv.Itoa(dim)
+	}
+
+	shape := strings.Join(dims, ", ")
+	if len(dims) == 1 {
+		shape += ","
+	}
+
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, shape)
+
+	// Pad the header with spaces so the data starts at a multiple of 64 bytes
+	prefixLen := len(npyMagic) + 4
+	total := prefixLen + len(header) + 1
+	header += strings.Repeat(" ", (64-total%64)%64) + "\n"
+
+	buf := bufio.NewWriter(w)
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{1, 0})
+	binary.Write(buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+
+	raw := make([]byte, dtype.size)
+	if dtype.kind == 'f' && dtype.size == 4 {
+		// Straight from float32 tensors, so no value is rounded twice
+		for _, val := range Values[float32](t) {
+			binary.LittleEndian.PutUint32(raw, math.Float32bits(val))
+			buf.Write(raw)
+		}
+		return buf.Flush()
+	}
+
+	for _, val := range values {
+		dtype.put(raw, val)
+		buf.Write(raw)
+	}
+
+	return buf.Flush()
+}
+
+// check reports if val can be stored as the type without changing it.
+func (n npyType) check(val float64) error {
+	switch n.kind {
+	case 'f':
+		return nil
+
+	case 'b':
+		if val != 0 && val != 1 {
+			return fmt.Errorf("%v is not a bool", val)
+		}
+		return nil
+	}
+
+	if val != math.Trunc(val) {
+		return fmt.Errorf("%v is not a whole number", val)
+	}
+
+	bits := 8 * n.size
+	lo, hi := 0.0, math.Ldexp(1, bits)
+	if n.kind == 'i' {
+		lo, hi = -math.Ldexp(1, bits-1), math.Ldexp(1, bits-1)
+	}
+	if val < lo || val >= hi {
+		return fmt.Errorf("%v is out of the range of %c%d", val, n.kind, bits)
+	}
+	return nil
+}
+
+// put stores val as the type at the start of b, val has to pass check.
+func (n npyType) put(b []byte, val float64) {
+	switch {
+	case n.kind == 'f' && n.size == 4:
+		n.order.PutUint32(b, math.Float32bits(float32(val)))
+	case n.kind == 'f':
+		n.order.PutUint64(b, math.Float64bits(val))
+	case n.kind == 'i':
+		putNpyBits(b, n.order, n.size, uint64(int64(val)))
+	default:
+		putNpyBits(b, n.order, n.size, uint64(val))
+	}
+}
+
+func putNpyBits(b []byte, order binary.ByteOrder, size int, bits uint64) {
+	switch size {
+	case 1:
+		b[0] = byte(bits)
+	case 2:
+		order.PutUint16(b, uint16(bits))
+	case 4:
+		order.PutUint32(b, uint32(bits))
+	default:
+		order.PutUint64(b, bits)
+	}
+}
+
+// LoadNpy reads a tensor from an .npy file.
+func LoadNpy(path string) (Tensor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadNpy(bufio.NewReader(file))
+}
+
+// SaveNpy writes a tensor to an .npy file.
+func SaveNpy(path string, t Tensor) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteNpy(file, t); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// ReadNpz reads every array of an .npz archive, keyed by name like NumPy's
+// np.load. Both np.savez and np.savez_compressed archives can be read.
+func ReadNpz(r io.ReaderAt, size int64) (map[string]Tensor, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	tensors := make(map[string]Tensor, len(archive.File))
+	for _, file := range archive.File {
+		content, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		ten, err := ReadNpy(bufio.NewReader(content))
+		content.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+
+		tensors[strings.TrimSuffix(file.Name, ".npy")] = ten
+	}
+
+	return tensors, nil
+}
+
+// WriteNpz writes tensors to an uncompressed .npz archive, like np.savez.
+func WriteNpz(w io.Writer, tensors map[string]Tensor) error {
+	// Sorted names keep the output the same between runs
+	names := make([]string, 0, len(tensors))
+	for name := range tensors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	archive := zip.NewWriter(w)
+	for _, name := range names {
+		var buf bytes.Buffer
+		if err := WriteNpy(&buf, tensors[name]); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: zip.Store})
+		if err != nil {
+			return err
+		}
+
+		if _, err := file.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// LoadNpz reads every array of an .npz file.
+func LoadNpz(path string) (map[string]Tensor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadNpz(file, info.Size())
+}
+
+// SaveNpz writes tensors to an .npz file.
+func SaveNpz(path string, tensors map[string]Tensor) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteNpz(file, tensors); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
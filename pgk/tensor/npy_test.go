@@ -0,0 +1,155 @@
+package tensor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+// npyFile builds an .npy file from a header dict and raw data.
+func npyFile(header string, data []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{1, 0})
+	binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func TestNpyRoundTrip(t *testing.T) {
+	values := []float64{-3, -1, 0, 2, 5, 127}
+
+	tests := []struct {
+		descr string
+		dtype DType
+	}{
+		{"<f8", Float64},
+		{"<f4", Float32},
+		{"<i1", defaultDType},
+		{"<i2", defaultDType},
+		{"<i4", defaultDType},
+		{"<i8", defaultDType},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.descr, func(t *testing.T) {
+			ten := fromFloat64s(Shape{2, 3}, values, Float64)
+
+			var buf bytes.Buffer
+			if err := WriteNpyAs(&buf, ten, tc.descr); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), "'descr': '"+tc.descr+"'") {
+				t.Fatalf("header does not hold dtype %s", tc.descr)
+			}
+
+			res, err := ReadNpy(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.DType() != tc.dtype {
+				t.Errorf("dtype %v, want %v", res.DType(), tc.dtype)
+			}
+			if !res.Shape().DeepEq(Shape{2, 3}) {
+				t.Errorf("shape %v, want (2, 3)", res.Shape())
+			}
+			for i, val := range Values[float64](res) {
+				if val != values[i] {
+					t.Fatalf("value %d is %v, want %v", i, val, values[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWriteNpyAsRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		descr  string
+	}{
+		{"fraction as int", []float64{1, 2.5}, "<i4"},
+		{"out of int8 range", []float64{128, 0}, "<i1"},
+		{"negative as unsigned", []float64{-1, 0}, "<u2"},
+		{"non bool", []float64{0, 2}, "|b1"},
+		{"big endian", []float64{1, 2}, ">i4"},
+		{"unknown dtype", []float64{1, 2}, "<c8"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteNpyAs(&buf, fromFloat64s(Shape{1, 2}, tc.values, Float64), tc.descr)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if buf.Len() != 0 {
+				t.Errorf("%d bytes written before the error", buf.Len())
+			}
+		})
+	}
+}
+
+func TestReadNpyBadHeaders(t *testing.T) {
+	tests := []struct {
+		name string
+		file []byte
+		want error
+	}{
+		{
+			// Over a terabyte claimed, 8 bytes there
+			name: "more data than the file holds",
+			file: npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (200000000000,), }", make([]byte, 8)),
+			want: io.ErrUnexpectedEOF,
+		},
+		{
+			name: "size overflowing int",
+			file: npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (4294967296, 4294967296), }", nil),
+		},
+		{
+			name: "truncated header",
+			file: npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (2,), }", nil)[:20],
+			want: io.ErrUnexpectedEOF,
+		},
+		{
+			name: "unsupported dtype",
+			file: npyFile("{'descr': '<c16', 'fortran_order': False, 'shape': (1,), }", make([]byte, 16)),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadNpy(bytes.NewReader(tc.file))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tc.want != nil && !errors.Is(err, tc.want) {
+				t.Errorf("error %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadNpyFortranOrder(t *testing.T) {
+	// (2, 3) stored column major
+	data := make([]byte, 6*8)
+	for i, val := range []float64{1, 4, 2, 5, 3, 6} {
+		binary.LittleEndian.PutUint64(data[8*i:], math.Float64bits(val))
+	}
+
+	res, err := ReadNpy(bytes.NewReader(npyFile("{'descr': '<f8', 'fortran_order': True, 'shape': (2, 3), }", data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []float64{1, 2, 3, 4, 5, 6}
+	if got := Values[float64](res); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("values %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,140 @@
+package tensor
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Elementwise operations like Map, MapBatch and Exp split tensors with at
+// least elementwiseThreshold values into chunks that a pool of goroutines
+// takes turns computing. Smaller tensors run on the calling goroutine, where
+// starting the workers would cost more than they save.
+
+// Chunks are never smaller than this, so every chunk is worth a goroutine.
+const elementwiseMinChunk = 1 << 12
+
+var (
+	elementwiseWorkers   = 0
+	elementwiseThreshold = 1 << 15
+)
+
+// SetElementwiseWorkers sets how many goroutines elementwise operations use,
+// 0 uses GOMAXPROCS and 1 keeps them on the calling goroutine.
+func SetElementwiseWorkers(workers int) error {
+	if workers < 0 {
+		return errors.New("number of workers cannot be negative")
+	}
+
+	elementwiseWorkers = workers
+	return nil
+}
+
+// ElementwiseWorkers returns how many goroutines elementwise operations use.
+func ElementwiseWorkers() int {
+	if elementwiseWorkers > 0 {
+		return elementwiseWorkers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// SetElementwiseThreshold sets the number of values from which elementwise
+// operations run in parallel.
+func SetElementwiseThreshold(size int) error {
+	if size < 0 {
+		return errors.New("threshold cannot be negative")
+	}
+
+	elementwiseThreshold = size
+	return nil
+}
+
+// ElementwiseThreshold returns the number of values from which elementwise
+// operations run in parallel.
+func ElementwiseThreshold() int {
+	return elementwiseThreshold
+}
+
+// parallelFor calls fn on chunks covering [0, n), in parallel when n reaches
+// the threshold, so fn has to be safe to run on different chunks at once. It
+// returns the error of the first chunk that failed.
+func parallelFor(n int, fn func(start, end int) error) error {
+	workers := ElementwiseWorkers()
+	if n < elementwiseThreshold || n <= elementwiseMinChunk || workers <= 1 {
+		return fn(0, n)
+	}
+
+	// A few chunks per worker even out workers that fall behind
+	chunk := max(elementwiseMinChunk, (n+4*workers-1)/(4*workers))
+	chunks := (n + chunk - 1) / chunk
+	workers = min(workers, chunks)
+
+	errs := make([]error, chunks)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				c := int(next.Add(1)) - 1
+				if c >= chunks {
+					return
+				}
+				errs[c] = fn(c*chunk, min((c+1)*chunk, n))
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parallelItems calls fn for every item in [0, n), handed out one at a time to
+// the elementwise workers when the size values the items cover together reach
+// the threshold. It is for work split by item, like the channels of a tensor,
+// where the items are too few for parallelFor. The order of the calls is
+// undefined, and it returns the error of the first item that failed, the
+// others still run.
+func parallelItems(n, size int, fn func(i int) error) error {
+	workers := min(ElementwiseWorkers(), n)
+	if size < elementwiseThreshold || workers <= 1 {
+		for i := range n {
+			if err := fn(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	errs := make([]error, n)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= n {
+					return
+				}
+				errs[i] = fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
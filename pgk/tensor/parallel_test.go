@@ -0,0 +1,66 @@
+package tensor
+
+import (
+	"math"
+	"testing"
+)
+
+// benchmarkWorkers runs bench once with a single elementwise worker and once
+// with the default pool.
+func benchmarkWorkers(b *testing.B, bench func(b *testing.B)) {
+	defer SetElementwiseWorkers(0)
+
+	for _, workers := range []int{1, 0} {
+		name := "1_worker"
+		if workers == 0 {
+			name = "default_pool"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			SetElementwiseWorkers(workers)
+			bench(b)
+		})
+	}
+}
+
+func TestParallelMapMatchesSerial(t *testing.T) {
+	defer SetElementwiseWorkers(0)
+
+	input, _ := RandTensor(Shape{300, 300}, -1, 1)
+	exp := func(x float64) (float64, error) {
+		return math.Exp(x), nil
+	}
+
+	SetElementwiseWorkers(1)
+	serial, err := input.Map(exp, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetElementwiseWorkers(4)
+	parallel, err := input.Map(exp, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Values[float64](serial)
+	for i, val := range Values[float64](parallel) {
+		if val != expected[i] {
+			t.Fatalf("value %d is %v, want %v", i, val, expected[i])
+		}
+	}
+}
+
+// BenchmarkMap maps exp over a million values.
+func BenchmarkMap(b *testing.B) {
+	input, _ := RandTensor(Shape{1000, 1000}, -1, 1)
+	exp := func(x float64) (float64, error) {
+		return math.Exp(x), nil
+	}
+
+	benchmarkWorkers(b, func(b *testing.B) {
+		for range b.N {
+			input.Map(exp, false)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package tensor
+
+// Permute returns a view of the tensor with its dimensions in the order of
+// axes, where axes[i] is the dimension that ends up at i, e.g. Permute(0, 2,
+// 3, 1) turns NCHW into NHWC. No axes reverses the dimensions. The view shares
+// its data with t.
+func (t *tensor[E]) Permute(axes ...int) (Tensor, error) {
+	order, err := permutation(t.Dims(), axes)
+	if err != nil {
+		return nil, err
+	}
+
+	strides := t.Strides()
+	newShape := make(Shape, len(order))
+	newStrides := make([]int, len(order))
+	for i, axis := range order {
+		newShape[i] = t.TShape[axis]
+		newStrides[i] = strides[axis]
+	}
+
+	return t.newView(0, newShape, newStrides), nil
+}
+
+// Squeeze returns a view of the tensor without the given dimensions of size
+// 1, or without all of them when no axes are given.
+func (t *tensor[E]) Squeeze(axes ...int) (Tensor, error) {
+	keep, err := squeezed(t.TShape, axes)
+	if err != nil {
+		return nil, err
+	}
+
+	strides := t.Strides()
+	newShape := Shape{}
+	newStrides := []int{}
+	for i, dim := range t.TShape {
+		if keep[i] {
+			newShape = append(newShape, dim)
+			newStrides = append(newStrides, strides[i])
+		}
+	}
+
+	if len(newShape) == 0 {
+		newShape, newStrides = Shape{1}, []int{1}
+	}
+
+	return t.newView(0, newShape, newStrides), nil
+}
+
+// ExpandDims returns a view of the tensor with a dimension of size 1 inserted
+// at axis.
+func (t *tensor[E]) ExpandDims(axis int) (Tensor, error) {
+	axis, err := expandedAxis(t.Dims(), axis)
+	if err != nil {
+		return nil, err
+	}
+
+	newShape, _ := t.TShape.ExpandDims(axis)
+
+	// The stride of a dimension of size 1 is never used to move
+	strides := t.Strides()
+	newStrides := make([]int, 0, len(strides)+1)
+	newStrides = append(newStrides, strides[:axis]...)
+	newStrides = append(newStrides, 1)
+	newStrides = append(newStrides, strides[axis:]...)
+
+	return t.newView(0, newShape, newStrides), nil
+}
@@ -0,0 +1,66 @@
+package tensor
+
+import "testing"
+
+func TestPermute(t *testing.T) {
+	ten, _ := RandTensor(Shape{2, 3, 4, 5}, -1, 1)
+
+	// Transpose is Permute of the last two axes
+	transposed := ten.Transpose(false)
+	swapped, err := ten.Permute(0, 1, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		axes     []int
+		shape    Shape
+		expected func(i, j, k, l int) (int, int, int, int)
+	}{
+		{"nchw to nhwc", []int{0, 2, 3, 1}, Shape{2, 4, 5, 3}, func(i, j, k, l int) (int, int, int, int) { return i, k, l, j }},
+		{"reversed", nil, Shape{5, 4, 3, 2}, func(i, j, k, l int) (int, int, int, int) { return l, k, j, i }},
+		{"negative axes", []int{-1, 0, 1, 2}, Shape{5, 2, 3, 4}, func(i, j, k, l int) (int, int, int, int) { return l, i, j, k }},
+	}
+
+	for _, test := range tests {
+		permuted, err := ten.Permute(test.axes...)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !permuted.Shape().DeepEq(test.shape) {
+			t.Fatalf("%s: shape %v, want %v", test.name, permuted.Shape(), test.shape)
+		}
+
+		for i := range 2 {
+			for j := range 3 {
+				for k := range 4 {
+					for l := range 5 {
+						a, b, c, d := test.expected(i, j, k, l)
+						want := ten.ValueAt(((i*3+j)*4+k)*5 + l)
+						got := permuted.ValueAt(((a*test.shape[1]+b)*test.shape[2]+c)*test.shape[3] + d)
+						if got != want {
+							t.Fatalf("%s: value at %v is %v, want %v", test.name, []int{a, b, c, d}, got, want)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if !transposed.Shape().DeepEq(swapped.Shape()) {
+		t.Fatalf("Transpose gives %v, Permute of the last axes %v", transposed.Shape(), swapped.Shape())
+	}
+	expected := swapped.DataCopy()
+	for i, val := range transposed.DataCopy() {
+		if val != expected[i] {
+			t.Fatalf("value %d of Transpose is %v, want %v", i, val, expected[i])
+		}
+	}
+
+	for _, axes := range [][]int{{0, 1, 2}, {0, 1, 2, 2}, {0, 1, 2, 4}} {
+		if _, err := ten.Permute(axes...); err == nil {
+			t.Errorf("axes %v gave no error", axes)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package tensor
+
+import "sync"
+
+// Tensors created by operations take their buffers from a pool. Passing a
+// tensor that is no longer needed to Release hands its buffer back, so the
+// next tensor of the same size reuses it instead of allocating. Every size has
+// its own sync.Pool, so buffers that stay unused are still freed by the
+// garbage collector.
+
+// Smaller buffers are cheaper to allocate than to pool.
+const poolMinSize = 256
+
+var bufferPooling = true
+
+// SetBufferPooling turns taking buffers from the pool on or off. Released
+// tensors still hand back their buffers while it is off.
+func SetBufferPooling(enabled bool) {
+	bufferPooling = enabled
+}
+
+// BufferPooling reports whether new tensors take their buffers from the pool.
+func BufferPooling() bool {
+	return bufferPooling
+}
+
+// bufferPool holds a *sync.Pool of *[]E for every buffer size.
+type bufferPool[E Float] struct {
+	sizes sync.Map
+}
+
+var (
+	float32Buffers bufferPool[float32]
+	float64Buffers bufferPool[float64]
+)
+
+func buffersOf[E Float]() *bufferPool[E] {
+	var pool any
+	if dtypeOf[E]() == Float32 {
+		pool = &float32Buffers
+	} else {
+		pool = &float64Buffers
+	}
+	return pool.(*bufferPool[E])
+}
+
+// get returns a zeroed buffer of size values, together with the pointer that
+// hands it back to the pool, which is nil when pooling is off or the buffer is
+// too small. Keeping the pointer means putting the buffer back doesn't
+// allocate.
+func (p *bufferPool[E]) get(size int) ([]E, *[]E) {
+	if !bufferPooling || size < poolMinSize {
+		return make([]E, size), nil
+	}
+
+	if pool, ok := p.sizes.Load(size); ok {
+		if buf, ok := pool.(*sync.Pool).Get().(*[]E); ok {
+			data := (*buf)[:size]
+			clear(data)
+			return data, buf
+		}
+	}
+
+	data := make([]E, size)
+	return data, &data
+}
+
+// put hands data back to the pool through buf, the pointer get returned.
+func (p *bufferPool[E]) put(buf *[]E, data []E) {
+	*buf = data[:cap(data)]
+	pool, ok := p.sizes.Load(len(*buf))
+	if !ok {
+		pool, _ = p.sizes.LoadOrStore(len(*buf), &sync.Pool{})
+	}
+	pool.(*sync.Pool).Put(buf)
+}
+
+// Acquire creates a tensor of zeros like Zeros, meant as scratch space that is
+// handed back with Release once it is no longer needed.
+func Acquire(shape Shape, dtype DType) Tensor {
+	return Zeros(shape, dtype)
+}
+
+// Release hands the buffers of tensors that are no longer needed back to the
+// pool. A released tensor can't be used anymore. Only tensors that own a
+// buffer from the pool and were never viewed give it back: once Transpose,
+// Slice, Permute, an AxisIter or any other view has shared the buffer of a
+// tensor, the view may outlive it, so releasing the tensor leaves the buffer
+// to the garbage collector. Views, tensors created from a slice and nil
+// tensors are left alone.
+func Release(tensors ...Tensor) {
+	for _, ten := range tensors {
+		if ten != nil {
+			ten.release()
+		}
+	}
+}
+
+func (t *tensor[E]) release() {
+	if t.pooled == nil || t.shared.Load() {
+		return
+	}
+
+	buffersOf[E]().put(t.pooled, t.Data)
+	t.Data, t.pooled = nil, nil
+}
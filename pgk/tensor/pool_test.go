@@ -0,0 +1,73 @@
+package tensor
+
+import "testing"
+
+func TestReleaseKeepsViewedBuffers(t *testing.T) {
+	shape := Shape{1, 1, 32, 32}
+
+	base := Zeros(shape, Float64)
+	base.ScalarAdd(1, true)
+	view := base.Transpose(false)
+
+	Release(base)
+
+	// The next tensor of the same size must not get the buffer of the view
+	other := Zeros(shape, Float64)
+	other.ScalarAdd(2, true)
+
+	for i, val := range Values[float64](view) {
+		if val != 1 {
+			t.Fatalf("value %d of the view is %v after releasing its base, want 1", i, val)
+		}
+	}
+}
+
+func TestReleaseReusesBuffers(t *testing.T) {
+	shape := Shape{1, 1, 32, 32}
+
+	ten := Zeros(shape, Float64)
+	data := asType[float64](ten).Data
+	data[0] = 5
+	Release(ten)
+
+	// A sync.Pool may drop its buffers at any time, so only a reused buffer
+	// is checked for being cleared
+	other := asType[float64](Zeros(shape, Float64))
+	if &other.Data[0] == &data[0] && other.Data[0] != 0 {
+		t.Fatalf("reused buffer starts with %v, want 0", other.Data[0])
+	}
+}
+
+// benchmarkPooling runs bench once allocating every tensor and once taking
+// the buffers from the pool.
+func benchmarkPooling(b *testing.B, bench func(b *testing.B)) {
+	defer SetBufferPooling(true)
+
+	for _, pooling := range []bool{false, true} {
+		name := "allocating"
+		if pooling {
+			name = "pooled"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			SetBufferPooling(pooling)
+			b.ReportAllocs()
+			bench(b)
+		})
+	}
+}
+
+// BenchmarkScratchTensors computes x*w + x into scratch tensors that are
+// released every step, like a layer does with its intermediate results.
+func BenchmarkScratchTensors(b *testing.B) {
+	x, _ := RandTensor(Shape{64, 512}, -1, 1)
+	w, _ := RandTensor(Shape{64, 512}, -1, 1)
+
+	benchmarkPooling(b, func(b *testing.B) {
+		for range b.N {
+			product, _ := x.Multiply(w, false)
+			sum, _ := product.Add(x, false)
+			Release(product, sum)
+		}
+	})
+}
@@ -0,0 +1,361 @@
+package tensor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Post-training quantization stores values as int8 with the affine mapping
+// value = scale * (q - zeroPoint). The range a scale and zero point cover
+// always includes 0, so zero padding and the zeros of relu stay exact.
+
+// Rows of the left operand a worker of QuantizedMatMul takes at a time.
+const quantizedBlockRows = 16
+
+// Longest rows QuantizedMatMul multiplies. With the zero points taken out
+// every product is at most 255*255 in size, and a sum of k of them has to
+// fit in an int32.
+const maxQuantizedK = math.MaxInt32 / (255 * 255)
+
+// QTensor is a tensor of int8 values with a scale and zero point for the
+// whole tensor, or one per index along Axis for per-channel quantization.
+type QTensor struct {
+	TShape     Shape
+	Data       []int8
+	Axis       int // Axis with a scale and zero point per index, -1 when all values share one
+	Scales     []float64
+	ZeroPoints []int32
+}
+
+// QuantParams returns the scale and zero point that spread [min, max] over
+// the 256 int8 values. The range is widened to include 0 first.
+func QuantParams(min, max float64) (float64, int32) {
+	min, max = math.Min(min, 0), math.Max(max, 0)
+	if max == min {
+		return 1, 0
+	}
+
+	scale := (max - min) / (math.MaxInt8 - math.MinInt8)
+	zeroPoint := math.Round(math.MinInt8 - min/scale)
+	return scale, int32(math.Max(math.MinInt8, math.Min(math.MaxInt8, zeroPoint)))
+}
+
+// Quantize quantizes t with a single scale and zero point, like the ones
+// QuantParams gives for the range of t seen during calibration. Values
+// outside the range are clamped.
+func Quantize(t Tensor, scale float64, zeroPoint int32) (*QTensor, error) {
+	if t == nil {
+		return nil, errors.New("tensor cannot be nil")
+	}
+
+	if scale <= 0 {
+		return nil, errors.New("scale has to be positive")
+	}
+
+	if zeroPoint < math.MinInt8 || zeroPoint > math.MaxInt8 {
+		return nil, fmt.Errorf("zero point %d out of the int8 range", zeroPoint)
+	}
+
+	values := float64s(t)
+	data := make([]int8, len(values))
+	parallelFor(len(values), func(start, end int) error {
+		for i := start; i < end; i++ {
+			data[i] = quantizeValue(values[i], scale, zeroPoint)
+		}
+		return nil
+	})
+
+	return &QTensor{
+		TShape:     t.Shape().Clone(),
+		Data:       data,
+		Axis:       -1,
+		Scales:     []float64{scale},
+		ZeroPoints: []int32{zeroPoint},
+	}, nil
+}
+
+// QuantizePerChannel quantizes t with a scale and zero point for every index
+// along axis, fitted to the range of the values at that index. Per-channel
+// weights keep small filters or units from losing their precision to the
+// large ones.
+func QuantizePerChannel(t Tensor, axis int) (*QTensor, error) {
+	if t == nil {
+		return nil, errors.New("tensor cannot be nil")
+	}
+
+	axis, err := tensorAxis(t.Dims(), axis)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &QTensor{
+		TShape:     t.Shape().Clone(),
+		Data:       make([]int8, t.Size()),
+		Axis:       axis,
+		Scales:     make([]float64, t.Shape()[axis]),
+		ZeroPoints: make([]int32, t.Shape()[axis]),
+	}
+
+	// Every channel is fitted and quantized on its own, in parallel
+	values := float64s(t)
+	outer, channels, inner := q.layout()
+	parallelItems(channels, len(values), func(c int) error {
+		minVal, maxVal := 0.0, 0.0
+		for o := range outer {
+			for _, val := range values[(o*channels+c)*inner:][:inner] {
+				minVal = math.Min(minVal, val)
+				maxVal = math.Max(maxVal, val)
+			}
+		}
+
+		scale, zeroPoint := QuantParams(minVal, maxVal)
+		q.Scales[c], q.ZeroPoints[c] = scale, zeroPoint
+
+		for o := range outer {
+			start := (o*channels + c) * inner
+			for i, val := range values[start : start+inner] {
+				q.Data[start+i] = quantizeValue(val, scale, zeroPoint)
+			}
+		}
+		return nil
+	})
+
+	return q, nil
+}
+
+func quantizeValue(val, scale float64, zeroPoint int32) int8 {
+	q := math.Round(val/scale) + float64(zeroPoint)
+	return int8(math.Max(math.MinInt8, math.Min(math.MaxInt8, q)))
+}
+
+func (q *QTensor) Shape() Shape {
+	return q.TShape
+}
+
+func (q *QTensor) Size() int {
+	return len(q.Data)
+}
+
+// layout splits the values into outer blocks of the channels along Axis,
+// each channel a run of inner values, so the value at row major index i is in
+// channel (i / inner) % channels. Without an axis all values are one channel.
+func (q *QTensor) layout() (outer, channels, inner int) {
+	if q.Axis < 0 {
+		return 1, 1, len(q.Data)
+	}
+
+	outer, channels, inner = 1, q.TShape[q.Axis], 1
+	for _, dim := range q.TShape[:q.Axis] {
+		outer *= dim
+	}
+	for _, dim := range q.TShape[q.Axis+1:] {
+		inner *= dim
+	}
+	return outer, channels, inner
+}
+
+// Dequantize converts the int8 values back to a tensor of the given dtype.
+func (q *QTensor) Dequantize(dtype DType) Tensor {
+	values := make([]float64, len(q.Data))
+	outer, channels, inner := q.layout()
+	parallelItems(channels, len(values), func(c int) error {
+		scale, zeroPoint := q.Scales[c], q.ZeroPoints[c]
+		for o := range outer {
+			start := (o*channels + c) * inner
+			for i, val := range q.Data[start : start+inner] {
+				values[start+i] = scale * float64(int32(val)-zeroPoint)
+			}
+		}
+		return nil
+	})
+	return fromFloat64s(q.TShape, values, dtype)
+}
+
+// QuantizedMatMul multiplies x, read as a matrix of rows of its last
+// dimension, with the transpose of the (rows, k) matrix w and returns the
+// (rows of x, rows of w) product in dtype. Either operand can have a scale
+// per row, quantized along axis 0 of a matrix, so weights stored as (outputs,
+// inputs) are quantized per output whichever side they are on.
+//
+// The products are summed in int32, the zero point of w is taken out
+// afterwards through the sums of the rows of x, so the inner loop reads the
+// weights as int8. The sums only fit for rows of up to 33025 values, longer
+// rows give an error.
+func QuantizedMatMul(x, w *QTensor, dtype DType) (Tensor, error) {
+	if x == nil || w == nil {
+		return nil, errors.New("operands cannot be nil")
+	}
+
+	k := x.TShape[len(x.TShape)-1]
+	if k > maxQuantizedK {
+		return nil, fmt.Errorf("rows of %d values overflow the int32 sums, at most %d can be multiplied", k, maxQuantizedK)
+	}
+
+	m, n := x.Size()/k, w.TShape[0]
+	if w.Size() != n*k {
+		return nil, shapeMismatch("QuantizedMatMul", Shape{n, k}, w.TShape)
+	}
+
+	xScales, xZeros, err := x.rowParams(m)
+	if err != nil {
+		return nil, err
+	}
+
+	wScales, wZeros, err := w.rowParams(n)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]float64, m*n)
+	rows := func(start, end int) {
+		shifted := make([]int32, 2*k)
+		acc := make([]int32, 2*n)
+		for i := start; i < end; i += 2 {
+			pair := min(2, end-i)
+
+			// With the zero point of x taken out up front, only the sums of the
+			// rows of x are needed to take out the zero points of w
+			var sums [2]int32
+			for r := range pair {
+				for c, val := range x.Data[(i+r)*k : (i+r+1)*k] {
+					shifted[r*k+c] = int32(val) - xZeros[i+r]
+					sums[r] += shifted[r*k+c]
+				}
+			}
+
+			if pair == 2 {
+				dotRows(shifted[:k], shifted[k:], w.Data, acc[:n], acc[n:])
+			} else {
+				dotRow(shifted[:k], w.Data, acc[:n])
+			}
+
+			for r := range pair {
+				for j := range n {
+					sum := acc[r*n+j] - wZeros[j]*sums[r]
+					values[(i+r)*n+j] = xScales[i+r] * wScales[j] * float64(sum)
+				}
+			}
+		}
+	}
+
+	blocks := (m + quantizedBlockRows - 1) / quantizedBlockRows
+	workers := min(MatMulWorkers(), blocks)
+	if m*n*k < matMulParallelThreshold || workers <= 1 {
+		rows(0, m)
+		return fromFloat64s(Shape{m, n}, values, dtype), nil
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				block := int(next.Add(1)) - 1
+				if block >= blocks {
+					return
+				}
+				rows(block*quantizedBlockRows, min((block+1)*quantizedBlockRows, m))
+			}
+		}()
+	}
+	wg.Wait()
+
+	return fromFloat64s(Shape{m, n}, values, dtype), nil
+}
+
+// rowParams returns the scale and zero point of each of the rows of q read
+// as a matrix, which is either quantized per tensor or along axis 0.
+func (q *QTensor) rowParams(rows int) ([]float64, []int32, error) {
+	scales, zeroPoints := make([]float64, rows), make([]int32, rows)
+	switch {
+	case q.Axis < 0:
+		for i := range rows {
+			scales[i], zeroPoints[i] = q.Scales[0], q.ZeroPoints[0]
+		}
+
+	case q.Axis == 0 && len(q.Scales) == rows:
+		copy(scales, q.Scales)
+		copy(zeroPoints, q.ZeroPoints)
+
+	default:
+		return nil, nil, fmt.Errorf("operand of shape %v is quantized along axis %d, only per tensor or per row can be multiplied", q.TShape, q.Axis)
+	}
+
+	return scales, zeroPoints, nil
+}
+
+// dotRow sets acc[j] to the dot product of x with row j of w. Every load of
+// x is shared by four rows of w.
+func dotRow(x []int32, w []int8, acc []int32) {
+	k := len(x)
+
+	j := 0
+	for ; j+4 <= len(acc); j += 4 {
+		w0 := w[j*k : (j+1)*k][:len(x)]
+		w1 := w[(j+1)*k : (j+2)*k][:len(x)]
+		w2 := w[(j+2)*k : (j+3)*k][:len(x)]
+		w3 := w[(j+3)*k : (j+4)*k][:len(x)]
+
+		var s0, s1, s2, s3 int32
+		for i, xv := range x {
+			s0 += xv * int32(w0[i])
+			s1 += xv * int32(w1[i])
+			s2 += xv * int32(w2[i])
+			s3 += xv * int32(w3[i])
+		}
+		acc[j], acc[j+1], acc[j+2], acc[j+3] = s0, s1, s2, s3
+	}
+
+	for ; j < len(acc); j++ {
+		var sum int32
+		for i, wv := range w[j*k : (j+1)*k] {
+			sum += x[i] * int32(wv)
+		}
+		acc[j] = sum
+	}
+}
+
+// dotRows is dotRow for two rows of x at once, so every load of w is shared
+// by both.
+func dotRows(x0, x1 []int32, w []int8, acc0, acc1 []int32) {
+	k := len(x0)
+	x1 = x1[:k]
+
+	j := 0
+	for ; j+4 <= len(acc0); j += 4 {
+		w0 := w[j*k : (j+1)*k][:len(x0)]
+		w1 := w[(j+1)*k : (j+2)*k][:len(x0)]
+		w2 := w[(j+2)*k : (j+3)*k][:len(x0)]
+		w3 := w[(j+3)*k : (j+4)*k][:len(x0)]
+
+		var s00, s01, s02, s03, s10, s11, s12, s13 int32
+		for i, a := range x0 {
+			b := x1[i]
+			v0, v1, v2, v3 := int32(w0[i]), int32(w1[i]), int32(w2[i]), int32(w3[i])
+			s00 += a * v0
+			s01 += a * v1
+			s02 += a * v2
+			s03 += a * v3
+			s10 += b * v0
+			s11 += b * v1
+			s12 += b * v2
+			s13 += b * v3
+		}
+		acc0[j], acc0[j+1], acc0[j+2], acc0[j+3] = s00, s01, s02, s03
+		acc1[j], acc1[j+1], acc1[j+2], acc1[j+3] = s10, s11, s12, s13
+	}
+
+	for ; j < len(acc0); j++ {
+		var s0, s1 int32
+		for i, wv := range w[j*k : (j+1)*k] {
+			s0 += x0[i] * int32(wv)
+			s1 += x1[i] * int32(wv)
+		}
+		acc0[j], acc1[j] = s0, s1
+	}
+}
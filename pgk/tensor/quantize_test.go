@@ -0,0 +1,150 @@
+package tensor
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestQuantParams(t *testing.T) {
+	tests := []struct{ min, max float64 }{
+		{-1, 1},
+		{0, 6},
+		{-3, -1},
+		{0.5, 2},
+		{-0.001, 100},
+	}
+
+	for _, test := range tests {
+		scale, zeroPoint := QuantParams(test.min, test.max)
+		if scale <= 0 {
+			t.Fatalf("[%v, %v]: scale %v is not positive", test.min, test.max, scale)
+		}
+
+		// 0 has to be exact, and the ends of the range within half a step
+		if val := quantizeValue(0, scale, zeroPoint); int32(val) != zeroPoint {
+			t.Errorf("[%v, %v]: 0 quantizes to %d, want the zero point %d", test.min, test.max, val, zeroPoint)
+		}
+
+		for _, end := range []float64{math.Min(test.min, 0), math.Max(test.max, 0)} {
+			q := quantizeValue(end, scale, zeroPoint)
+			if got := scale * float64(int32(q)-zeroPoint); math.Abs(got-end) > scale/2+1e-12 {
+				t.Errorf("[%v, %v]: %v comes back as %v", test.min, test.max, end, got)
+			}
+		}
+	}
+
+	if scale, zeroPoint := QuantParams(0, 0); scale != 1 || zeroPoint != 0 {
+		t.Errorf("empty range gives scale %v and zero point %d, want 1 and 0", scale, zeroPoint)
+	}
+}
+
+func TestQuantizeRoundTrip(t *testing.T) {
+	ten, _ := RandTensor(Shape{4, 5, 6}, -2, 3)
+	scale, zeroPoint := QuantParams(ten.Min(), ten.Max())
+
+	q, err := Quantize(ten, scale, zeroPoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Values[float64](ten)
+	for i, val := range Values[float64](q.Dequantize(Float64)) {
+		if math.Abs(val-expected[i]) > scale/2+1e-12 {
+			t.Fatalf("value %d comes back as %v, want %v within %v", i, val, expected[i], scale/2)
+		}
+	}
+}
+
+func TestQuantizePerChannelRoundTrip(t *testing.T) {
+	ten, _ := RandTensor(Shape{3, 4, 5}, -1, 1)
+	for axis := range ten.Dims() {
+		q, err := QuantizePerChannel(ten, axis)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(q.Scales) != ten.Shape()[axis] {
+			t.Fatalf("axis %d: %d scales for %d channels", axis, len(q.Scales), ten.Shape()[axis])
+		}
+
+		outer, channels, inner := q.layout()
+		if outer*channels*inner != ten.Size() {
+			t.Fatalf("axis %d: layout %d*%d*%d doesn't cover %d values", axis, outer, channels, inner, ten.Size())
+		}
+
+		expected := Values[float64](ten)
+		for i, val := range Values[float64](q.Dequantize(Float64)) {
+			c := (i / inner) % channels
+			if math.Abs(val-expected[i]) > q.Scales[c]/2+1e-12 {
+				t.Fatalf("axis %d: value %d comes back as %v, want %v within %v", axis, i, val, expected[i], q.Scales[c]/2)
+			}
+		}
+	}
+}
+
+func TestQuantizedMatMulMatchesMatMul(t *testing.T) {
+	tests := []struct {
+		name    string
+		m, n, k int
+	}{
+		{"single row", 1, 7, 9},
+		{"odd rows", 5, 6, 13},
+		{"blocks", 70, 33, 64},
+	}
+
+	for _, test := range tests {
+		x, _ := RandTensor(Shape{test.m, test.k}, -1, 2)
+		w, _ := RandTensor(Shape{test.n, test.k}, -0.5, 0.5)
+
+		expected, err := x.MatMul(w.Transpose(false))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xScale, xZeroPoint := QuantParams(x.Min(), x.Max())
+		qx, err := Quantize(x, xScale, xZeroPoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		qw, err := QuantizePerChannel(w, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := QuantizedMatMul(qx, qw, Float64)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !res.Shape().DeepEq(Shape{test.m, test.n}) {
+			t.Fatalf("%s: shape %v, want (%d, %d)", test.name, res.Shape(), test.m, test.n)
+		}
+
+		// Every product is off by at most half a step of each operand times
+		// the largest value of the other, |x| <= 2 and |w| <= 0.5
+		expectedValues := Values[float64](expected)
+		for i, val := range Values[float64](res) {
+			tolerance := float64(test.k) * (0.5*xScale/2 + 2*qw.Scales[i%test.n]/2)
+			if math.Abs(val-expectedValues[i]) > tolerance {
+				t.Fatalf("%s: value %d is %v, want %v within %v", test.name, i, val, expectedValues[i], tolerance)
+			}
+		}
+	}
+}
+
+func TestQuantizedMatMulErrors(t *testing.T) {
+	x := &QTensor{TShape: Shape{2, 3}, Data: make([]int8, 6), Axis: -1, Scales: []float64{1}, ZeroPoints: []int32{0}}
+	w := &QTensor{TShape: Shape{4, 2}, Data: make([]int8, 8), Axis: -1, Scales: []float64{1}, ZeroPoints: []int32{0}}
+
+	var mismatch *ShapeMismatchError
+	if _, err := QuantizedMatMul(x, w, Float64); !errors.As(err, &mismatch) {
+		t.Errorf("rows of different lengths gave %v, want a ShapeMismatchError", err)
+	}
+
+	long := &QTensor{TShape: Shape{1, maxQuantizedK + 1}, Data: make([]int8, maxQuantizedK+1), Axis: -1, Scales: []float64{1}, ZeroPoints: []int32{0}}
+	if _, err := QuantizedMatMul(long, long, Float64); err == nil {
+		t.Errorf("rows of %d values didn't give an error", maxQuantizedK+1)
+	}
+}
@@ -0,0 +1,239 @@
+package tensor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The constructors below draw from an RNG and give tensors with the default
+// dtype. The package level functions use the global RNG.
+
+// Normal creates a tensor with values drawn from a normal distribution.
+func (g *RNG) Normal(shape Shape, mean, std float64) (Tensor, error) {
+	if std < 0 {
+		return nil, errors.New("std cannot be negative")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	resData := make([]float64, shape.TotalSize())
+	for i := range resData {
+		resData[i] = mean + g.src.NormFloat64()*std
+	}
+
+	return fromFloat64s(shape, resData, defaultDType), nil
+}
+
+// TruncatedNormal creates a tensor with values drawn from a normal
+// distribution, values more than two standard deviations from the mean are
+// drawn again.
+func (g *RNG) TruncatedNormal(shape Shape, mean, std float64) (Tensor, error) {
+	if std < 0 {
+		return nil, errors.New("std cannot be negative")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	resData := make([]float64, shape.TotalSize())
+	for i := range resData {
+		val := g.src.NormFloat64()
+		for math.Abs(val) > 2 {
+			val = g.src.NormFloat64()
+		}
+		resData[i] = mean + val*std
+	}
+
+	return fromFloat64s(shape, resData, defaultDType), nil
+}
+
+// Bernoulli creates a tensor of ones with probability p and zeros otherwise,
+// e.g. a dropout mask.
+func (g *RNG) Bernoulli(shape Shape, p float64) (Tensor, error) {
+	if p < 0 || p > 1 {
+		return nil, fmt.Errorf("probability %v not in [0, 1]", p)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	resData := make([]float64, shape.TotalSize())
+	for i := range resData {
+		if g.src.Float64() < p {
+			resData[i] = 1
+		}
+	}
+
+	return fromFloat64s(shape, resData, defaultDType), nil
+}
+
+// categoryWeights reads the rows of probs along its last axis as cumulative
+// weights. The weights don't have to add up to one, but can't be negative and
+// every row needs a positive total.
+func categoryWeights(probs Tensor) ([][]float64, error) {
+	if probs == nil {
+		return nil, errors.New("probs cannot be nil")
+	}
+
+	values := float64s(probs)
+	categories := probs.Shape()[probs.Dims()-1]
+	if categories == 0 {
+		return nil, errors.New("probs needs at least one category")
+	}
+
+	rows := make([][]float64, len(values)/categories)
+	for r := range rows {
+		cumulative := make([]float64, categories)
+		total := 0.0
+		for c, val := range values[r*categories : (r+1)*categories] {
+			if val < 0 || math.IsNaN(val) {
+				return nil, fmt.Errorf("invalid probability %v", val)
+			}
+			total += val
+			cumulative[c] = total
+		}
+
+		if total <= 0 || math.IsInf(total, 0) {
+			return nil, fmt.Errorf("probabilities of row %d add up to %v", r, total)
+		}
+		rows[r] = cumulative
+	}
+
+	return rows, nil
+}
+
+// sampleCategory draws a category from cumulative weights.
+func (g *RNG) sampleCategory(cumulative []float64) int {
+	target := g.src.Float64() * cumulative[len(cumulative)-1]
+	lo, hi := 0, len(cumulative)-1
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if cumulative[mid] > target {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+// Categorical draws samples category indices for every row of probs, the
+// probabilities of the categories along its last axis, like sampling from the
+// output of a softmax. The result has the shape of probs with the last axis
+// replaced by samples and the dtype of probs.
+func (g *RNG) Categorical(probs Tensor, samples int) (Tensor, error) {
+	if samples < 0 {
+		return nil, errors.New("number of samples cannot be negative")
+	}
+
+	rows, err := categoryWeights(probs)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	resData := make([]float64, len(rows)*samples)
+	for r, cumulative := range rows {
+		for s := 0; s < samples; s++ {
+			resData[r*samples+s] = float64(g.sampleCategory(cumulative))
+		}
+	}
+
+	resShape := probs.Shape().Clone()
+	resShape[len(resShape)-1] = samples
+	return fromFloat64s(resShape, resData, probs.DType()), nil
+}
+
+// Multinomial draws trials categories for every row of probs, like
+// Categorical, and counts how often every category came up. The result has
+// the shape and dtype of probs.
+func (g *RNG) Multinomial(probs Tensor, trials int) (Tensor, error) {
+	if trials < 0 {
+		return nil, errors.New("number of trials cannot be negative")
+	}
+
+	rows, err := categoryWeights(probs)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	categories := probs.Shape()[probs.Dims()-1]
+	resData := make([]float64, len(rows)*categories)
+	for r, cumulative := range rows {
+		for i := 0; i < trials; i++ {
+			resData[r*categories+g.sampleCategory(cumulative)]++
+		}
+	}
+
+	return fromFloat64s(probs.Shape().Clone(), resData, probs.DType()), nil
+}
+
+// Perm returns a random permutation of the ints in [0, n), which can be given
+// to IndexSelect to shuffle a tensor along an axis.
+func (g *RNG) Perm(n int) []int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.src.Perm(n)
+}
+
+// Permutation creates a tensor of shape (n) holding a random permutation of
+// [0, n).
+func (g *RNG) Permutation(n int) (Tensor, error) {
+	if n <= 0 {
+		return nil, errors.New("n has to be positive")
+	}
+
+	perm := g.Perm(n)
+	resData := make([]float64, n)
+	for i, val := range perm {
+		resData[i] = float64(val)
+	}
+
+	return fromFloat64s(Shape{n}, resData, defaultDType), nil
+}
+
+// Normal creates a tensor with values drawn from a normal distribution by the
+// global RNG.
+func Normal(shape Shape, mean, std float64) (Tensor, error) {
+	return GlobalRNG().Normal(shape, mean, std)
+}
+
+// TruncatedNormal creates a tensor with values drawn from a normal
+// distribution cut off at two standard deviations by the global RNG.
+func TruncatedNormal(shape Shape, mean, std float64) (Tensor, error) {
+	return GlobalRNG().TruncatedNormal(shape, mean, std)
+}
+
+// Bernoulli creates a tensor of ones with probability p by the global RNG.
+func Bernoulli(shape Shape, p float64) (Tensor, error) {
+	return GlobalRNG().Bernoulli(shape, p)
+}
+
+// Categorical draws category indices from the rows of probs by the global RNG.
+func Categorical(probs Tensor, samples int) (Tensor, error) {
+	return GlobalRNG().Categorical(probs, samples)
+}
+
+// Multinomial counts trials categories drawn from the rows of probs by the
+// global RNG.
+func Multinomial(probs Tensor, trials int) (Tensor, error) {
+	return GlobalRNG().Multinomial(probs, trials)
+}
+
+// Perm returns a random permutation of [0, n) from the global RNG.
+func Perm(n int) []int {
+	return GlobalRNG().Perm(n)
+}
+
+// Permutation creates a tensor holding a random permutation of [0, n) from the
+// global RNG.
+func Permutation(n int) (Tensor, error) {
+	return GlobalRNG().Permutation(n)
+}
@@ -0,0 +1,139 @@
+package tensor
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestRandomSameSeed(t *testing.T) {
+	probs := indexTensor(Shape{2, 3}, 0.2, 0.5, 0.3, 1, 1, 2)
+
+	draws := map[string]func(g *RNG) (Tensor, error){
+		"Normal":          func(g *RNG) (Tensor, error) { return g.Normal(Shape{3, 4}, 1, 2) },
+		"TruncatedNormal": func(g *RNG) (Tensor, error) { return g.TruncatedNormal(Shape{3, 4}, 1, 2) },
+		"Bernoulli":       func(g *RNG) (Tensor, error) { return g.Bernoulli(Shape{3, 4}, 0.3) },
+		"Categorical":     func(g *RNG) (Tensor, error) { return g.Categorical(probs, 10) },
+		"Multinomial":     func(g *RNG) (Tensor, error) { return g.Multinomial(probs, 10) },
+	}
+
+	for name, draw := range draws {
+		t.Run(name, func(t *testing.T) {
+			first, err := draw(NewRNG(7))
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := draw(NewRNG(7))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if fmt.Sprint(Values[float64](first)) != fmt.Sprint(Values[float64](second)) {
+				t.Errorf("two RNGs with the same seed gave %v and %v", Values[float64](first), Values[float64](second))
+			}
+		})
+	}
+}
+
+func TestTruncatedNormalBounds(t *testing.T) {
+	mean, std := 3.0, 0.5
+	res, err := NewRNG(1).TruncatedNormal(Shape{100, 100}, mean, std)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, val := range Values[float64](res) {
+		if math.Abs(val-mean) > 2*std {
+			t.Fatalf("value %d is %v, more than two standard deviations from %v", i, val, mean)
+		}
+	}
+}
+
+func TestCategoricalSkipsZeroProbabilities(t *testing.T) {
+	// Zero weight categories at the start, middle and end of the rows
+	probs := indexTensor(Shape{2, 4}, 0, 0.5, 0, 0.5, 1, 0, 3, 0)
+
+	res, err := NewRNG(1).Categorical(probs, 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Shape().DeepEq(Shape{2, 2000}) {
+		t.Fatalf("shape %v, want (2, 2000)", res.Shape())
+	}
+
+	allowed := [2]map[float64]bool{{1: true, 3: true}, {0: true, 2: true}}
+	values := Values[float64](res)
+	for r := range 2 {
+		for _, val := range values[r*2000 : (r+1)*2000] {
+			if !allowed[r][val] {
+				t.Fatalf("row %d drew category %v, which has probability zero", r, val)
+			}
+		}
+	}
+}
+
+func TestMultinomialCounts(t *testing.T) {
+	probs := indexTensor(Shape{3, 4}, 0.1, 0.2, 0.3, 0.4, 0, 0, 1, 0, 5, 5, 0, 1)
+
+	res, err := NewRNG(1).Multinomial(probs, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := Values[float64](res)
+	for r := range 3 {
+		sum := 0.0
+		for _, count := range values[r*4 : (r+1)*4] {
+			sum += count
+		}
+		if sum != 25 {
+			t.Errorf("counts of row %d add up to %v, want 25", r, sum)
+		}
+	}
+
+	// A row with all its weight on one category
+	if fmt.Sprint(values[4:8]) != fmt.Sprint([]float64{0, 0, 25, 0}) {
+		t.Errorf("counts %v of a row with one category, want [0 0 25 0]", values[4:8])
+	}
+}
+
+func TestRandomErrors(t *testing.T) {
+	g := NewRNG(1)
+
+	tests := []struct {
+		name string
+		draw func() (Tensor, error)
+	}{
+		{"normal negative std", func() (Tensor, error) { return g.Normal(Shape{2}, 0, -1) }},
+		{"truncated normal negative std", func() (Tensor, error) { return g.TruncatedNormal(Shape{2}, 0, -1) }},
+		{"bernoulli p below 0", func() (Tensor, error) { return g.Bernoulli(Shape{2}, -0.1) }},
+		{"bernoulli p above 1", func() (Tensor, error) { return g.Bernoulli(Shape{2}, 1.1) }},
+		{"categorical negative probability", func() (Tensor, error) {
+			return g.Categorical(indexTensor(Shape{1, 2}, 1, -0.5), 1)
+		}},
+		{"categorical NaN probability", func() (Tensor, error) {
+			return g.Categorical(indexTensor(Shape{1, 2}, 1, math.NaN()), 1)
+		}},
+		{"categorical zero total", func() (Tensor, error) {
+			return g.Categorical(indexTensor(Shape{1, 2}, 0, 0), 1)
+		}},
+		{"categorical negative samples", func() (Tensor, error) {
+			return g.Categorical(indexTensor(Shape{1, 2}, 1, 1), -1)
+		}},
+		{"multinomial NaN probability", func() (Tensor, error) {
+			return g.Multinomial(indexTensor(Shape{1, 2}, math.NaN(), 1), 3)
+		}},
+		{"multinomial negative trials", func() (Tensor, error) {
+			return g.Multinomial(indexTensor(Shape{1, 2}, 1, 1), -1)
+		}},
+		{"permutation of nothing", func() (Tensor, error) { return g.Permutation(0) }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.draw(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package tensor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The reductions below work over any set of axes, negative axes count from
+// the last one and no axes reduces over all of them. With keepDims the reduced
+// axes stay in the shape with a size of 1, so the result broadcasts against
+// the input. Without it they are removed, reducing every axis leaves a shape
+// of (1).
+
+// reducedAxes marks the axes to reduce over.
+func reducedAxes(dims int, axes []int) ([]bool, error) {
+	reduced := make([]bool, dims)
+	if len(axes) == 0 {
+		for i := range reduced {
+			reduced[i] = true
+		}
+		return reduced, nil
+	}
+
+	for _, axis := range axes {
+		// The errors name the axis as it was given
+		idx := axis
+		if idx < 0 {
+			idx += dims
+		}
+
+		if idx < 0 || idx >= dims {
+			return nil, fmt.Errorf("axis %d out of range for %d dimensions", axis, dims)
+		}
+
+		if reduced[idx] {
+			return nil, fmt.Errorf("axis %d given more than once", axis)
+		}
+		reduced[idx] = true
+	}
+
+	return reduced, nil
+}
+
+// reducedShape gives the shape left after reducing shape over the marked axes.
+func reducedShape(shape Shape, reduced []bool, keepDims bool) Shape {
+	resShape := Shape{}
+	for i, dim := range shape {
+		switch {
+		case !reduced[i]:
+			resShape = append(resShape, dim)
+		case keepDims:
+			resShape = append(resShape, 1)
+		}
+	}
+
+	if len(resShape) == 0 {
+		resShape = Shape{1}
+	}
+
+	return resShape
+}
+
+// reduceLoop walks t in row major order and calls fn with the offset of every
+// element in Data, the index of the output it reduces into and its row major
+// position among the elements reduced into the same output.
+func (t *tensor[E]) reduceLoop(reduced []bool, fn func(offset, out, pos int)) {
+	dims := len(t.TShape)
+	outStrides := make([]int, dims)
+	posStrides := make([]int, dims)
+
+	outAcc, posAcc := 1, 1
+	for d := dims - 1; d >= 0; d-- {
+		if reduced[d] {
+			posStrides[d] = posAcc
+			posAcc *= t.TShape[d]
+		} else {
+			outStrides[d] = outAcc
+			outAcc *= t.TShape[d]
+		}
+	}
+
+	strides := [][]int{t.Strides(), outStrides, posStrides}
+	broadcastLoop(t.TShape, strides, func(i int, offsets []int) {
+		fn(offsets[0], offsets[1], offsets[2])
+	})
+}
+
+// reduce folds the values over the axes into accumulators starting at init.
+// It returns the accumulators with their shape, and how many values went into
+// each of them.
+func (t *tensor[E]) reduce(keepDims bool, axes []int, init float64, op func(acc, x float64) float64) ([]float64, Shape, int, error) {
+	if t.Size() == 0 {
+		return nil, nil, 0, errors.New("cannot reduce an empty tensor")
+	}
+
+	reduced, err := reducedAxes(t.Dims(), axes)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	resShape := reducedShape(t.TShape, reduced, keepDims)
+	resData := make([]float64, resShape.TotalSize())
+	for i := range resData {
+		resData[i] = init
+	}
+
+	t.reduceLoop(reduced, func(offset, out, pos int) {
+		resData[out] = op(resData[out], float64(t.Data[offset]))
+	})
+
+	return resData, resShape, t.Size() / len(resData), nil
+}
+
+// SumAxes sums the values over the given axes.
+func (t *tensor[E]) SumAxes(keepDims bool, axes ...int) (Tensor, error) {
+	resData, resShape, _, err := t.reduce(keepDims, axes, 0, func(acc, x float64) float64 {
+		return acc + x
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fromFloat64s(resShape, resData, t.DType()), nil
+}
+
+// ProdAxes multiplies the values over the given axes.
+func (t *tensor[E]) ProdAxes(keepDims bool, axes ...int) (Tensor, error) {
+	resData, resShape, _, err := t.reduce(keepDims, axes, 1, func(acc, x float64) float64 {
+		return acc * x
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fromFloat64s(resShape, resData, t.DType()), nil
+}
+
+// MaxAxes takes the largest value over the given axes.
+func (t *tensor[E]) MaxAxes(keepDims bool, axes ...int) (Tensor, error) {
+	resData, resShape, _, err := t.reduce(keepDims, axes, math.Inf(-1), math.Max)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromFloat64s(resShape, resData, t.DType()), nil
+}
+
+// MinAxes takes the smallest value over the given axes.
+func (t *tensor[E]) MinAxes(keepDims bool, axes ...int) (Tensor, error) {
+	resData, resShape, _, err := t.reduce(keepDims, axes, math.Inf(1), math.Min)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromFloat64s(resShape, resData, t.DType()), nil
+}
+
+// MeanAxes averages the values over the given axes.
+func (t *tensor[E]) MeanAxes(keepDims bool, axes ...int) (Tensor, error) {
+	resData, resShape, _, err := t.mean(keepDims, axes)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromFloat64s(resShape, resData, t.DType()), nil
+}
+
+func (t *tensor[E]) mean(keepDims bool, axes []int) ([]float64, Shape, int, error) {
+	resData, resShape, count, err := t.reduce(keepDims, axes, 0, func(acc, x float64) float64 {
+		return acc + x
+	})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	for i := range resData {
+		resData[i] /= float64(count)
+	}
+
+	return resData, resShape, count, nil
+}
+
+// VarAxes gives the population variance over the given axes, the mean of the
+// squared distances to the mean.
+func (t *tensor[E]) VarAxes(keepDims bool, axes ...int) (Tensor, error) {
+	resData, resShape, err := t.variance(keepDims, axes)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromFloat64s(resShape, resData, t.DType()), nil
+}
+
+// StdAxes gives the population standard deviation over the given axes.
+func (t *tensor[E]) StdAxes(keepDims bool, axes ...int) (Tensor, error) {
+	resData, resShape, err := t.variance(keepDims, axes)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range resData {
+		resData[i] = math.Sqrt(resData[i])
+	}
+
+	return fromFloat64s(resShape, resData, t.DType()), nil
+}
+
+func (t *tensor[E]) variance(keepDims bool, axes []int) ([]float64, Shape, error) {
+	means, resShape, count, err := t.mean(keepDims, axes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Axes were checked by mean
+	reduced, _ := reducedAxes(t.Dims(), axes)
+
+	resData := make([]float64, len(means))
+	t.reduceLoop(reduced, func(offset, out, pos int) {
+		diff := float64(t.Data[offset]) - means[out]
+		resData[out] += diff * diff
+	})
+
+	for i := range resData {
+		resData[i] /= float64(count)
+	}
+
+	return resData, resShape, nil
+}
+
+// ArgMaxAxes gives the position of the largest value over the given axes.
+// Over more than one axis the position is the row major index into the
+// reduced axes. The first position wins a tie.
+func (t *tensor[E]) ArgMaxAxes(keepDims bool, axes ...int) (Tensor, error) {
+	return t.arg(keepDims, axes, func(x, best E) bool { return x > best })
+}
+
+// ArgMinAxes gives the position of the smallest value over the given axes,
+// like ArgMaxAxes.
+func (t *tensor[E]) ArgMinAxes(keepDims bool, axes ...int) (Tensor, error) {
+	return t.arg(keepDims, axes, func(x, best E) bool { return x < best })
+}
+
+func (t *tensor[E]) arg(keepDims bool, axes []int, better func(x, best E) bool) (Tensor, error) {
+	if t.Size() == 0 {
+		return nil, errors.New("cannot reduce an empty tensor")
+	}
+
+	reduced, err := reducedAxes(t.Dims(), axes)
+	if err != nil {
+		return nil, err
+	}
+
+	resShape := reducedShape(t.TShape, reduced, keepDims)
+	resData := make([]float64, resShape.TotalSize())
+	best := make([]E, len(resData))
+
+	t.reduceLoop(reduced, func(offset, out, pos int) {
+		if pos == 0 || better(t.Data[offset], best[out]) {
+			best[out] = t.Data[offset]
+			resData[out] = float64(pos)
+		}
+	})
+
+	return fromFloat64s(resShape, resData, t.DType()), nil
+}
@@ -0,0 +1,87 @@
+package tensor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReductions(t *testing.T) {
+	// x[a][b][c] = 12a + 4b + c
+	x := arange(2, 3, 4)
+	y := indexTensor(Shape{2, 3}, 1, 5, 5, 7, -2, 0)
+
+	tests := []struct {
+		name   string
+		reduce func() (Tensor, error)
+		shape  Shape
+		want   []float64
+	}{
+		{"sum axis 0", func() (Tensor, error) { return x.SumAxes(false, 0) }, Shape{3, 4},
+			[]float64{12, 14, 16, 18, 20, 22, 24, 26, 28, 30, 32, 34}},
+		{"sum last axis keepdims", func() (Tensor, error) { return x.SumAxes(true, -1) }, Shape{2, 3, 1},
+			[]float64{6, 22, 38, 54, 70, 86}},
+		{"sum two axes", func() (Tensor, error) { return x.SumAxes(false, 0, 2) }, Shape{3},
+			[]float64{60, 92, 124}},
+		{"sum all", func() (Tensor, error) { return x.SumAxes(false) }, Shape{1},
+			[]float64{276}},
+		{"sum of a transpose", func() (Tensor, error) { return arange(3, 4).Transpose(false).SumAxes(false, 0) }, Shape{3},
+			[]float64{6, 22, 38}},
+		{"mean axis 1", func() (Tensor, error) { return x.MeanAxes(false, 1) }, Shape{2, 4},
+			[]float64{4, 5, 6, 7, 16, 17, 18, 19}},
+		{"mean negative axes keepdims", func() (Tensor, error) { return x.MeanAxes(true, -1, -3) }, Shape{1, 3, 1},
+			[]float64{7.5, 11.5, 15.5}},
+		{"max axis 2", func() (Tensor, error) { return x.MaxAxes(false, 2) }, Shape{2, 3},
+			[]float64{3, 7, 11, 15, 19, 23}},
+		{"max all keepdims", func() (Tensor, error) { return x.MaxAxes(true) }, Shape{1, 1, 1},
+			[]float64{23}},
+		{"argmax rows, first of a tie", func() (Tensor, error) { return y.ArgMaxAxes(false, 1) }, Shape{2},
+			[]float64{1, 0}},
+		{"argmax columns", func() (Tensor, error) { return y.ArgMaxAxes(false, 0) }, Shape{3},
+			[]float64{1, 0, 0}},
+		{"argmax negative axis keepdims", func() (Tensor, error) { return y.ArgMaxAxes(true, -1) }, Shape{2, 1},
+			[]float64{1, 0}},
+		{"argmax all", func() (Tensor, error) { return y.ArgMaxAxes(false) }, Shape{1},
+			[]float64{3}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.reduce()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertExact(t, tc.name, res, tc.shape, tc.want...)
+		})
+	}
+}
+
+func TestReductionAxisErrors(t *testing.T) {
+	x := arange(2, 3, 4)
+
+	tests := []struct {
+		name string
+		axes []int
+		want string
+	}{
+		{"axis too large", []int{3}, "axis 3 out of range"},
+		{"negative axis too small", []int{-5}, "axis -5 out of range"},
+		{"duplicate axis", []int{1, 1}, "axis 1 given more than once"},
+		{"duplicate through a negative axis", []int{0, -3}, "axis -3 given more than once"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, reduce := range map[string]func(bool, ...int) (Tensor, error){
+				"SumAxes":    x.SumAxes,
+				"MeanAxes":   x.MeanAxes,
+				"MaxAxes":    x.MaxAxes,
+				"ArgMaxAxes": x.ArgMaxAxes,
+			} {
+				_, err := reduce(false, tc.axes...)
+				if err == nil || !strings.Contains(err.Error(), tc.want) {
+					t.Errorf("%s(%v): error %v, want one containing %q", name, tc.axes, err, tc.want)
+				}
+			}
+		})
+	}
+}
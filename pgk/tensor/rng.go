@@ -0,0 +1,110 @@
+package tensor
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// RNG is a seedable source of random numbers for tensors. Two RNGs made with
+// the same seed give the same tensors, initializations and shuffles. An RNG
+// can be shared between goroutines.
+type RNG struct {
+	mu  sync.Mutex
+	src *rand.Rand
+}
+
+// NewRNG creates an RNG with the given seed.
+func NewRNG(seed int64) *RNG {
+	return &RNG{src: rand.New(rand.NewSource(seed))}
+}
+
+// globalRNG is used by RandTensor, Shuffle and the layers that aren't given an
+// RNG. It starts from a random seed and is swapped atomically, so SetSeed and
+// SetRNG can be called while other goroutines draw from it.
+var globalRNG atomic.Pointer[RNG]
+
+func init() {
+	globalRNG.Store(NewRNG(rand.Int63()))
+}
+
+// SetSeed replaces the global RNG with one made from seed, which makes the
+// functions that use it reproducible.
+func SetSeed(seed int64) {
+	globalRNG.Store(NewRNG(seed))
+}
+
+// SetRNG replaces the global RNG.
+func SetRNG(rng *RNG) error {
+	if rng == nil {
+		return errors.New("rng cannot be nil")
+	}
+
+	globalRNG.Store(rng)
+	return nil
+}
+
+// GlobalRNG returns the RNG used when none is given.
+func GlobalRNG() *RNG {
+	return globalRNG.Load()
+}
+
+// OrGlobal returns the RNG, or the global RNG when it is nil. It lets structs
+// with an optional RNG field fall back to the global one.
+func (g *RNG) OrGlobal() *RNG {
+	if g == nil {
+		return GlobalRNG()
+	}
+	return g
+}
+
+// Float64 returns a number in [0, 1).
+func (g *RNG) Float64() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.src.Float64()
+}
+
+// Intn returns a number in [0, n).
+func (g *RNG) Intn(n int) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.src.Intn(n)
+}
+
+// RandTensor creates a tensor with the default dtype filled with values drawn
+// uniformly between minVal and maxVal.
+func (g *RNG) RandTensor(shape Shape, minVal, maxVal float64) (Tensor, error) {
+	if minVal > maxVal {
+		return nil, errors.New("minVal bigger than maxVal")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	resData := make([]float64, shape.TotalSize())
+	for i := range resData {
+		resData[i] = minVal + g.src.Float64()*(maxVal-minVal)
+	}
+
+	return fromFloat64s(shape, resData, defaultDType), nil
+}
+
+// Shuffle shuffles the batches of x and y in place, keeping the batches of the
+// two at the same positions.
+func (g *RNG) Shuffle(x, y Tensor) error {
+	n := x.Shape().Batches()
+	for i := n - 1; i > 0; i-- {
+		j := g.Intn(i + 1)
+
+		if err := swapBatches(x, i, j); err != nil {
+			return err
+		}
+
+		if err := swapBatches(y, i, j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
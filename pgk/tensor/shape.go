@@ -2,6 +2,7 @@ package tensor
 
 import (
 	"errors"
+	"fmt"
 )
 
 type Shape []int
@@ -61,6 +62,9 @@ func (s Shape) CalcStrides() []int {
 	return strides
 }
 
+// Transpose swaps the last two dimensions of s in place and returns it, like
+// Tensor.Transpose does for the shape of a tensor. Shape.Permute gives any
+// other order of the dimensions.
 func (s Shape) Transpose() Shape {
 	s[len(s)-2], s[len(s)-1] = s[len(s)-1], s[len(s)-2]
 	return s
@@ -90,10 +94,18 @@ func (s Shape) Dims() int {
 	return len(s)
 }
 
+// Eq reports if s and other have the same number of dimensions, all of the
+// same size.
 func (s Shape) Eq(other Shape) bool {
-  if s.Batches() != other.Batches() || s.Channels() != other.Channels() || s.Rows() != other.Rows() || s.Cols() != other.Cols() {
-    return false
-  }
+	if len(s) != len(other) {
+		return false
+	}
+
+	for i := range s {
+		if s[i] != other[i] {
+			return false
+		}
+	}
 
 	return true
 }
@@ -131,3 +143,134 @@ func (s Shape) TotalSize() int {
 func (s Shape) IsScalar() bool {
 	return len(s) == 0
 }
+
+// Permute returns the shape with its dimensions in the order of axes, where
+// axes[i] is the dimension that ends up at i. No axes reverses the dimensions.
+func (s Shape) Permute(axes ...int) (Shape, error) {
+	order, err := permutation(len(s), axes)
+	if err != nil {
+		return nil, err
+	}
+
+	newShape := make(Shape, len(s))
+	for i, axis := range order {
+		newShape[i] = s[axis]
+	}
+	return newShape, nil
+}
+
+// Squeeze removes the given dimensions of size 1, or all of them when no axes
+// are given. Squeezing out every dimension leaves a shape of (1).
+func (s Shape) Squeeze(axes ...int) (Shape, error) {
+	keep, err := squeezed(s, axes)
+	if err != nil {
+		return nil, err
+	}
+
+	newShape := Shape{}
+	for i, dim := range s {
+		if keep[i] {
+			newShape = append(newShape, dim)
+		}
+	}
+
+	if len(newShape) == 0 {
+		newShape = Shape{1}
+	}
+	return newShape, nil
+}
+
+// ExpandDims inserts a dimension of size 1 at axis, negative axes count back
+// from the end of the new shape.
+func (s Shape) ExpandDims(axis int) (Shape, error) {
+	axis, err := expandedAxis(len(s), axis)
+	if err != nil {
+		return nil, err
+	}
+
+	newShape := make(Shape, 0, len(s)+1)
+	newShape = append(newShape, s[:axis]...)
+	newShape = append(newShape, 1)
+	return append(newShape, s[axis:]...), nil
+}
+
+// permutation checks that axes is an order of dims dimensions, turning
+// negative axes into their positive position.
+func permutation(dims int, axes []int) ([]int, error) {
+	order := make([]int, dims)
+	if len(axes) == 0 {
+		for i := range order {
+			order[i] = dims - 1 - i
+		}
+		return order, nil
+	}
+
+	if len(axes) != dims {
+		return nil, fmt.Errorf("%d axes given to permute %d dimensions", len(axes), dims)
+	}
+
+	seen := make([]bool, dims)
+	for i, axis := range axes {
+		if axis < 0 {
+			axis += dims
+		}
+
+		if axis < 0 || axis >= dims {
+			return nil, fmt.Errorf("axis %d out of range for %d dimensions", axes[i], dims)
+		}
+
+		if seen[axis] {
+			return nil, fmt.Errorf("axis %d given more than once", axes[i])
+		}
+
+		seen[axis] = true
+		order[i] = axis
+	}
+
+	return order, nil
+}
+
+// squeezed marks the dimensions that stay after squeezing out axes.
+func squeezed(s Shape, axes []int) ([]bool, error) {
+	keep := make([]bool, len(s))
+	if len(axes) == 0 {
+		for i, dim := range s {
+			keep[i] = dim != 1
+		}
+		return keep, nil
+	}
+
+	for i := range keep {
+		keep[i] = true
+	}
+
+	for _, given := range axes {
+		axis := given
+		if axis < 0 {
+			axis += len(s)
+		}
+
+		if axis < 0 || axis >= len(s) {
+			return nil, fmt.Errorf("axis %d out of range for %d dimensions", given, len(s))
+		}
+
+		if s[axis] != 1 {
+			return nil, fmt.Errorf("cannot squeeze axis %d of size %d", given, s[axis])
+		}
+		keep[axis] = false
+	}
+
+	return keep, nil
+}
+
+func expandedAxis(dims, given int) (int, error) {
+	axis := given
+	if axis < 0 {
+		axis += dims + 1
+	}
+
+	if axis < 0 || axis > dims {
+		return 0, fmt.Errorf("axis %d out of range for %d dimensions", given, dims+1)
+	}
+	return axis, nil
+}
@@ -0,0 +1,30 @@
+package tensor
+
+import "testing"
+
+func TestShapeEq(t *testing.T) {
+	cases := []struct {
+		a, b Shape
+		want bool
+	}{
+		{Shape{3, 4}, Shape{3, 4}, true},
+		{Shape{2, 3, 4, 5, 6}, Shape{2, 3, 4, 5, 6}, true},
+		{Shape{}, Shape{}, true},
+		{Shape{3, 4}, Shape{4, 3}, false},
+		{Shape{3, 4}, Shape{1, 1, 3, 4}, false},
+		{Shape{4}, Shape{1, 4}, false},
+		{Shape{3, 1}, Shape{1, 3}, false},
+		// The same last four dimensions, different leading ones
+		{Shape{2, 1, 1, 3, 4}, Shape{5, 1, 1, 3, 4}, false},
+		{Shape{2, 3, 1, 1, 1}, Shape{3, 2, 1, 1, 1}, false},
+	}
+
+	for _, tc := range cases {
+		if got := tc.a.Eq(tc.b); got != tc.want {
+			t.Errorf("%v.Eq(%v) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+		if got := tc.b.Eq(tc.a); got != tc.want {
+			t.Errorf("%v.Eq(%v) = %v, want %v", tc.b, tc.a, got, tc.want)
+		}
+	}
+}
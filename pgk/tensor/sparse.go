@@ -0,0 +1,286 @@
+package tensor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// CSR is a sparse matrix in compressed sparse row format. The column indices
+// and values of row i are at positions indptr[i] up to indptr[i+1], with the
+// columns of a row in increasing order. Only the nonzero values are stored,
+// which suits inputs like bag of words or one hot vectors.
+type CSR struct {
+	shape   Shape
+	indptr  []int
+	indices []int
+	values  []float64
+}
+
+// COO is a sparse matrix in coordinate format, a list of (row, col, value)
+// entries in any order. It is easy to build and is turned into a CSR for
+// computing, entries at the same position are summed then.
+type COO struct {
+	shape  Shape
+	rows   []int
+	cols   []int
+	values []float64
+}
+
+// NewCSR creates a rows by cols CSR matrix from its row pointers, column
+// indices and values, which are used without copying.
+func NewCSR(rows, cols int, indptr, indices []int, values []float64) (*CSR, error) {
+	if rows < 0 || cols < 0 {
+		return nil, errors.New("Dimensions cannot be negative")
+	}
+
+	if len(indptr) != rows+1 || indptr[0] != 0 {
+		return nil, fmt.Errorf("indptr needs %d entries starting at 0", rows+1)
+	}
+
+	if len(indices) != len(values) || indptr[rows] != len(values) {
+		return nil, errors.New("indptr, indices and values don't match")
+	}
+
+	for r := 0; r < rows; r++ {
+		if indptr[r+1] < indptr[r] {
+			return nil, errors.New("indptr has to be non decreasing")
+		}
+
+		for k := indptr[r]; k < indptr[r+1]; k++ {
+			if indices[k] < 0 || indices[k] >= cols {
+				return nil, fmt.Errorf("column %d out of range for %d columns", indices[k], cols)
+			}
+			if k > indptr[r] && indices[k] <= indices[k-1] {
+				return nil, fmt.Errorf("columns of row %d have to be increasing", r)
+			}
+		}
+	}
+
+	return &CSR{shape: Shape{rows, cols}, indptr: indptr, indices: indices, values: values}, nil
+}
+
+// NewCOO creates a rows by cols COO matrix from the positions and values of
+// its entries.
+func NewCOO(rows, cols int, rowIdx, colIdx []int, values []float64) (*COO, error) {
+	if rows < 0 || cols < 0 {
+		return nil, errors.New("Dimensions cannot be negative")
+	}
+
+	if len(rowIdx) != len(values) || len(colIdx) != len(values) {
+		return nil, errors.New("rows, cols and values need the same length")
+	}
+
+	for i := range values {
+		if rowIdx[i] < 0 || rowIdx[i] >= rows || colIdx[i] < 0 || colIdx[i] >= cols {
+			return nil, fmt.Errorf("entry (%d, %d) out of range for %v", rowIdx[i], colIdx[i], Shape{rows, cols})
+		}
+	}
+
+	return &COO{shape: Shape{rows, cols}, rows: rowIdx, cols: colIdx, values: values}, nil
+}
+
+// ToCSR stores the nonzero values of a dense tensor in a CSR matrix. Like
+// Dense, every dimension in front of the last is read as rows, so a tensor of
+// shape (batches, 1, 1, cols) becomes a batches by cols matrix.
+func ToCSR(t Tensor) (*CSR, error) {
+	if t == nil {
+		return nil, errors.New("Tensor cannot be nil")
+	}
+
+	cols := t.Shape().Cols()
+	rows := 0
+	if cols > 0 {
+		rows = t.Size() / cols
+	}
+
+	values := float64s(t)
+	csr := &CSR{shape: Shape{rows, cols}, indptr: make([]int, rows+1)}
+	for r := 0; r < rows; r++ {
+		for c, val := range values[r*cols : (r+1)*cols] {
+			if val != 0 {
+				csr.indices = append(csr.indices, c)
+				csr.values = append(csr.values, val)
+			}
+		}
+		csr.indptr[r+1] = len(csr.values)
+	}
+
+	return csr, nil
+}
+
+// Shape returns the shape of the matrix, (rows, cols).
+func (c *CSR) Shape() Shape {
+	return c.shape
+}
+
+// NNZ returns the number of stored values.
+func (c *CSR) NNZ() int {
+	return len(c.values)
+}
+
+// Row returns the column indices and values of row r, sharing the storage of
+// the matrix.
+func (c *CSR) Row(r int) ([]int, []float64) {
+	return c.indices[c.indptr[r]:c.indptr[r+1]], c.values[c.indptr[r]:c.indptr[r+1]]
+}
+
+// ToDense creates a dense tensor of the given dtype holding the matrix.
+func (c *CSR) ToDense(dtype DType) Tensor {
+	resData := make([]float64, c.shape.TotalSize())
+	cols := c.shape.Cols()
+	for r := 0; r < c.shape.Rows(); r++ {
+		for k := c.indptr[r]; k < c.indptr[r+1]; k++ {
+			resData[r*cols+c.indices[k]] = c.values[k]
+		}
+	}
+	return fromFloat64s(c.shape, resData, dtype)
+}
+
+// ToCOO lists the stored values of the matrix as COO entries.
+func (c *CSR) ToCOO() *COO {
+	coo := &COO{shape: c.shape.Clone(), cols: append([]int(nil), c.indices...), values: append([]float64(nil), c.values...)}
+	for r := 0; r < c.shape.Rows(); r++ {
+		for k := c.indptr[r]; k < c.indptr[r+1]; k++ {
+			coo.rows = append(coo.rows, r)
+		}
+	}
+	return coo
+}
+
+// RowSlice returns the rows from start up to end as a CSR matrix, e.g. to cut
+// a data set into batches. The column indices and values are shared.
+func (c *CSR) RowSlice(start, end int) (*CSR, error) {
+	if start < 0 || end > c.shape.Rows() || start > end {
+		return nil, fmt.Errorf("rows %d to %d out of range for %d rows", start, end, c.shape.Rows())
+	}
+
+	indptr := make([]int, end-start+1)
+	for r := range indptr {
+		indptr[r] = c.indptr[start+r] - c.indptr[start]
+	}
+
+	lo, hi := c.indptr[start], c.indptr[end]
+	return &CSR{shape: Shape{end - start, c.shape.Cols()}, indptr: indptr, indices: c.indices[lo:hi:hi], values: c.values[lo:hi:hi]}, nil
+}
+
+// Shape returns the shape of the matrix, (rows, cols).
+func (c *COO) Shape() Shape {
+	return c.shape
+}
+
+// NNZ returns the number of entries.
+func (c *COO) NNZ() int {
+	return len(c.values)
+}
+
+// ToCSR sorts the entries into a CSR matrix, summing entries at the same
+// position.
+func (c *COO) ToCSR() *CSR {
+	order := make([]int, len(c.values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if c.rows[a] != c.rows[b] {
+			return c.rows[a] < c.rows[b]
+		}
+		return c.cols[a] < c.cols[b]
+	})
+
+	rows := c.shape.Rows()
+	csr := &CSR{shape: c.shape.Clone(), indptr: make([]int, rows+1)}
+	for i, idx := range order {
+		last := len(csr.values) - 1
+		if i > 0 && c.rows[idx] == c.rows[order[i-1]] && c.cols[idx] == csr.indices[last] {
+			csr.values[last] += c.values[idx]
+			continue
+		}
+
+		csr.indices = append(csr.indices, c.cols[idx])
+		csr.values = append(csr.values, c.values[idx])
+		csr.indptr[c.rows[idx]+1] = len(csr.values)
+	}
+
+	// Rows without entries end where the row before them ends
+	for r := 1; r <= rows; r++ {
+		csr.indptr[r] = max(csr.indptr[r], csr.indptr[r-1])
+	}
+
+	return csr
+}
+
+// ToDense creates a dense tensor of the given dtype holding the matrix.
+func (c *COO) ToDense(dtype DType) Tensor {
+	return c.ToCSR().ToDense(dtype)
+}
+
+// MatMul multiplies the sparse matrix by a dense matrix, only going over the
+// stored values. The result is a dense (rows, other cols) matrix with the
+// dtype of other.
+func (c *CSR) MatMul(other Tensor) (Tensor, error) {
+	if other == nil {
+		return nil, errors.New("Tensor cannot be nil")
+	}
+
+	if !singleMatrix(other.Shape()) || c.shape.Cols() != other.Shape().Rows() {
+		return nil, shapeMismatch("sparse MatMul", Shape{c.shape.Cols(), other.Shape().Cols()}, other.Shape())
+	}
+
+	if other.DType() == Float32 {
+		return sparseMatMul(c, asType[float32](other), false), nil
+	}
+	return sparseMatMul(c, asType[float64](other), false), nil
+}
+
+// TransposeMatMul multiplies the transpose of the sparse matrix by a dense
+// matrix without building the transpose, like the weight gradient of Dense
+// for a sparse input. The result is a dense (cols, other cols) matrix with the
+// dtype of other.
+func (c *CSR) TransposeMatMul(other Tensor) (Tensor, error) {
+	if other == nil {
+		return nil, errors.New("Tensor cannot be nil")
+	}
+
+	if !singleMatrix(other.Shape()) || c.shape.Rows() != other.Shape().Rows() {
+		return nil, shapeMismatch("sparse TransposeMatMul", Shape{c.shape.Rows(), other.Shape().Cols()}, other.Shape())
+	}
+
+	if other.DType() == Float32 {
+		return sparseMatMul(c, asType[float32](other), true), nil
+	}
+	return sparseMatMul(c, asType[float64](other), true), nil
+}
+
+// sparseMatMul adds a scaled row of b into the result for every stored value.
+// For row r and column k that is row k of b into row r of the result, or with
+// transpose row r of b into row k.
+func sparseMatMul[E Float](c *CSR, b *tensor[E], transpose bool) *tensor[E] {
+	bMat := matrixOf(b)
+	p := bMat.cols
+
+	resRows := c.shape.Rows()
+	if transpose {
+		resRows = c.shape.Cols()
+	}
+	resTen := zeros[E](Shape{resRows, p})
+
+	for r := 0; r < c.shape.Rows(); r++ {
+		for k := c.indptr[r]; k < c.indptr[r+1]; k++ {
+			val := E(c.values[k])
+			resRow, bRow := r, c.indices[k]
+			if transpose {
+				resRow, bRow = bRow, r
+			}
+
+			out := resTen.Data[resRow*p : (resRow+1)*p]
+			offset := bRow * bMat.rowStride
+			for j := range out {
+				out[j] += val * bMat.data[offset+j*bMat.colStride]
+			}
+		}
+	}
+
+	return resTen
+}
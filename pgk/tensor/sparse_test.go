@@ -0,0 +1,135 @@
+package tensor
+
+import (
+	"math"
+	"testing"
+)
+
+// sparseFixture is a 5 by 6 matrix with empty rows and two entries at the
+// same position, which COO.ToCSR sums.
+func sparseFixture(t *testing.T) (*CSR, Tensor) {
+	rows := []int{0, 0, 2, 2, 2, 4, 0}
+	cols := []int{1, 4, 0, 5, 0, 3, 1}
+	values := []float64{2, -1, 3, 0.5, 1.5, -4, 1}
+
+	coo, err := NewCOO(5, 6, rows, cols, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dense, _ := TensorFrom([]int{5, 6}, []float64{
+		0, 3, 0, 0, -1, 0,
+		0, 0, 0, 0, 0, 0,
+		4.5, 0, 0, 0, 0, 0.5,
+		0, 0, 0, 0, 0, 0,
+		0, 0, 0, -4, 0, 0,
+	})
+	return coo.ToCSR(), dense
+}
+
+func assertValues(t *testing.T, name string, got Tensor, want Tensor) {
+	t.Helper()
+
+	if !got.Shape().DeepEq(want.Shape()) {
+		t.Fatalf("%s: shape %v, want %v", name, got.Shape(), want.Shape())
+	}
+
+	expected := Values[float64](want)
+	for i, val := range Values[float64](got) {
+		if math.Abs(val-expected[i]) > 1e-9 {
+			t.Fatalf("%s: value %d is %v, want %v", name, i, val, expected[i])
+		}
+	}
+}
+
+func TestCOOToCSR(t *testing.T) {
+	csr, dense := sparseFixture(t)
+
+	if csr.NNZ() != 5 {
+		t.Errorf("%d stored values, want 5 with the duplicates summed", csr.NNZ())
+	}
+
+	for _, r := range []int{1, 3} {
+		if cols, _ := csr.Row(r); len(cols) != 0 {
+			t.Errorf("row %d holds columns %v, want none", r, cols)
+		}
+	}
+
+	assertValues(t, "ToDense", csr.ToDense(Float64), dense)
+	assertValues(t, "COO round trip", csr.ToCOO().ToDense(Float64), dense)
+
+	fromDense, err := ToCSR(dense)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertValues(t, "ToCSR", fromDense.ToDense(Float64), dense)
+}
+
+func TestSparseMatMul(t *testing.T) {
+	csr, dense := sparseFixture(t)
+	rng := NewRNG(1)
+
+	for _, dtype := range []DType{Float64, Float32} {
+		other, _ := rng.RandTensor(Shape{6, 3}, -1, 1)
+		other = other.AsType(dtype)
+
+		want, _ := dense.AsType(dtype).MatMul(other)
+		got, err := csr.MatMul(other)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.DType() != dtype {
+			t.Errorf("dtype %v, want the dtype of the dense operand %v", got.DType(), dtype)
+		}
+		assertValues(t, "MatMul "+string(dtype), got, want)
+
+		// A transposed view of the dense operand
+		otherT, _ := rng.RandTensor(Shape{3, 6}, -1, 1)
+		want, _ = dense.MatMul(otherT.Transpose(false))
+		got, _ = csr.MatMul(otherT.Transpose(false))
+		assertValues(t, "MatMul transposed "+string(dtype), got, want)
+
+		gradient, _ := rng.RandTensor(Shape{5, 3}, -1, 1)
+		gradient = gradient.AsType(dtype)
+		want, _ = dense.Transpose(false).AsType(dtype).MatMul(gradient)
+		got, err = csr.TransposeMatMul(gradient)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertValues(t, "TransposeMatMul "+string(dtype), got, want)
+	}
+
+	if _, err := csr.MatMul(Zeros(Shape{5, 3}, Float64)); err == nil {
+		t.Error("MatMul with 5 rows: expected an error")
+	}
+	if _, err := csr.TransposeMatMul(Zeros(Shape{6, 3}, Float64)); err == nil {
+		t.Error("TransposeMatMul with 6 rows: expected an error")
+	}
+}
+
+func TestNewCSRErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		indptr  []int
+		indices []int
+		values  []float64
+	}{
+		{"short indptr", []int{0, 1}, []int{0}, []float64{1}},
+		{"decreasing indptr", []int{0, 2, 1}, []int{0, 1}, []float64{1, 2}},
+		{"column out of range", []int{0, 1, 1}, []int{3}, []float64{1}},
+		{"unsorted columns", []int{0, 2, 2}, []int{1, 0}, []float64{1, 2}},
+		{"duplicate columns", []int{0, 2, 2}, []int{1, 1}, []float64{1, 2}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewCSR(2, 3, tc.indptr, tc.indices, tc.values); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if _, err := NewCOO(2, 3, []int{2}, []int{0}, []float64{1}); err == nil {
+		t.Error("COO entry out of range: expected an error")
+	}
+}
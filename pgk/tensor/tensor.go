@@ -4,8 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"math/rand"
-	"sync"
+	"sync/atomic"
 )
 
 type Number interface {
@@ -20,7 +19,8 @@ type Tensor interface {
 	Size() int
 
 	// Data Access
-	data() *[]float64
+	DType() DType
+	AsType(dtype DType) Tensor
 	DataCopy() []float64
 	ValueAt(index int) float64
 	SetValueAt(index int, value float64) error
@@ -29,6 +29,9 @@ type Tensor interface {
 	// Stuff Like Transpose
 	Transpose(inPlace bool) Tensor
 	Reshape(shape Shape) error
+	Permute(axes ...int) (Tensor, error)
+	Squeeze(axes ...int) (Tensor, error)
+	ExpandDims(axis int) (Tensor, error)
 
 	// Mapping functions
 	Map(fn func(float64) (float64, error), inPlace bool) (Tensor, error)
@@ -48,6 +51,23 @@ type Tensor interface {
 	ScalarMultiply(x float64, inPlace bool) Tensor
 	ScalarDivide(x float64, inPlace bool) (Tensor, error)
 
+	// Elementwise math
+	Exp(inPlace bool) Tensor
+	Log(inPlace bool) Tensor
+	Sqrt(inPlace bool) Tensor
+	Pow(exponent float64, inPlace bool) Tensor
+	Abs(inPlace bool) Tensor
+	Tanh(inPlace bool) Tensor
+	Sign(inPlace bool) Tensor
+	Clip(minVal, maxVal float64, inPlace bool) (Tensor, error)
+	Maximum(other Tensor, inPlace bool) (Tensor, error)
+	Minimum(other Tensor, inPlace bool) (Tensor, error)
+
+	// Comparisons giving masks of ones and zeros
+	Equal(other Tensor) (Tensor, error)
+	Greater(other Tensor) (Tensor, error)
+	Less(other Tensor) (Tensor, error)
+
 	Sum() float64
 	Avg() float64
 	Min() float64
@@ -59,6 +79,17 @@ type Tensor interface {
 
 	ArgMax(axis int) ([]int, error)
 
+	// Reductions over any set of axes
+	SumAxes(keepDims bool, axes ...int) (Tensor, error)
+	MeanAxes(keepDims bool, axes ...int) (Tensor, error)
+	MaxAxes(keepDims bool, axes ...int) (Tensor, error)
+	MinAxes(keepDims bool, axes ...int) (Tensor, error)
+	VarAxes(keepDims bool, axes ...int) (Tensor, error)
+	StdAxes(keepDims bool, axes ...int) (Tensor, error)
+	ProdAxes(keepDims bool, axes ...int) (Tensor, error)
+	ArgMaxAxes(keepDims bool, axes ...int) (Tensor, error)
+	ArgMinAxes(keepDims bool, axes ...int) (Tensor, error)
+
 	AxisSum(axis int) (Tensor, error)
 
 	Pad(pads ...int) (Tensor, error)
@@ -73,62 +104,58 @@ type Tensor interface {
 
 	CrossCorrelate(kernels Tensor, strides [2]int, resTen Tensor) (Tensor, error)
 	Convolve(kernels Tensor, strides [2]int, resTen Tensor) (Tensor, error)
+	Im2Col(kernelSize, strides [2]int) (Tensor, error)
+	Col2Im(shape Shape, kernelSize, strides [2]int) (Tensor, error)
+
+	Take(indices []int) (Tensor, error)
+	IndexSelect(axis int, indices []int) (Tensor, error)
+	Gather(axis int, index Tensor) (Tensor, error)
+	ScatterAdd(axis int, index Tensor, src Tensor, inPlace bool) (Tensor, error)
 
 	Slice(start, end int) (Tensor, error)
 	RegionSlice(startRow, startCol, numRows, numCols int) (Tensor, []int, error)
 	BatchSlice(startBatch, endBatch int) (Tensor, error)
 
+	// Formatting
+	String() string
+	Format(f fmt.State, verb rune)
 	Print()
-}
-
-func (t *tensor) Print() {
-
-	tIter, _ := IterFromTensor(t, "")
 
-	for ten, ok := tIter.Next(); ok; ten, ok = tIter.Next() {
-
-		rows := ten.Shape().Rows()
-		cols := ten.Shape().Cols()
-
-		tData := *ten.data()
-		fmt.Printf("\n")
-		for i := range rows {
-			fmt.Printf("\n")
-			for j := range cols {
-				fmt.Printf("%v ", tData[i*cols+j])
-			}
-		}
-	}
+	// Used to reach the typed storage from code that doesn't know the dtype
+	view(start int, shape Shape, strides []int) Tensor
+	assignFrom(other Tensor)
+	release()
 }
 
-type tensor struct {
-	TShape Shape
-	Data   []float64
+type tensor[E Float] struct {
+	TShape  Shape
+	Data    []E
+	strides []int
+	pooled  *[]E // Hands Data back to the pool, nil when it isn't from the pool
+
+	// Set once a view shares Data, the buffer then stays out of the pool
+	shared atomic.Bool
 }
 
+// ZerosTensor creates a tensor of zeros with the default dtype.
 func ZerosTensor(shape Shape) Tensor {
-	return &tensor{TShape: shape.Clone(), Data: make([]float64, shape.TotalSize())}
+	return Zeros(shape, defaultDType)
 }
 
+// RandTensor creates a tensor with the default dtype filled with values drawn
+// uniformly between minVal and maxVal by the global RNG.
 func RandTensor(shape Shape, minVal, maxVal float64) (Tensor, error) {
-	if minVal > maxVal {
-		return nil, errors.New("minVal bigger than maxVal")
-	}
-
-	resTen := tensor{TShape: shape.Clone(), Data: make([]float64, shape.TotalSize())}
-	for i := range resTen.Data {
-		resTen.Data[i] = minVal + rand.Float64()*(maxVal-minVal)
-	}
-
-	return &resTen, nil
+	return GlobalRNG().RandTensor(shape, minVal, maxVal)
 }
 
+// TensorFrom creates a tensor with the default dtype from input. With the
+// Float64 dtype input is used as the data of the tensor without copying.
 func TensorFrom(shape Shape, input []float64) (Tensor, error) {
 	if shape.TotalSize() != len(input) {
-		return nil, errors.New("Shape doesn't match size of input")
+		return nil, shapeMismatch("TensorFrom", shape, Shape{len(input)})
 	}
 
-	return &tensor{TShape: shape.Clone(), Data: input}, nil
+	return fromFloat64s(shape, input, defaultDType), nil
 }
 
 func TensorFromMatrix(input *[][]float64) (Tensor, error) {
@@ -138,15 +165,15 @@ func TensorFromMatrix(input *[][]float64) (Tensor, error) {
 		return nil, errors.New("cannot have an empty matrix")
 	}
 
-	resTen := tensor{TShape: []int{rows, cols}, Data: make([]float64, rows*cols)}
+	resData := make([]float64, rows*cols)
 
 	for i := range rows {
 		for j := range cols {
-			resTen.Data[i*cols+j] = (*input)[i][j]
+			resData[i*cols+j] = (*input)[i][j]
 		}
 	}
 
-	return &resTen, nil
+	return fromFloat64s([]int{rows, cols}, resData, defaultDType), nil
 }
 
 func Identity(n int) (Tensor, error) {
@@ -162,7 +189,7 @@ func Identity(n int) (Tensor, error) {
 	return resTen, nil
 }
 
-func (t *tensor) CrossCorrelate(kernels Tensor, strides [2]int, resTen Tensor) (Tensor, error) {
+func (t *tensor[E]) CrossCorrelate(kernels Tensor, strides [2]int, resTen Tensor) (Tensor, error) {
 	// Check that the input tensor and kernels have the same number of channels
 	if t.Shape().Channels() != kernels.Shape().Channels() {
 		return nil, errors.New("input doesn't have the same amount of channels as filters")
@@ -177,20 +204,25 @@ func (t *tensor) CrossCorrelate(kernels Tensor, strides [2]int, resTen Tensor) (
 	// Create or check result tensor dimensions
 	outShape := []int{outRows, outCols}
 	if resTen == nil {
-		resTen = ZerosTensor(outShape)
+		resTen = zeros[E](outShape)
 	} else if !resTen.Shape().Eq(outShape) {
 		return nil, errors.New("resTen doesn't have right dimensions to hold cross correlation result")
 	}
 
+	if resTen.DType() != t.DType() {
+		return nil, errors.New("resTen doesn't have the same dtype as the input")
+	}
+
 	// Access tensor data references
-	matData := *t.data()
-	kernelData := *kernels.data()
-	resData := *resTen.data()
+	matData := t.values()
+	kernelData := asType[E](kernels).values()
+	res := asType[E](resTen)
+	resData := res.buffer()
 
 	// Iterate through the input tensor
 	for i := 0; i < outRows; i++ {
 		for j := 0; j < outCols; j++ {
-			sum := 0.0
+			var sum E
 
 			// Cross-correlation operation
 			for ki := 0; ki < kRows; ki++ {
@@ -207,11 +239,12 @@ func (t *tensor) CrossCorrelate(kernels Tensor, strides [2]int, resTen Tensor) (
 			resData[i*outCols+j] += sum
 		}
 	}
+	res.flush(resData)
 
 	return resTen, nil
 }
 
-func (t *tensor) Convolve(kernels Tensor, strides [2]int, resTen Tensor) (Tensor, error) {
+func (t *tensor[E]) Convolve(kernels Tensor, strides [2]int, resTen Tensor) (Tensor, error) {
 	// Check that the input tensor and kernels have the same number of channels
 	if t.Shape().Channels() != kernels.Shape().Channels() {
 		return nil, errors.New("input doesn't have the same amount of channels as filters")
@@ -226,20 +259,25 @@ func (t *tensor) Convolve(kernels Tensor, strides [2]int, resTen Tensor) (Tensor
 	// Create or check result tensor dimensions
 	outShape := []int{outRows, outCols}
 	if resTen == nil {
-		resTen = ZerosTensor(outShape)
+		resTen = zeros[E](outShape)
 	} else if !resTen.Shape().Eq(outShape) {
 		return nil, errors.New("resTen doesn't have right dimensions to hold convolution result")
 	}
 
+	if resTen.DType() != t.DType() {
+		return nil, errors.New("resTen doesn't have the same dtype as the input")
+	}
+
 	// Access tensor data references
-	matData := *t.data()
-	kernelData := *kernels.data()
-	resData := *resTen.data()
+	matData := t.values()
+	kernelData := asType[E](kernels).values()
+	res := asType[E](resTen)
+	resData := res.buffer()
 
 	// Iterate through the input tensor
 	for i := 0; i < outRows; i++ {
 		for j := 0; j < outCols; j++ {
-			sum := 0.0
+			var sum E
 
 			// Convolution operation (kernel is flipped)
 			for ki := 0; ki < kRows; ki++ {
@@ -257,11 +295,14 @@ func (t *tensor) Convolve(kernels Tensor, strides [2]int, resTen Tensor) (Tensor
 			resData[i*outCols+j] += sum
 		}
 	}
+	res.flush(resData)
 
 	return resTen, nil
 }
 
-func (t *tensor) Slice(start, end int) (Tensor, error) {
+// Slice returns a view of the values from start up to end, in row major order,
+// as a single row.
+func (t *tensor[E]) Slice(start, end int) (Tensor, error) {
 	switch {
 	case start >= end:
 		return nil, errors.New("batchslice: end is less than or equal to start")
@@ -269,21 +310,21 @@ func (t *tensor) Slice(start, end int) (Tensor, error) {
 	case start < 0 || end < 0:
 		return nil, errors.New("batchslice: points cannot be negative")
 
-	case end >= t.Shape().TotalSize():
+	case end > t.Shape().TotalSize():
 		return nil, errors.New("batchslice: end point out of range")
 	}
 
-	data := t.Data[start:end]
-
-	resTen, err := TensorFrom([]int{1, len(data)}, data)
-	if err != nil {
-		return nil, err
+	// A strided view can't be flattened without copying
+	if !t.isContiguous() {
+		return FromSlice([]int{1, end - start}, t.values()[start:end])
 	}
 
-	return resTen, nil
+	return t.newView(start, []int{1, end - start}, []int{end - start, 1}), nil
 }
 
-func (t *tensor) RegionSlice(startRow, startCol, numRows, numCols int) (Tensor, []int, error) {
+// RegionSlice returns a view of a rectangular region of a matrix, together
+// with the row major indices of the region in the matrix.
+func (t *tensor[E]) RegionSlice(startRow, startCol, numRows, numCols int) (Tensor, []int, error) {
 
 	if !t.Shape().IsMatrix() {
 		return nil, nil, errors.New("tensor must be a matrix")
@@ -297,27 +338,26 @@ func (t *tensor) RegionSlice(startRow, startCol, numRows, numCols int) (Tensor,
 		return nil, nil, errors.New("region exceeds matrix bounds")
 	}
 
-	data := make([]float64, 0, numRows*numCols)
-	indeces := make([]int, 0, numRows*numCols)
+	strides := t.alignedStrides(2)
+	cols := t.Shape().Cols()
 
+	indeces := make([]int, 0, numRows*numCols)
 	for i := 0; i < numRows; i++ {
+		startIdx := (startRow+i)*cols + startCol
 
-		startIdx := (startRow+i)*t.Shape().Cols() + startCol
-		endIdx := startIdx + numCols
-
-		for i := startIdx; i < endIdx; i++ {
-			data = append(data, t.ValueAt(i))
-			indeces = append(indeces, i)
+		for j := startIdx; j < startIdx+numCols; j++ {
+			indeces = append(indeces, j)
 		}
 	}
 
-	// Create the new tensor
-	resMat, _ := TensorFrom([]int{numRows, numCols}, data)
+	start := startRow*strides[0] + startCol*strides[1]
+	resMat := t.newView(start, []int{numRows, numCols}, strides)
 
 	return resMat, indeces, nil
 }
 
-func (t *tensor) BatchSlice(startBatch, endBatch int) (Tensor, error) {
+// BatchSlice returns a view of the batches from startBatch up to endBatch.
+func (t *tensor[E]) BatchSlice(startBatch, endBatch int) (Tensor, error) {
 	switch {
 	case startBatch >= endBatch:
 		return nil, errors.New("end is less than or equal to start")
@@ -325,62 +365,47 @@ func (t *tensor) BatchSlice(startBatch, endBatch int) (Tensor, error) {
 	case startBatch < 0 || endBatch < 0:
 		return nil, errors.New("points cannot be negative")
 
-  
-	case endBatch >= t.Shape().Batches():
+	case endBatch > t.Shape().Batches():
 		return nil, errors.New("end point out of range")
 	}
 
-	startIdx := startBatch * t.Strides()[0]
-	endIdx := endBatch * t.Strides()[0]
-
 	newShape := []int{endBatch - startBatch, t.Shape().Channels(), t.Shape().Rows(), t.Shape().Cols()}
 
-	newData := t.Data[startIdx:endIdx]
-
-	resTen, err := TensorFrom(newShape, newData)
-	if err != nil {
-		return nil, err
+	strides := t.alignedStrides(4)
+	if t.Dims() < 4 {
+		strides[0] = t.Size()
 	}
 
-	return resTen, nil
+	return t.newView(startBatch*strides[0], newShape, strides), nil
 }
-func (t *tensor) Transpose(inPlace bool) Tensor {
 
-	matrixIter, _ := IterFromTensor(t, "matrix")
+// Transpose swaps the last two dimensions by swapping their strides, the
+// result shares its data with t. It stays the matrix transpose the Backward
+// of every layer relies on, also for batches of matrices, and a vector is
+// read as a (1, n) row. Other orders of the dimensions go through Permute,
+// which takes the axes, e.g. Permute() for NumPy's full transpose.
+func (t *tensor[E]) Transpose(inPlace bool) Tensor {
+	dims := max(t.Dims(), 2)
 
-	result := make([]float64, 0, t.Shape().TotalSize())
-	for matrix, ok := matrixIter.Next(); ok; matrix, ok = matrixIter.Next() {
-		result = append(result, subTranspose(matrix.data(), t.Shape().Rows(), t.Shape().Cols())...)
-	}
+	newShape := make(Shape, dims)
+	newShape[0] = 1
+	copy(newShape[dims-t.Dims():], t.Shape())
+	newShape[dims-2], newShape[dims-1] = newShape[dims-1], newShape[dims-2]
 
-	newShape := t.Shape().Clone()
-	newShape[len(newShape)-2], newShape[len(newShape)-1] = newShape[len(newShape)-1], newShape[len(newShape)-2]
+	newStrides := t.alignedStrides(dims)
+	newStrides[dims-2], newStrides[dims-1] = newStrides[dims-1], newStrides[dims-2]
 
 	if inPlace {
-		t.Data = result
-		t.Reshape(newShape)
+		t.TShape = newShape
+		t.strides = newStrides
 		return t
 	}
 
-	resTen, _ := TensorFrom(newShape, result)
-
-	return resTen
+	t.shared.Store(true)
+	return &tensor[E]{TShape: newShape, Data: t.Data, strides: newStrides}
 }
 
-func subTranspose(matrix *[]float64, rows, cols int) []float64 {
-
-	transposed := make([]float64, rows*cols)
-
-	for i := 0; i < rows; i++ {
-		for j := 0; j < cols; j++ {
-			transposed[j*rows+i] = (*matrix)[i*cols+j]
-		}
-	}
-
-	return transposed
-}
-
-func (t *tensor) Reshape(shape Shape) error {
+func (t *tensor[E]) Reshape(shape Shape) error {
 	for _, val := range shape {
 		if val <= 0 {
 			return errors.New("Dimensions cannot be negative")
@@ -388,44 +413,52 @@ func (t *tensor) Reshape(shape Shape) error {
 	}
 
 	if t.Shape().TotalSize()%shape.TotalSize() != 0 {
-		return errors.New("Cannot reshape when totalSizes are not the same")
+		return fmt.Errorf("Reshape: size %d cannot be reshaped to %v", t.Shape().TotalSize(), shape)
 	}
+
+	var newShape Shape
 	switch len(shape) {
 	case 0, 1:
 		return errors.New("must have atleast a matrix input")
 
 	case 2:
-		t.TShape = []int{t.Shape().TotalSize() / shape.TotalSize(), 1, shape[0], shape[1]}
+		newShape = []int{t.Shape().TotalSize() / shape.TotalSize(), 1, shape[0], shape[1]}
 
 	case 3:
-		t.TShape = []int{t.Shape().TotalSize() / shape.TotalSize(), shape[0], shape[1], shape[2]}
+		newShape = []int{t.Shape().TotalSize() / shape.TotalSize(), shape[0], shape[1], shape[2]}
 
 	case 4:
-		t.TShape = shape
+		newShape = shape
 
 	default:
 		return errors.New("Invalid shape length")
 	}
 
+	// A strided view has to be copied to get a row major layout
+	if !t.isContiguous() {
+		t.Data = t.values()
+	}
+
+	t.TShape = newShape
+	t.strides = nil
+
 	return nil
 }
 
-func (t *tensor) RepAdd(other Tensor, inPlace bool) (Tensor, error) {
+func (t *tensor[E]) RepAdd(other Tensor, inPlace bool) (Tensor, error) {
 
 	if other.Dims() == 0 {
 		return nil, errors.New("other tensor has no dimension")
 	}
 
-	var resTen tensor
-	switch inPlace {
-	case true:
-		resTen = *t
-
-	case false:
-		resTen = tensor{TShape: t.Shape().Clone(), Data: make([]float64, len(t.Data))}
+	resTen := t
+	if !inPlace {
+		resTen = zeros[E](t.TShape)
 	}
 
-	otherData := *other.data()
+	tData := t.values()
+	resData := resTen.buffer()
+	otherData := asType[E](other).values()
 
 	shape := t.Shape()
 	otherShape := other.Shape()
@@ -445,129 +478,83 @@ func (t *tensor) RepAdd(other Tensor, inPlace bool) (Tensor, error) {
 				for j := range cols {
 					resIdx := resBatch + resChannel + resRow + j
 					otherIdx := otherBatch + otherChannel + otherRow + j%oCols
-					resTen.Data[resIdx] = t.Data[resIdx] + otherData[otherIdx]
+					resData[resIdx] = tData[resIdx] + otherData[otherIdx]
 				}
 			}
 		}
 	}
+	resTen.flush(resData)
 
-	return &resTen, nil
+	return resTen, nil
 }
 
-func (t *tensor) RepMultiply(other Tensor, inPlace bool) (Tensor, error) {
+func (t *tensor[E]) RepMultiply(other Tensor, inPlace bool) (Tensor, error) {
 
 	if other.Dims() == 0 {
 		return nil, errors.New("other tensor has no dimension")
 	}
 
-	var resTen tensor
-	switch inPlace {
-	case true:
-		resTen = *t
-
-	case false:
-		resTen = tensor{TShape: t.Shape().Clone(), Data: make([]float64, len(t.Data))}
+	resTen := t
+	if !inPlace {
+		resTen = zeros[E](t.TShape)
 	}
 
-	otherData := *other.data()
+	tData := t.values()
+	resData := resTen.buffer()
+	otherData := asType[E](other).values()
 
 	shape := t.Shape()
 	otherShape := other.Shape()
 
 	for i := range shape.Rows() {
 		for j := range shape.Cols() {
-			resTen.Data[i*shape.Cols()+j] = t.Data[i*shape.Cols()+j] * otherData[i%otherShape.Rows()*otherShape.Cols()+j%otherShape.Cols()]
+			resData[i*shape.Cols()+j] = tData[i*shape.Cols()+j] * otherData[i%otherShape.Rows()*otherShape.Cols()+j%otherShape.Cols()]
 		}
 	}
+	resTen.flush(resData)
 
-	return &resTen, nil
+	return resTen, nil
 }
 
-func (t *tensor) Add(other Tensor, inPlace bool) (Tensor, error) {
-	if !t.Shape().Eq(other.Shape()) {
-		return nil, errors.New("Dimensions not the same")
-	}
-
-	var resTen tensor
-	if inPlace {
-		resTen = *t
-	} else {
-		resTen = tensor{TShape: t.Shape().Clone(), Data: make([]float64, len(t.Data))}
-	}
-
-	otherData := other.data()
-	for i := 0; i < len(t.Data); i++ {
-		resTen.Data[i] = t.Data[i] + (*otherData)[i]
-	}
-
-	return &resTen, nil
+// Add adds other elementwise, broadcasting the shapes against each other.
+func (t *tensor[E]) Add(other Tensor, inPlace bool) (Tensor, error) {
+	return t.broadcastBinary(other, inPlace, func(x, y E) E {
+		return x + y
+	})
 }
 
-func (t *tensor) Subtract(other Tensor, inPlace bool) (Tensor, error) {
-	if !t.Shape().Eq(other.Shape()) {
-		return nil, errors.New("Dimensions not the same")
-	}
-
-	var resTen tensor
-	if inPlace {
-		resTen = *t
-	} else {
-		resTen = tensor{TShape: t.Shape().Clone(), Data: make([]float64, len(t.Data))}
-	}
-
-	otherData := other.data()
-	for i := 0; i < len(t.Data); i++ {
-		resTen.Data[i] = t.Data[i] - (*otherData)[i]
-	}
-
-	return &resTen, nil
+// Subtract subtracts other elementwise, broadcasting the shapes against each other.
+func (t *tensor[E]) Subtract(other Tensor, inPlace bool) (Tensor, error) {
+	return t.broadcastBinary(other, inPlace, func(x, y E) E {
+		return x - y
+	})
 }
 
-func (t *tensor) Multiply(other Tensor, inPlace bool) (Tensor, error) {
-	if !t.Shape().Eq(other.Shape()) {
-		return nil, errors.New("Dimensions not the same")
-	}
-
-	var resTen tensor
-	if inPlace {
-		resTen = *t
-	} else {
-		resTen = tensor{TShape: t.Shape().Clone(), Data: make([]float64, len(t.Data))}
-	}
-
-	otherData := other.data()
-	for i := 0; i < len(t.Data); i++ {
-		resTen.Data[i] = t.Data[i] * (*otherData)[i]
-	}
-
-	return &resTen, nil
+// Multiply multiplies by other elementwise, broadcasting the shapes against each other.
+func (t *tensor[E]) Multiply(other Tensor, inPlace bool) (Tensor, error) {
+	return t.broadcastBinary(other, inPlace, func(x, y E) E {
+		return x * y
+	})
 }
 
-func (t *tensor) Divide(other Tensor, inPlace bool) (Tensor, error) {
-	if !t.Shape().Eq(other.Shape()) {
-		return nil, errors.New("Dimensions not the same")
-	}
-
-	var resTen tensor
-	if inPlace {
-		resTen = *t
-	} else {
-		resTen = tensor{TShape: t.Shape().Clone(), Data: make([]float64, len(t.Data))}
+// Divide divides by other elementwise, broadcasting the shapes against each other.
+func (t *tensor[E]) Divide(other Tensor, inPlace bool) (Tensor, error) {
+	if other == nil {
+		return nil, errors.New("other tensor cannot be nil")
 	}
 
-	otherData := other.data()
-	for i := 0; i < len(t.Data); i++ {
-		otherVal := (*otherData)[i]
-		if otherVal == 0 {
+	for _, val := range asType[E](other).values() {
+		if val == 0 {
 			return nil, errors.New("Cannot divide by zero")
 		}
-		resTen.Data[i] = t.Data[i] + (*otherData)[i]
 	}
 
-	return &resTen, nil
+	return t.broadcastBinary(other, inPlace, func(x, y E) E {
+		return x / y
+	})
 }
 
-func (t *tensor) AddMatrix(other Tensor) error {
+func (t *tensor[E]) AddMatrix(other Tensor) error {
 	if !other.Shape().IsMatrix() {
 		return errors.New("cannot add non matrix")
 	}
@@ -577,208 +564,272 @@ func (t *tensor) AddMatrix(other Tensor) error {
 	}
 
 	if other.Shape().TotalSize() != t.TShape.Rows()*t.TShape.Cols() {
-		return errors.New("matrix sizes not the same size")
+		return shapeMismatch("AddMatrix", Shape{t.TShape.Rows(), t.TShape.Cols()}, other.Shape())
 	}
 
-	t.Data = append(t.Data, *other.data()...)
+	// Limit the capacity so appending never writes into data shared with other views
+	values := t.values()
+	t.Data = append(values[:len(values):len(values)], asType[E](other).values()...)
+	t.strides = nil
 
 	t.TShape[0] += 1
 
 	return nil
 }
 
-func (t *tensor) Map(fn func(float64) (float64, error), inPlace bool) (Tensor, error) {
-	var resTen tensor
-	if inPlace {
-		resTen = *t
-	} else {
-		resTen = tensor{TShape: t.Shape().Clone(), Data: make([]float64, len(t.Data))}
-	}
-
-	for i := 0; i < len(t.Data); i++ {
-		y, err := fn(t.Data[i])
-		if err != nil {
-			return nil, err
+// Map applies fn to every value. Large tensors are split into chunks that run
+// in parallel, so fn can be called from several goroutines at once.
+func (t *tensor[E]) Map(fn func(float64) (float64, error), inPlace bool) (Tensor, error) {
+	resTen := t
+	if !inPlace {
+		resTen = zeros[E](t.TShape)
+	}
+
+	tData := t.values()
+	resData := resTen.buffer()
+	err := parallelFor(len(tData), func(start, end int) error {
+		for i := start; i < end; i++ {
+			y, err := fn(float64(tData[i]))
+			if err != nil {
+				return err
+			}
+			resData[i] = E(y)
 		}
-		resTen.Data[i] = y
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	resTen.flush(resData)
 
-	return &resTen, nil
+	return resTen, nil
 }
 
-func (t *tensor) MapBatch(fn func(...float64) (float64, error), inPlace bool, others ...Tensor) (Tensor, error) {
+// MapBatch applies fn to every value of t together with the values of others
+// at the same position, broadcasting the shapes against each other. Like Map
+// it runs large tensors in parallel chunks.
+func (t *tensor[E]) MapBatch(fn func(...float64) (float64, error), inPlace bool, others ...Tensor) (Tensor, error) {
+	shapes := []Shape{t.Shape()}
 	for _, other := range others {
+		shapes = append(shapes, other.Shape())
+	}
 
-		if !t.Shape().Eq(other.Shape()) {
-			return nil, errors.New("Shapes do not match")
-		}
+	outShape, err := BroadcastShapes(shapes...)
+	if err != nil {
+		return nil, err
 	}
 
-	var resTen tensor
+	resTen := t
 	switch inPlace {
 	case true:
-		resTen = *t
+		if outShape.TotalSize() != t.Size() {
+			return nil, shapeMismatch("in place MapBatch", t.Shape(), outShape)
+		}
 
 	case false:
-		resTen = tensor{TShape: t.Shape().Clone(), Data: make([]float64, len(t.Data))}
+		resTen = zeros[E](outShape)
 	}
 
-	for i := 0; i < len(t.Data); i++ {
+	datas := [][]E{t.Data}
+	strides := [][]int{broadcastStrides(t.Shape(), t.Strides(), outShape)}
+	for _, other := range others {
+		o := asType[E](other)
+		datas = append(datas, o.Data)
+		strides = append(strides, broadcastStrides(o.Shape(), o.Strides(), outShape))
+	}
 
-		inputs := []float64{t.Data[i]}
-		for _, other := range others {
-			inputs = append(inputs, (*other.data())[i])
-		}
+	err = parallelFor(outShape.TotalSize(), func(start, end int) error {
+		var err error
+		inputs := make([]float64, len(datas))
+		broadcastRange(outShape, strides, start, end, func(i int, offsets []int) {
+			if err != nil {
+				return
+			}
 
-		y, err := fn(inputs...)
-		if err != nil {
-			return nil, err
-		}
+			for j, data := range datas {
+				inputs[j] = float64(data[offsets[j]])
+			}
 
-		resTen.Data[i] = y
+			var y float64
+			y, err = fn(inputs...)
+
+			// In place results go to the position of the element in t
+			if inPlace {
+				resTen.Data[offsets[0]] = E(y)
+			} else {
+				resTen.Data[i] = E(y)
+			}
+		})
+		return err
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
-	return &resTen, nil
+	return resTen, nil
 }
 
-func (t *tensor) ScalarAdd(x float64, inPlace bool) Tensor {
+func (t *tensor[E]) ScalarAdd(x float64, inPlace bool) Tensor {
+	val := E(x)
 	if inPlace {
-		for i := 0; i < len(t.Data); i++ {
-			t.Data[i] += x
+		tData := t.buffer()
+		for i := 0; i < len(tData); i++ {
+			tData[i] += val
 		}
+		t.flush(tData)
 		return nil
 	}
-	resTen := tensor{TShape: t.Shape().Clone(), Data: make([]float64, len(t.Data))}
+	resTen := zeros[E](t.TShape)
 
-	for i := 0; i < len(t.Data); i++ {
-		resTen.Data[i] = t.Data[i] + x
+	tData := t.values()
+	for i := 0; i < len(tData); i++ {
+		resTen.Data[i] = tData[i] + val
 	}
-	return &resTen
+	return resTen
 }
 
-func (t *tensor) ScalarSubtract(x float64, inPlace bool) Tensor {
+func (t *tensor[E]) ScalarSubtract(x float64, inPlace bool) Tensor {
+	val := E(x)
 	if inPlace {
-		for i := 0; i < len(t.Data); i++ {
-			t.Data[i] -= x
+		tData := t.buffer()
+		for i := 0; i < len(tData); i++ {
+			tData[i] -= val
 		}
+		t.flush(tData)
 		return nil
 	}
-	resTen := tensor{TShape: t.Shape().Clone(), Data: make([]float64, len(t.Data))}
+	resTen := zeros[E](t.TShape)
 
-	for i := 0; i < len(t.Data); i++ {
-		resTen.Data[i] = t.Data[i] - x
+	tData := t.values()
+	for i := 0; i < len(tData); i++ {
+		resTen.Data[i] = tData[i] - val
 	}
-	return &resTen
+	return resTen
 }
 
-func (t *tensor) ScalarMultiply(x float64, inPlace bool) Tensor {
+func (t *tensor[E]) ScalarMultiply(x float64, inPlace bool) Tensor {
+	val := E(x)
 	if inPlace {
-		for i := 0; i < len(t.Data); i++ {
-			t.Data[i] *= x
+		tData := t.buffer()
+		for i := 0; i < len(tData); i++ {
+			tData[i] *= val
 		}
+		t.flush(tData)
 		return nil
 	}
-	resTen := tensor{TShape: t.Shape().Clone(), Data: make([]float64, len(t.Data))}
+	resTen := zeros[E](t.TShape)
 
-	for i := 0; i < len(t.Data); i++ {
-		resTen.Data[i] = t.Data[i] * x
+	tData := t.values()
+	for i := 0; i < len(tData); i++ {
+		resTen.Data[i] = tData[i] * val
 	}
-	return &resTen
+	return resTen
 }
 
-func (t *tensor) ScalarDivide(x float64, inPlace bool) (Tensor, error) {
+func (t *tensor[E]) ScalarDivide(x float64, inPlace bool) (Tensor, error) {
 	if x == 0 {
 		return nil, errors.New("cannot divide by zero")
 	}
+	val := E(x)
 
 	if inPlace {
-		for i := 0; i < len(t.Data); i++ {
-			t.Data[i] /= x
+		tData := t.buffer()
+		for i := 0; i < len(tData); i++ {
+			tData[i] /= val
 		}
+		t.flush(tData)
 		return nil, nil
 	}
-	resTen := tensor{TShape: t.Shape().Clone(), Data: make([]float64, len(t.Data))}
+	resTen := zeros[E](t.TShape)
 
-	for i := 0; i < len(t.Data); i++ {
-		resTen.Data[i] = t.Data[i] - x
+	tData := t.values()
+	for i := 0; i < len(tData); i++ {
+		resTen.Data[i] = tData[i] / val
 	}
-	return &resTen, nil
+	return resTen, nil
 }
 
-func (t *tensor) Sum() float64 {
-	if t.Data == nil || len(t.Data) == 0 {
+func (t *tensor[E]) Sum() float64 {
+	tData := t.values()
+	if len(tData) == 0 {
 		return 0.0
 	}
 	sum := 0.0
-	for i := 0; i < len(t.Data); i++ {
-		sum += t.Data[i]
+	for i := 0; i < len(tData); i++ {
+		sum += float64(tData[i])
 	}
 	return sum
 }
 
-func (t *tensor) Avg() float64 {
-	if t.Data == nil || len(t.Data) == 0 {
+func (t *tensor[E]) Avg() float64 {
+	tData := t.values()
+	if len(tData) == 0 {
 		return 0.0
 	}
 	sum := 0.0
-	for i := 0; i < len(t.Data); i++ {
-		sum += t.Data[i]
+	for i := 0; i < len(tData); i++ {
+		sum += float64(tData[i])
 	}
-	return sum / float64(len(t.Data))
+	return sum / float64(len(tData))
 }
 
-func (t *tensor) Min() float64 {
-	if t.Data == nil || len(t.Data) == 0 {
+func (t *tensor[E]) Min() float64 {
+	tData := t.values()
+	if len(tData) == 0 {
 		return math.Inf(-1)
 	}
-	min := t.Data[0]
-	for i := 0; i < len(t.Data); i++ {
-		if t.Data[i] < min {
-			min = t.Data[i]
+	min := tData[0]
+	for i := 0; i < len(tData); i++ {
+		if tData[i] < min {
+			min = tData[i]
 		}
 	}
 
-	return min
+	return float64(min)
 }
 
-func (t *tensor) Max() float64 {
-	if t.Data == nil || len(t.Data) == 0 {
+func (t *tensor[E]) Max() float64 {
+	tData := t.values()
+	if len(tData) == 0 {
 		return math.Inf(1)
 	}
-	max := t.Data[0]
-	for i := 0; i < len(t.Data); i++ {
-		if t.Data[i] > max {
-			max = t.Data[i]
+	max := tData[0]
+	for i := 0; i < len(tData); i++ {
+		if tData[i] > max {
+			max = tData[i]
 		}
 	}
 
-	return max
+	return float64(max)
 }
 
-func (t *tensor) MaxIndex() int {
-	if t.Data == nil || len(t.Data) == 0 {
+func (t *tensor[E]) MaxIndex() int {
+	tData := t.values()
+	if len(tData) == 0 {
 		return -1
 	}
-	max := t.Data[0]
+	max := tData[0]
 	maxIdx := 0
-	for i := 0; i < len(t.Data); i++ {
-		if t.Data[i] > max {
-			max = t.Data[i]
+	for i := 0; i < len(tData); i++ {
+		if tData[i] > max {
+			max = tData[i]
 			maxIdx = i
 		}
 	}
 
 	return maxIdx
 }
-func (t *tensor) MinIndex() int {
-	if t.Data == nil || len(t.Data) == 0 {
+func (t *tensor[E]) MinIndex() int {
+	tData := t.values()
+	if len(tData) == 0 {
 		return -1
 	}
-	min := t.Data[0]
+	min := tData[0]
 	minIdx := 0
-	for i := 0; i > len(t.Data); i++ {
-		if t.Data[i] > min {
-			min = t.Data[i]
+	for i := 0; i < len(tData); i++ {
+		if tData[i] < min {
+			min = tData[i]
 			minIdx = i
 		}
 	}
@@ -786,15 +837,16 @@ func (t *tensor) MinIndex() int {
 	return minIdx
 }
 
-func (t *tensor) AvgIndex() int {
-	if t.Data == nil || len(t.Data) == 0 {
+func (t *tensor[E]) AvgIndex() int {
+	tData := t.values()
+	if len(tData) == 0 {
 		return -1
 	}
-	avg := t.Data[0]
+	avg := tData[0]
 	avgIdx := 0
-	for i := 0; i < len(t.Data); i++ {
-		if t.Data[i] > avg {
-			avg = t.Data[i]
+	for i := 0; i < len(tData); i++ {
+		if tData[i] > avg {
+			avg = tData[i]
 			avgIdx = i
 		}
 	}
@@ -802,18 +854,20 @@ func (t *tensor) AvgIndex() int {
 	return avgIdx
 }
 
-func (t *tensor) ArgMax(axis int) ([]int, error) {
+func (t *tensor[E]) ArgMax(axis int) ([]int, error) {
 	if axis > 1 || axis < 0 {
 		return nil, errors.New("Axis not 1 or 0")
 	}
 
+	// Work on a transposed view so t itself is never changed
+	var mat Tensor = t
 	if axis == 0 {
-		t.Transpose(true)
+		mat = t.Transpose(false)
 	}
 
-	result := make([]int, 0, t.Shape().Rows())
+	result := make([]int, 0, mat.Shape().Rows())
 
-	rowIter, err := IterFromTensor(t, "rows")
+	rowIter, err := IterRows(mat)
 	if err != nil {
 		return nil, err
 	}
@@ -823,14 +877,10 @@ func (t *tensor) ArgMax(axis int) ([]int, error) {
 		result = append(result, maxIndex)
 	}
 
-	if axis == 0 {
-		t.Transpose(true)
-	}
-
 	return result, nil
 }
 
-func (t *tensor) Tile(reps_rows, reps_cols int) (Tensor, error) {
+func (t *tensor[E]) Tile(reps_rows, reps_cols int) (Tensor, error) {
 	if reps_rows == 0 || reps_cols == 0 {
 		return t, nil
 	}
@@ -839,14 +889,14 @@ func (t *tensor) Tile(reps_rows, reps_cols int) (Tensor, error) {
 	newShape[len(newShape)-2] *= reps_rows
 	newShape[len(newShape)-1] *= reps_cols
 
-	resTen := ZerosTensor(newShape)
+	resTen := zeros[E](newShape)
 
-	resMatrixIter, err := IterFromTensor(resTen, "matrix")
+	resMatrixIter, err := IterMatrices(resTen)
 	if err != nil {
 		return nil, err
 	}
 
-	inputMatrixIter, err := IterFromTensor(t, "matrix")
+	inputMatrixIter, err := IterMatrices(t)
 	if err != nil {
 		return nil, err
 	}
@@ -854,8 +904,8 @@ func (t *tensor) Tile(reps_rows, reps_cols int) (Tensor, error) {
 	for resMatrix, ok := resMatrixIter.Next(); ok; resMatrix, ok = resMatrixIter.Next() {
 		inputMatrix, _ := inputMatrixIter.Next()
 
-		inputData := *inputMatrix.data()
-		resData := *resMatrix.data()
+		inputData := asType[E](inputMatrix).values()
+		resData := asType[E](resMatrix).buffer()
 
 		for i := range newShape.Rows() {
 			for j := range newShape.Cols() {
@@ -871,7 +921,7 @@ func (t *tensor) Tile(reps_rows, reps_cols int) (Tensor, error) {
 	return resTen, nil
 }
 
-func (t *tensor) Dilate(rows, cols int) (Tensor, error) {
+func (t *tensor[E]) Dilate(rows, cols int) (Tensor, error) {
 
 	if rows < 0 || cols < 0 {
 		return nil, errors.New("dilate: dimensions cannot be negative")
@@ -885,14 +935,14 @@ func (t *tensor) Dilate(rows, cols int) (Tensor, error) {
 	newWidth := t.Shape().Cols()*(cols+1) - cols
 	newShape := []int{t.Shape().Batches(), t.Shape().Channels(), newHeight, newWidth}
 
-	resTen := ZerosTensor(newShape)
+	resTen := zeros[E](newShape)
 
-	resMatIter, err := IterFromTensor(resTen, "matrix")
+	resMatIter, err := IterMatrices(resTen)
 	if err != nil {
 		return nil, err
 	}
 
-	dataMatIter, err := IterFromTensor(t, "matrix")
+	dataMatIter, err := IterMatrices(t)
 	if err != nil {
 		return nil, err
 	}
@@ -900,10 +950,11 @@ func (t *tensor) Dilate(rows, cols int) (Tensor, error) {
 	for dataMat, ok := dataMatIter.Next(); ok; dataMat, ok = dataMatIter.Next() {
 		resMat, _ := resMatIter.Next()
 
-		resMatData := *(resMat.data())
+		dataMatData := asType[E](dataMat).values()
+		resMatData := asType[E](resMat).buffer()
 		for i := range dataMat.Shape().Rows() {
 			for j := range dataMat.Shape().Cols() {
-				resMatData[i*resMat.Shape().Cols()*rows+j*cols] = t.Data[i*dataMat.Shape().Cols()+j]
+				resMatData[i*(rows+1)*newWidth+j*(cols+1)] = dataMatData[i*dataMat.Shape().Cols()+j]
 			}
 		}
 
@@ -912,74 +963,68 @@ func (t *tensor) Dilate(rows, cols int) (Tensor, error) {
 	return resTen, nil
 }
 
-func (t *tensor) Normalize() error {
+func (t *tensor[E]) Normalize() error {
 
-	t.Transpose(true)
-
-	rowIter, err := IterFromTensor(t, "rows")
+	// Every row of the transposed view is a column of t
+	colIter, err := IterRows(t.Transpose(false))
 	if err != nil {
 		return err
 	}
 
-	for row, ok := rowIter.Next(); ok; row, ok = rowIter.Next() {
-		minVal := row.Min()
-		maxVal := row.Max()
+	for col, ok := colIter.Next(); ok; col, ok = colIter.Next() {
+		minVal := col.Min()
+		maxVal := col.Max()
 
 		if minVal == maxVal {
-			row.ScalarMultiply(0.0, true)
+			col.ScalarMultiply(0.0, true)
 			continue
 		}
 
-		row.ScalarSubtract(minVal, true)
-		row.ScalarDivide(maxVal-minVal, true)
+		col.ScalarSubtract(minVal, true)
+		col.ScalarDivide(maxVal-minVal, true)
 	}
 
-	t.Transpose(true)
-
 	return nil
 }
 
-func (t *tensor) OneHotEncode(size int) (Tensor, error) {
-	resTen := ZerosTensor([]int{t.Shape().Rows(), size})
-	for i, val := range t.Data {
+func (t *tensor[E]) OneHotEncode(size int) (Tensor, error) {
+	resTen := zeros[E]([]int{t.Shape().Rows(), size})
+	for i, val := range t.values() {
 		resTen.SetValueAt(i*size+int(val), 1.0)
 	}
 
 	return resTen, nil
 }
-func (t *tensor) AxisSum(axis int) (Tensor, error) {
+func (t *tensor[E]) AxisSum(axis int) (Tensor, error) {
 	if !t.Shape().IsMatrix() {
 		return nil, errors.New("AxisSum() only implemented for matrices")
 	}
 
-	var resVec tensor
+	rows, cols := t.Shape().Rows(), t.Shape().Cols()
+	tData := t.values()
+
+	var resVec tensor[E]
 	switch axis {
 	case 0:
-		cols := t.Shape().Cols()
-		resVec = tensor{TShape: []int{1, cols}, Data: make([]float64, cols)}
-		t.Transpose(true)
+		resVec = tensor[E]{TShape: []int{1, cols}, Data: make([]E, cols)}
+		for i := range tData {
+			resVec.Data[i%cols] += tData[i]
+		}
 
 	case 1:
-		rows := t.Shape().Rows()
-		resVec = tensor{TShape: []int{rows, 1}, Data: make([]float64, rows)}
+		resVec = tensor[E]{TShape: []int{rows, 1}, Data: make([]E, rows)}
+		for i := range tData {
+			resVec.Data[i/cols] += tData[i]
+		}
 
 	default:
 		return nil, errors.New("Invalid axis choice chooses 0: for the sum of all the columns, and 1: for the sum of all the rows")
 	}
 
-	cols := t.Shape().Cols()
-	for i := 0; i < t.Size(); i++ {
-		resVec.Data[i/cols] += t.Data[i]
-	}
-
-	if axis == 0 {
-		t.Transpose(true)
-	}
-
 	return &resVec, nil
 }
 
-func (t *tensor) Pad(pads ...int) (Tensor, error) {
+func (t *tensor[E]) Pad(pads ...int) (Tensor, error) {
 	for _, pad := range pads {
 		if pad < 0 {
 			return nil, errors.New("negative padding value given")
@@ -1012,13 +1057,13 @@ func (t *tensor) Pad(pads ...int) (Tensor, error) {
 	paddedShape[len(paddedShape)-1] += L + R
 	paddedShape[len(paddedShape)-2] += T + B
 
-	resTen := ZerosTensor(paddedShape)
-	resTenIter, err := IterFromTensor(resTen, "")
+	resTen := zeros[E](paddedShape)
+	resTenIter, err := IterMatrices(resTen)
 	if err != nil {
 		return nil, err
 	}
 
-	tenIter, err := IterFromTensor(t, "")
+	tenIter, err := IterMatrices(t)
 	if err != nil {
 		return nil, err
 	}
@@ -1029,8 +1074,8 @@ func (t *tensor) Pad(pads ...int) (Tensor, error) {
 			return nil, errors.New("Shouldn't be possible, but result matrix iterator ran out of matrices before tensor")
 		}
 
-		Data := *mat.data()
-		resTenData := *resMat.data()
+		Data := asType[E](mat).values()
+		resTenData := asType[E](resMat).buffer()
 		for i := 0; i < mat.Shape().Rows(); i++ {
 
 			for j := 0; j < mat.Shape().Cols(); j++ {
@@ -1042,7 +1087,7 @@ func (t *tensor) Pad(pads ...int) (Tensor, error) {
 	return resTen, nil
 }
 
-func (t *tensor) Trim(trim_sizes ...int) (Tensor, error) {
+func (t *tensor[E]) Trim(trim_sizes ...int) (Tensor, error) {
 
 	for _, trim := range trim_sizes {
 		if trim < 0 {
@@ -1080,13 +1125,13 @@ func (t *tensor) Trim(trim_sizes ...int) (Tensor, error) {
 		return nil, errors.New("Trimmed shape must stay positive")
 	}
 
-	resTen := ZerosTensor(trimmedShape)
-	resMatrixIter, err := IterFromTensor(resTen, "channel")
+	resTen := zeros[E](trimmedShape)
+	resMatrixIter, err := IterMatrices(resTen)
 	if err != nil {
 		return nil, err
 	}
 
-	inputMatrixIter, err := IterFromTensor(t, "channel")
+	inputMatrixIter, err := IterMatrices(t)
 	if err != nil {
 		return nil, err
 	}
@@ -1097,12 +1142,12 @@ func (t *tensor) Trim(trim_sizes ...int) (Tensor, error) {
 			return nil, errors.New("Shouldn't be possible, but result matrix iterator ran out of matrices before tensor")
 		}
 
-		inputData := *inputMatrix.data()
-		resData := *resMatrix.data()
-		for i := T; i < resMatrix.Shape().Rows(); i++ {
+		inputData := asType[E](inputMatrix).values()
+		resData := asType[E](resMatrix).buffer()
+		for i := 0; i < resMatrix.Shape().Rows(); i++ {
 
-			for j := L; j < resMatrix.Shape().Cols(); j++ {
-				resData[(i+T)*resMatrix.Shape().Cols()+j+L] = inputData[i*inputMatrix.Shape().Cols()+j]
+			for j := 0; j < resMatrix.Shape().Cols(); j++ {
+				resData[i*resMatrix.Shape().Cols()+j] = inputData[(i+T)*inputMatrix.Shape().Cols()+j+L]
 			}
 		}
 	}
@@ -1110,197 +1155,108 @@ func (t *tensor) Trim(trim_sizes ...int) (Tensor, error) {
 	return resTen, nil
 }
 
-func (t *tensor) Shape() Shape {
+func (t *tensor[E]) Shape() Shape {
 	return t.TShape
 }
 
-func (t *tensor) Strides() []int {
+func (t *tensor[E]) Strides() []int {
+	if t.strides != nil {
+		return t.strides
+	}
 	return t.TShape.CalcStrides()
 }
 
-func (t *tensor) Dims() int {
+func (t *tensor[E]) Dims() int {
 	return t.TShape.Dims()
 }
 
-func (t *tensor) Size() int {
+func (t *tensor[E]) Size() int {
 	return t.TShape.TotalSize()
 }
 
-func (t *tensor) data() *[]float64 {
-	return &t.Data
+func (t *tensor[E]) DataCopy() []float64 {
+	return convertSlice[E, float64](t.values())
 }
 
-func (t *tensor) DataCopy() []float64 {
-	resArr := make([]float64, len(t.Data))
-	copy(resArr, t.Data)
-	return resArr
+func (t *tensor[E]) ValueAt(index int) float64 {
+	return float64(t.Data[t.offset(index)])
 }
 
-func (t *tensor) ValueAt(index int) float64 {
-	return t.Data[index]
-}
-
-func (t *tensor) SetValueAt(index int, value float64) error {
+func (t *tensor[E]) SetValueAt(index int, value float64) error {
 	if index >= t.Shape().TotalSize() {
 		return errors.New("index out of range")
 	}
 
-	t.Data[index] = value
+	t.Data[t.offset(index)] = E(value)
 
 	return nil
 }
 
-func (t *tensor) AddValueAt(index int, value float64) error {
+func (t *tensor[E]) AddValueAt(index int, value float64) error {
 	if index >= t.Shape().TotalSize() {
 		return errors.New("index out of range")
 	}
 
-	t.Data[index] += value
+	t.Data[t.offset(index)] += E(value)
 
 	return nil
 }
 
+// Multiply multiplies two tensors elementwise, broadcasting their shapes.
 func Multiply(t_1, t_2 Tensor, inPlace bool) (Tensor, error) {
-	t1, ok := t_1.(*tensor)
-	if !ok {
-		return nil, errors.New("Invalid first tensor")
-	}
-
-	t2, ok := t_2.(*tensor)
-	if !ok {
-		return nil, errors.New("Invalid second tensor")
-	}
-
-	if !t1.Shape().Eq(t2.Shape()) {
-		return nil, errors.New("Shapes do not match")
+	if t_1 == nil || t_2 == nil {
+		return nil, errors.New("Tensors cannot be nil")
 	}
 
-	resTen := tensor{TShape: t1.Shape().Clone(), Data: make([]float64, t1.Size())}
-	for i := 0; i < len(t1.Data); i++ {
-		resTen.Data[i] = (t1.Data)[i] * (t2.Data)[i]
-	}
-
-	return &resTen, nil
+	return t_1.Multiply(t_2, inPlace)
 }
 
+// Divide divides two tensors elementwise, broadcasting their shapes.
 func Divide(t_1, t_2 Tensor, inPlace bool) (Tensor, error) {
-	t1, ok := t_1.(*tensor)
-	if !ok {
-		return nil, errors.New("Invalid first tensor")
-	}
-
-	t2, ok := t_2.(*tensor)
-	if !ok {
-		return nil, errors.New("Invalid second tensor")
-	}
-
-	if !t1.Shape().Eq(t2.Shape()) {
-		return nil, errors.New("Tensors not the same shape")
-	}
-
-	if inPlace {
-		for i := 0; i < len(t1.Data); i++ {
-			if t2.Data[i] == 0 {
-				return nil, errors.New("Cannot divide by zero")
-			}
-			t1.Data[i] /= t2.Data[i]
-		}
-		return t1, nil
+	if t_1 == nil || t_2 == nil {
+		return nil, errors.New("Tensors cannot be nil")
 	}
 
-	resTen := tensor{TShape: t1.Shape().Clone(), Data: make([]float64, t1.Size())}
-	for i := 0; i < len(t1.Data); i++ {
-		if t2.Data[i] == 0 {
-			return nil, errors.New("Cannot divide by zero")
-		}
-		resTen.Data[i] = t1.Data[i] / t2.Data[i]
-	}
-
-	return &resTen, nil
+	return t_1.Divide(t_2, inPlace)
 }
 
-func (t *tensor) MatMul(other Tensor) (Tensor, error) {
-	if !t.Shape().IsMatrix() || !other.Shape().IsMatrix() {
-		return nil, errors.New("Not both matrices")
-	}
-
-	if t.Shape().Cols() != other.Shape().Rows() {
-		return nil, errors.New("matrices dimensions not compatible for matrix multiplication")
-	}
-
-	tData := t.data()
-	oData := other.data()
-
-	m := t.Shape().Clone().Rows()     // Height of first matrix
-	n := t.Shape().Clone().Cols()     // Width of first matrix, height of second matrix
-	p := other.Shape().Clone().Cols() // Width of second matrix
-
-	resTen := ZerosTensor([]int{m, p})
-	resTenData := *resTen.data()
-
-	other.Transpose(true)
-
-	var wg sync.WaitGroup
-	for i := range m {
-		IxP := i * p
-		IxN := i * n
-		wg.Add(1)
-		go func(i int) {
-			defer wg.Done()
-			for j := range p {
-				JxN := j * n
-				sum := 0.0
-				for k := range n {
-					sum += (*tData)[IxN+k] * (*oData)[JxN+k]
-				}
-				resTenData[IxP+j] = sum
-			}
-		}(i)
+// Add adds two tensors elementwise, broadcasting their shapes.
+func Add(t_1, t_2 Tensor, inPlace bool) (Tensor, error) {
+	if t_1 == nil || t_2 == nil {
+		return nil, errors.New("Tensors cannot be nil")
 	}
-	wg.Wait()
-
-	other.Transpose(true)
 
-	return resTen, nil
+	return t_1.Add(t_2, inPlace)
 }
 
-func Add(t_1, t_2 Tensor, inPlace bool) (Tensor, error) {
-	return nil, nil
+// Subtract subtracts two tensors elementwise, broadcasting their shapes.
+func Subtract(t_1, t_2 Tensor, inPlace bool) (Tensor, error) {
+	if t_1 == nil || t_2 == nil {
+		return nil, errors.New("Tensors cannot be nil")
+	}
 
+	return t_1.Subtract(t_2, inPlace)
 }
 
+// Shuffle shuffles the batches of x and y together with the global RNG.
 func Shuffle(x, y Tensor) error {
-	n := x.Shape().Batches()
-	for i := n - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
-
-		xBatchI, err := x.BatchSlice(i, i+1)
-		if err != nil {
-			return nil
-		}
-
-		xBatchJ, err := x.BatchSlice(j, j+1)
-		if err != nil {
-			return err
-		}
+	return GlobalRNG().Shuffle(x, y)
+}
 
-		xBatchIData := xBatchI.DataCopy()
-		copy(*xBatchI.data(), *xBatchJ.data())
-		copy(*xBatchJ.data(), xBatchIData)
+func swapBatches(t Tensor, i, j int) error {
+	batchI, err := t.BatchSlice(i, i+1)
+	if err != nil {
+		return err
+	}
 
-		yBatchI, err := y.BatchSlice(i, i+1)
-		if err != nil {
-			return nil
-		}
+	batchJ, err := t.BatchSlice(j, j+1)
+	if err != nil {
+		return err
+	}
 
-		yBatchJ, err := y.BatchSlice(j, j+1)
-		if err != nil {
-			return err
-		}
+	batchICopy := batchI.AsType(batchI.DType())
+	batchI.assignFrom(batchJ)
+	batchJ.assignFrom(batchICopy)
 
-		yBatchIData := yBatchI.DataCopy()
-		copy(*yBatchI.data(), *yBatchJ.data())
-		copy(*yBatchJ.data(), yBatchIData)
-	}
 	return nil
 }
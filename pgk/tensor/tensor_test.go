@@ -0,0 +1,45 @@
+package tensor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReshape(t *testing.T) {
+	tests := []struct {
+		name  string
+		shape Shape
+		want  Shape
+	}{
+		{"matrix", Shape{3, 8}, Shape{1, 1, 3, 8}},
+		{"batches of matrices", Shape{2, 3}, Shape{4, 1, 2, 3}},
+		{"volume", Shape{2, 3, 4}, Shape{1, 2, 3, 4}},
+		{"4D", Shape{2, 1, 4, 3}, Shape{2, 1, 4, 3}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ten := Zeros(Shape{4, 6}, Float64)
+			if err := ten.Reshape(tc.shape); err != nil {
+				t.Fatal(err)
+			}
+			if !ten.Shape().DeepEq(tc.want) {
+				t.Errorf("shape %v, want %v", ten.Shape(), tc.want)
+			}
+		})
+	}
+}
+
+func TestReshapeSizeError(t *testing.T) {
+	ten := Zeros(Shape{4, 6}, Float64)
+
+	err := ten.Reshape(Shape{5, 5})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	// The error names the size of the tensor and the shape asked for
+	if !strings.Contains(err.Error(), "24") || !strings.Contains(err.Error(), "[5 5]") {
+		t.Errorf("error %q does not name the size 24 and the shape [5 5]", err)
+	}
+}
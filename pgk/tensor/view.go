@@ -0,0 +1,120 @@
+package tensor
+
+// A tensor is a view onto its Data slice. The slice starts at the first
+// element of the view, which takes the place of an offset into the shared
+// buffer, and strides tell how far to move in Data for a step along each
+// dimension. Tensors without strides are laid out contiguously in row major
+// order.
+
+// newView creates a tensor sharing t's data, starting at start and walked with
+// the given shape and strides. The buffer of t is marked as shared, so Release
+// never hands it to another tensor while the view may still use it.
+func (t *tensor[E]) newView(start int, shape Shape, strides []int) *tensor[E] {
+	end := start + 1
+	for d, dim := range shape {
+		if dim == 0 {
+			end = start
+			break
+		}
+		end += (dim - 1) * strides[d]
+	}
+
+	t.shared.Store(true)
+	return &tensor[E]{TShape: shape, Data: t.Data[start:end:end], strides: strides}
+}
+
+// isContiguous reports if the values of the tensor are laid out in row major
+// order with no gaps, dimensions of size 1 can have any stride.
+func (t *tensor[E]) isContiguous() bool {
+	if t.strides == nil {
+		return true
+	}
+
+	expected := 1
+	for d := len(t.TShape) - 1; d >= 0; d-- {
+		if t.TShape[d] != 1 && t.strides[d] != expected {
+			return false
+		}
+		expected *= t.TShape[d]
+	}
+
+	return true
+}
+
+// offset converts a row major index into the tensor to an index into Data.
+func (t *tensor[E]) offset(index int) int {
+	if t.isContiguous() {
+		return index
+	}
+
+	offset := 0
+	for d := len(t.TShape) - 1; d >= 0; d-- {
+		offset += (index % t.TShape[d]) * t.strides[d]
+		index /= t.TShape[d]
+	}
+
+	return offset
+}
+
+// values returns the values of the tensor in row major order. For strided
+// views the values are gathered into a new slice, so the result should only
+// be written to when the tensor is contiguous.
+func (t *tensor[E]) values() []E {
+	size := t.Size()
+	if t.isContiguous() {
+		return t.Data[:size]
+	}
+
+	resData := make([]E, size)
+	strides := [][]int{t.strides}
+	broadcastLoop(t.TShape, strides, func(i int, offsets []int) {
+		resData[i] = t.Data[offsets[0]]
+	})
+
+	return resData
+}
+
+// buffer returns the values of the tensor in a row major slice that can be
+// written to, followed by a call to flush to store the result in the tensor.
+func (t *tensor[E]) buffer() []E {
+	return t.values()
+}
+
+// flush writes a slice returned by buffer back into a strided view.
+func (t *tensor[E]) flush(buf []E) {
+	if t.isContiguous() {
+		return
+	}
+	t.assign(buf)
+}
+
+// assign copies row major values into the tensor.
+func (t *tensor[E]) assign(values []E) {
+	if t.isContiguous() {
+		copy(t.Data[:t.Size()], values)
+		return
+	}
+
+	strides := [][]int{t.strides}
+	broadcastLoop(t.TShape, strides, func(i int, offsets []int) {
+		t.Data[offsets[0]] = values[i]
+	})
+}
+
+// alignedStrides right aligns the strides of the tensor to a shape with dims
+// dimensions, the added leading dimensions have to be of size 1.
+func (t *tensor[E]) alignedStrides(dims int) []int {
+	strides := t.Strides()
+	resStrides := make([]int, dims)
+	copy(resStrides[max(dims-len(strides), 0):], strides[max(len(strides)-dims, 0):])
+	return resStrides
+}
+
+func (t *tensor[E]) view(start int, shape Shape, strides []int) Tensor {
+	return t.newView(start, shape, strides)
+}
+
+// assignFrom copies the values of other, which has to be the same size, into t.
+func (t *tensor[E]) assignFrom(other Tensor) {
+	t.assign(asType[E](other).values())
+}
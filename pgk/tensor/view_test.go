@@ -0,0 +1,127 @@
+package tensor
+
+import (
+	"testing"
+)
+
+// viewCase makes a view of a fresh base tensor of arange values, so the
+// values of the view are the positions in the base it reads from.
+type viewCase struct {
+	name string
+	view func() (base, view Tensor, err error)
+}
+
+var viewCases = []viewCase{
+	{"slice", func() (Tensor, Tensor, error) {
+		base := arange(3, 4)
+		view, err := base.Slice(2, 7)
+		return base, view, err
+	}},
+	{"transpose", func() (Tensor, Tensor, error) {
+		base := arange(3, 4)
+		return base, base.Transpose(false), nil
+	}},
+	{"permute", func() (Tensor, Tensor, error) {
+		base := arange(2, 3, 4)
+		view, err := base.Permute(2, 0, 1)
+		return base, view, err
+	}},
+	{"region slice", func() (Tensor, Tensor, error) {
+		base := arange(4, 5)
+		view, _, err := base.RegionSlice(1, 2, 2, 3)
+		return base, view, err
+	}},
+	{"batch slice", func() (Tensor, Tensor, error) {
+		base := arange(4, 2, 2, 3)
+		view, err := base.BatchSlice(1, 3)
+		return base, view, err
+	}},
+	{"region slice of a transpose", func() (Tensor, Tensor, error) {
+		base := arange(4, 5)
+		view, _, err := base.Transpose(false).RegionSlice(1, 1, 3, 2)
+		return base, view, err
+	}},
+}
+
+func TestViewWritesReachBase(t *testing.T) {
+	for _, tc := range viewCases {
+		t.Run(tc.name, func(t *testing.T) {
+			base, view, err := tc.view()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			covered := map[int]bool{}
+			for _, val := range Values[float64](view) {
+				covered[int(val)] = true
+			}
+
+			// An in place operation and a single write both go to the base
+			view.ScalarMultiply(-1, true)
+			first := int(-view.ValueAt(0))
+			if err := view.SetValueAt(0, 100); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, val := range Values[float64](base) {
+				want := float64(i)
+				switch {
+				case i == first:
+					want = 100
+				case covered[i]:
+					want = -want
+				}
+
+				if val != want {
+					t.Fatalf("value %d of the base is %v, want %v", i, val, want)
+				}
+			}
+		})
+	}
+}
+
+func TestContiguousCopiesDetach(t *testing.T) {
+	copies := []struct {
+		name string
+		copy func(view Tensor) (Tensor, error)
+	}{
+		{"as type", func(view Tensor) (Tensor, error) { return view.AsType(Float64), nil }},
+		{"as float32", func(view Tensor) (Tensor, error) { return view.AsType(Float32), nil }},
+		{"data copy", func(view Tensor) (Tensor, error) {
+			return FromSlice(view.Shape().Clone(), view.DataCopy())
+		}},
+		{"concatenate", func(view Tensor) (Tensor, error) { return Concatenate(0, view, view) }},
+		{"slice", func(view Tensor) (Tensor, error) { return view.Slice(0, view.Size()) }},
+		{"reshape", func(view Tensor) (Tensor, error) {
+			return view, view.Reshape(Shape{view.Size(), 1})
+		}},
+	}
+
+	for _, tc := range copies {
+		t.Run(tc.name, func(t *testing.T) {
+			base := arange(3, 4)
+			view := base.Transpose(false)
+
+			res, err := tc.copy(view)
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := Values[float64](res)
+
+			base.ScalarMultiply(-1, true)
+			res.ScalarAdd(100, true)
+
+			for i, val := range Values[float64](base) {
+				if val != -float64(i) {
+					t.Fatalf("writing the copy changed value %d of the base to %v", i, val)
+				}
+			}
+
+			for i, val := range Values[float64](res) {
+				if val != before[i]+100 {
+					t.Fatalf("writing the base changed value %d of the copy to %v, want %v", i, val-100, before[i])
+				}
+			}
+		})
+	}
+}